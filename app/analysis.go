@@ -0,0 +1,59 @@
+package app
+
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+)
+
+const AnalysisTtl = time.Minute * 30
+
+type AnalysisCache struct {
+	store *ttlcache.Cache[string, []RankTile]
+}
+
+func MakeAnalysisCache() AnalysisCache {
+	return AnalysisCache{store: ttlcache.New[string, []RankTile]()}
+}
+
+// sortRankTiles orders the tiles from the best to the worst heuristic for the player to move
+func sortRankTiles(tiles []RankTile) []RankTile {
+	sorted := slices.Clone(tiles)
+	// ties are broken by tile so the order doesn't depend on the order the moves were searched
+	slices.SortStableFunc(sorted, func(a, b RankTile) int {
+		return cmp.Or(cmp.Compare(b.H, a.H), cmp.Compare(a.Row, b.Row), cmp.Compare(a.Col, b.Col))
+	})
+	return sorted
+}
+
+// CoachCandidates is the number of the bot's candidate replies shown to a player being coached
+const CoachCandidates = 3
+
+// topRankTiles returns up to count of the highest ranked tiles, best first
+func topRankTiles(tiles []RankTile, count int) []RankTile {
+	sorted := sortRankTiles(tiles)
+	return sorted[:min(len(sorted), count)]
+}
+
+func (ac AnalysisCache) Set(board OthelloBoard, tiles []RankTile) {
+	ac.store.Set(board.MarshalString(), sortRankTiles(tiles), AnalysisTtl)
+}
+
+func (ac AnalysisCache) Get(board OthelloBoard) ([]RankTile, bool) {
+	item := ac.store.Get(board.MarshalString())
+	if item == nil {
+		return nil, false
+	}
+	return item.Value(), true
+}
+
+// GetBest finds the best move of a recent analysis for the exact position, or nil if the position wasn't analyzed
+func (ac AnalysisCache) GetBest(board OthelloBoard) []RankTile {
+	tiles, ok := ac.Get(board)
+	if !ok || len(tiles) == 0 {
+		return nil
+	}
+	return tiles[:1]
+}
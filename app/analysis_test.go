@@ -0,0 +1,40 @@
+package app
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAnalysisCache_GetBest(t *testing.T) {
+	ac := MakeAnalysisCache()
+
+	board := MakeInitialBoard()
+	otherBoard := board.MakeMoved(ParseTile("d3"))
+
+	ac.Set(board, []RankTile{
+		{Tile: ParseTile("c4"), H: -1},
+		{Tile: ParseTile("d3"), H: 4},
+		{Tile: ParseTile("e6"), H: 2},
+		{Tile: ParseTile("f5"), H: 0},
+	})
+
+	assert.Equal(t, []RankTile{{Tile: ParseTile("d3"), H: 4}}, ac.GetBest(MakeInitialBoard()))
+	assert.Nil(t, ac.GetBest(otherBoard))
+}
+
+func TestTopRankTiles(t *testing.T) {
+	tiles := []RankTile{
+		{Tile: ParseTile("c4"), H: -1},
+		{Tile: ParseTile("d3"), H: 4},
+		{Tile: ParseTile("e6"), H: 2},
+		{Tile: ParseTile("f5"), H: 0},
+	}
+
+	assert.Equal(t, []RankTile{
+		{Tile: ParseTile("d3"), H: 4},
+		{Tile: ParseTile("e6"), H: 2},
+		{Tile: ParseTile("f5"), H: 0},
+	}, topRankTiles(tiles, CoachCandidates))
+	assert.Equal(t, []RankTile{{Tile: ParseTile("d3"), H: 4}, {Tile: ParseTile("c4"), H: -1}}, topRankTiles(tiles[:2], CoachCandidates))
+}
@@ -1,10 +1,13 @@
 package app
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
 	"log"
+	"math/bits"
 	"math/rand/v2"
+	"slices"
 	"strconv"
 	"strings"
 	"unicode"
@@ -42,12 +45,11 @@ func ParseTileSafe(s string) (Tile, error) {
 	}
 
 	// Example "a1" → Col: 0, Row: 0 (assuming standard preMoves)
-	ch := s[0]
-	ch = uint8(unicode.ToUpper(rune(ch))) // we don't need to handle
-	col := int(ch - 'A')
-	row := int(s[1] - '1')
+	// the chars are converted before subtracting so a char below 'A' or '1' is negative instead of wrapping around
+	col := int(unicode.ToUpper(rune(s[0]))) - 'A'
+	row := int(s[1]) - '1'
 
-	if row < 0 || row > BoardSize || col < 0 || col > BoardSize {
+	if !InBounds(row, col) {
 		return Tile{}, ErrInvalidTile
 	}
 	return Tile{Row: row, Col: col}, nil
@@ -66,9 +68,35 @@ func (t Tile) String() string {
 	return fmt.Sprintf("%c%d", rune(t.Col)+'A', t.Row+1)
 }
 
+func (t Tile) IsCorner() bool {
+	return (t.Row == 0 || t.Row == BoardSize-1) && (t.Col == 0 || t.Col == BoardSize-1)
+}
+
+func (t Tile) IsEdge() bool {
+	return t.Row == 0 || t.Row == BoardSize-1 || t.Col == 0 || t.Col == BoardSize-1
+}
+
+// IsXSquare checks if the tile is diagonally adjacent to a corner
+func (t Tile) IsXSquare() bool {
+	return (t.Row == 1 || t.Row == BoardSize-2) && (t.Col == 1 || t.Col == BoardSize-2)
+}
+
+func (t Tile) Neighbors() []Tile {
+	var tiles []Tile
+	for _, direction := range Directions {
+		row := t.Row + direction[0]
+		col := t.Col + direction[1]
+		if InBounds(row, col) {
+			tiles = append(tiles, Tile{Row: row, Col: col})
+		}
+	}
+	return tiles
+}
+
 type RankTile struct {
 	Tile
-	H float64
+	H    float64
+	Book bool // the engine found the move in its opening book instead of searching
 }
 
 func (t RankTile) String() string {
@@ -93,12 +121,19 @@ func ParseRankTile(s1 string, s2 string) (RankTile, error) {
 	return RankTile{Tile: tile, H: h}, nil
 }
 
+// OthelloBoard is a value type, copying a board copies every square so a copy can be moved without changing the
+// original, the methods take a pointer only so the board isn't copied on each call
 type OthelloBoard struct {
 	IsBlackMove bool
 	boardA      uint64
 	boardB      uint64
 }
 
+// Equal checks if both boards have the same squares and side to move
+func (b *OthelloBoard) Equal(o OthelloBoard) bool {
+	return b.boardA == o.boardA && b.boardB == o.boardB && b.IsBlackMove == o.IsBlackMove
+}
+
 var InitialBoard = MakeInitialBoard()
 
 func MakeInitialBoard() OthelloBoard {
@@ -148,6 +183,10 @@ func (b *OthelloBoard) BlackScore() int {
 	return b.countDiscs(Black)
 }
 
+func (b *OthelloBoard) CountEmpty() int {
+	return b.countDiscs(Empty)
+}
+
 func (b *OthelloBoard) countDiscs(color byte) int {
 	discs := 0
 	for _, tile := range AllTiles {
@@ -167,6 +206,45 @@ func (b *OthelloBoard) FindCurrentMoves() []Tile {
 	return moves
 }
 
+// IsLegalMove checks if the player to move can play the tile by only scanning the directions out from the tile for a
+// flank, instead of finding every move
+func (b *OthelloBoard) IsLegalMove(tile Tile) bool {
+	if !InBounds(tile.Row, tile.Col) || b.GetSquareByTile(tile) != Empty {
+		return false
+	}
+
+	currColor, oppColor := byte(White), byte(Black)
+	if b.IsBlackMove {
+		currColor, oppColor = Black, White
+	}
+
+	for _, direction := range Directions {
+		row := tile.Row + direction[0]
+		col := tile.Col + direction[1]
+
+		// at least one opponent disc must be between the tile and the flanking disc
+		between := 0
+		for InBounds(row, col) && b.GetSquare(row, col) == oppColor {
+			between++
+			row += direction[0]
+			col += direction[1]
+		}
+		if between > 0 && InBounds(row, col) && b.GetSquare(row, col) == currColor {
+			return true
+		}
+	}
+	return false
+}
+
+// HasCurrentMoves checks if the player to move has a legal move without enumerating them
+func (b *OthelloBoard) HasCurrentMoves() bool {
+	black, white := b.Bitboards()
+	if b.IsBlackMove {
+		return HasMovesBitboard(black, white)
+	}
+	return HasMovesBitboard(white, black)
+}
+
 func (b *OthelloBoard) CountPotentialMoves(color byte) int {
 	count := 0
 	b.OnPotentialMoves(color, func(tile Tile) {
@@ -175,6 +253,201 @@ func (b *OthelloBoard) CountPotentialMoves(color byte) int {
 	return count
 }
 
+// Mobility counts the moves for black and white using the bitboards for each color
+func (b *OthelloBoard) Mobility() (int, int) {
+	black, white := b.Bitboards()
+	return bits.OnesCount64(MovesBitboard(black, white)), bits.OnesCount64(MovesBitboard(white, black))
+}
+
+func (b *OthelloBoard) MobilityFor(color byte) int {
+	black, white := b.Bitboards()
+	if color == Black {
+		return bits.OnesCount64(MovesBitboard(black, white))
+	}
+	return bits.OnesCount64(MovesBitboard(white, black))
+}
+
+// CountFrontierDiscs counts the discs of the color adjacent to an empty square
+func (b *OthelloBoard) CountFrontierDiscs(color byte) int {
+	black, white := b.Bitboards()
+	empty := ^(black | white)
+	if color == Black {
+		return bits.OnesCount64(FrontierBitboard(black, empty))
+	}
+	return bits.OnesCount64(FrontierBitboard(white, empty))
+}
+
+// compactEvenBits packs the even bits of x into the lower 32 bits
+func compactEvenBits(x uint64) uint64 {
+	x &= 0x5555555555555555
+	x = (x | (x >> 1)) & 0x3333333333333333
+	x = (x | (x >> 2)) & 0x0f0f0f0f0f0f0f0f
+	x = (x | (x >> 4)) & 0x00ff00ff00ff00ff
+	x = (x | (x >> 8)) & 0x0000ffff0000ffff
+	x = (x | (x >> 16)) & 0x00000000ffffffff
+	return x
+}
+
+// Bitboards returns a bitboard of the black and white discs, bit row*BoardSize+col is set for a disc on that tile
+func (b *OthelloBoard) Bitboards() (uint64, uint64) {
+	// each square is packed into 2 bits, the low bit is set for white discs and the high bit is set for black discs
+	black := compactEvenBits(b.boardA>>1) | compactEvenBits(b.boardB>>1)<<32
+	white := compactEvenBits(b.boardA) | compactEvenBits(b.boardB)<<32
+	return black, white
+}
+
+// spreadEvenBits is the inverse of compactEvenBits, it spreads the lower 32 bits of x into the even bits
+func spreadEvenBits(x uint64) uint64 {
+	x &= 0x00000000ffffffff
+	x = (x | (x << 16)) & 0x0000ffff0000ffff
+	x = (x | (x << 8)) & 0x00ff00ff00ff00ff
+	x = (x | (x << 4)) & 0x0f0f0f0f0f0f0f0f
+	x = (x | (x << 2)) & 0x3333333333333333
+	x = (x | (x << 1)) & 0x5555555555555555
+	return x
+}
+
+// MakeBoardFromBitboards creates a board from a bitboard of the black and white discs, the inverse of Bitboards
+func MakeBoardFromBitboards(black uint64, white uint64, isBlackMove bool) OthelloBoard {
+	return OthelloBoard{
+		IsBlackMove: isBlackMove,
+		boardA:      spreadEvenBits(white) | spreadEvenBits(black)<<1,
+		boardB:      spreadEvenBits(white>>32) | spreadEvenBits(black>>32)<<1,
+	}
+}
+
+// flipBitboardVertical maps the tile at row, col to BoardSize-1-row, col
+func flipBitboardVertical(x uint64) uint64 {
+	return bits.ReverseBytes64(x)
+}
+
+// mirrorBitboardHorizontal maps the tile at row, col to row, BoardSize-1-col
+func mirrorBitboardHorizontal(x uint64) uint64 {
+	x = ((x >> 1) & 0x5555555555555555) | ((x & 0x5555555555555555) << 1)
+	x = ((x >> 2) & 0x3333333333333333) | ((x & 0x3333333333333333) << 2)
+	x = ((x >> 4) & 0x0f0f0f0f0f0f0f0f) | ((x & 0x0f0f0f0f0f0f0f0f) << 4)
+	return x
+}
+
+// transposeBitboard maps the tile at row, col to col, row
+func transposeBitboard(x uint64) uint64 {
+	t := 0x0f0f0f0f00000000 & (x ^ (x << 28))
+	x ^= t ^ (t >> 28)
+	t = 0x3333000033330000 & (x ^ (x << 14))
+	x ^= t ^ (t >> 14)
+	t = 0x5500550055005500 & (x ^ (x << 7))
+	x ^= t ^ (t >> 7)
+	return x
+}
+
+func (b *OthelloBoard) transformed(transform func(uint64) uint64) OthelloBoard {
+	black, white := b.Bitboards()
+	return MakeBoardFromBitboards(transform(black), transform(white), b.IsBlackMove)
+}
+
+// Transpose reflects the board across the a1-h8 diagonal
+func (b *OthelloBoard) Transpose() OthelloBoard {
+	return b.transformed(transposeBitboard)
+}
+
+// Symmetries are the 8 rotations and reflections of the board, the first is the board itself
+func (b *OthelloBoard) Symmetries() [8]OthelloBoard {
+	var boards [8]OthelloBoard
+	for i := range boards {
+		boards[i] = b.transformed(func(x uint64) uint64 {
+			if i&1 != 0 {
+				x = transposeBitboard(x)
+			}
+			if i&2 != 0 {
+				x = flipBitboardVertical(x)
+			}
+			if i&4 != 0 {
+				x = mirrorBitboardHorizontal(x)
+			}
+			return x
+		})
+	}
+	return boards
+}
+
+// Canonical is the symmetry of the board with the smallest black then white bitboards, every rotation and reflection
+// of a position has the same canonical board
+func (b *OthelloBoard) Canonical() OthelloBoard {
+	symmetries := b.Symmetries()
+	return slices.MinFunc(symmetries[:], func(a OthelloBoard, b OthelloBoard) int {
+		aBlack, aWhite := a.Bitboards()
+		bBlack, bWhite := b.Bitboards()
+		return cmp.Or(cmp.Compare(aBlack, bBlack), cmp.Compare(aWhite, bWhite))
+	})
+}
+
+const (
+	notColA uint64 = 0xfefefefefefefefe
+	notColH uint64 = 0x7f7f7f7f7f7f7f7f
+	allCols uint64 = 0xffffffffffffffff
+)
+
+// bitDirections are the shifts for each direction on a bitboard, and the mask removing discs that wrapped around a row
+var bitDirections = []struct {
+	shift int
+	mask  uint64
+}{
+	{shift: 1, mask: notColA},
+	{shift: -1, mask: notColH},
+	{shift: BoardSize, mask: allCols},
+	{shift: -BoardSize, mask: allCols},
+	{shift: BoardSize + 1, mask: notColA},
+	{shift: BoardSize - 1, mask: notColH},
+	{shift: -BoardSize + 1, mask: notColA},
+	{shift: -BoardSize - 1, mask: notColH},
+}
+
+func shiftBitboard(x uint64, shift int) uint64 {
+	if shift > 0 {
+		return x << shift
+	}
+	return x >> -shift
+}
+
+// MovesBitboard finds a bitboard of the moves for the own discs, flanking the opp discs
+func MovesBitboard(own uint64, opp uint64) uint64 {
+	empty := ^(own | opp)
+	var moves uint64
+	for _, d := range bitDirections {
+		x := shiftBitboard(own, d.shift) & d.mask & opp
+		// a line can flank at most BoardSize-2 discs
+		for range BoardSize - 3 {
+			x |= shiftBitboard(x, d.shift) & d.mask & opp
+		}
+		moves |= shiftBitboard(x, d.shift) & d.mask & empty
+	}
+	return moves
+}
+
+// HasMovesBitboard is MovesBitboard that stops at the first direction with a move
+func HasMovesBitboard(own uint64, opp uint64) bool {
+	empty := ^(own | opp)
+	for _, d := range bitDirections {
+		x := shiftBitboard(own, d.shift) & d.mask & opp
+		for range BoardSize - 3 {
+			x |= shiftBitboard(x, d.shift) & d.mask & opp
+		}
+		if shiftBitboard(x, d.shift)&d.mask&empty != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// FrontierBitboard finds a bitboard of the discs with an empty square in any direction
+func FrontierBitboard(discs uint64, empty uint64) uint64 {
+	var nextToEmpty uint64
+	for _, d := range bitDirections {
+		nextToEmpty |= shiftBitboard(empty, d.shift) & d.mask
+	}
+	return discs & nextToEmpty
+}
+
 func (b *OthelloBoard) OnCurrentMoves(onMove func(Tile)) {
 	var currColor byte
 	if b.IsBlackMove {
@@ -412,6 +685,51 @@ func MarshalMoveList(moveList []Move) string {
 	return sb.String()
 }
 
+// FormatMoveList writes the moves as numbered black and white pairs such as "1. B D3 W C5", the moves are replayed so
+// passes take up the turn of the side that passed
+func FormatMoveList(moveList []Move) string {
+	var sb strings.Builder
+
+	turn := 0
+	whiteMoved := true
+	write := func(isBlack bool, move string) {
+		if isBlack || whiteMoved {
+			if turn > 0 {
+				sb.WriteRune('\n')
+			}
+			turn++
+			fmt.Fprintf(&sb, "%d.", turn)
+			if !isBlack {
+				sb.WriteString(" B --")
+			}
+		}
+		if isBlack {
+			fmt.Fprintf(&sb, " B %s", move)
+		} else {
+			fmt.Fprintf(&sb, " W %s", move)
+		}
+		whiteMoved = !isBlack
+	}
+
+	board := InitialBoard
+	for _, move := range moveList {
+		if !move.Pass && len(board.FindCurrentMoves()) == 0 {
+			// the side to move had no moves, but the pass wasn't recorded in the move list
+			write(board.IsBlackMove, "PA")
+			board.IsBlackMove = !board.IsBlackMove
+		}
+		write(board.IsBlackMove, move.String())
+
+		if move.Pass {
+			board.IsBlackMove = !board.IsBlackMove
+		} else {
+			board.MakeMove(move.Tile)
+		}
+	}
+
+	return sb.String()
+}
+
 func (b *OthelloBoard) MarshalString() string {
 	var sb strings.Builder
 
@@ -453,11 +771,16 @@ func (b *OthelloBoard) MarshalString() string {
 
 var ErrBoardUnmarshal = errors.New("failed to unmarshal board from string")
 
+// UnmarshalBoard parses a board written by MarshalString into a new board, the discs and runs of empty squares must
+// fill every square of the board exactly
 func UnmarshalBoard(str string) (OthelloBoard, error) {
 	var b OthelloBoard
 	tileIndex := 0
 	for strIndex := 0; strIndex < len(str); {
 		ch := str[strIndex]
+		if strIndex > 1 && tileIndex >= BoardSize*BoardSize {
+			return OthelloBoard{}, fmt.Errorf("%w: more than %d squares", ErrBoardUnmarshal, BoardSize*BoardSize)
+		}
 		switch strIndex {
 		case 0:
 			switch ch {
@@ -496,12 +819,15 @@ func UnmarshalBoard(str string) (OthelloBoard, error) {
 				}
 				numStr := str[firstIndex:strIndex]
 				num, err := strconv.Atoi(numStr)
-				if err != nil {
+				if err != nil || num <= 0 {
 					return b, ErrBoardUnmarshal
 				}
 				tileIndex += num
 			}
 		}
 	}
+	if tileIndex != BoardSize*BoardSize {
+		return OthelloBoard{}, fmt.Errorf("%w: expected %d squares, got %d", ErrBoardUnmarshal, BoardSize*BoardSize, tileIndex)
+	}
 	return b, nil
 }
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"github.com/stretchr/testify/assert"
 	"slices"
+	"strings"
 	"testing"
 )
 
@@ -152,6 +153,42 @@ func TestMoveList_UnmarshalStrings(t *testing.T) {
 	}
 }
 
+func TestMoveList_Format(t *testing.T) {
+	// the tail of the seed-9 random game, white passes before black's last three moves
+	moveListStr := "F5,D6,C4,G5,D7,E3,H5,G6,G7,B4,B3,E6,B5,A4,D3,A2,F6,F3,F4,G4,C5,B6,G2,C3,D2,F7,H4,H8,A5,B2,C7,D8,G8,E2,F2,A3,C8,G1,B1,C1,C2,E7,D1,B8,F8,E1,A1,A6,A7,E8,C6,H2,H1,G3,H3,B7,A8,PA,H7,H6,F1"
+	moveList, err := UnmarshalMoveList(moveListStr)
+	if err != nil {
+		t.Fatalf("failed to unmarshal move list: %v", err)
+	}
+
+	var passlessList []Move
+	for _, move := range moveList {
+		if !move.Pass {
+			passlessList = append(passlessList, move)
+		}
+	}
+
+	expTail := "28. B H3 W B7\n29. B A8 W PA\n30. B H7 W H6\n31. B F1"
+
+	type Test struct {
+		moveList []Move
+		exp      string
+	}
+	tests := []Test{
+		{moveList: nil, exp: ""},
+		{moveList: moveList[:3], exp: "1. B F5 W D6\n2. B C4"},
+		{moveList: moveList, exp: expTail},
+		{moveList: passlessList, exp: expTail},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			str := FormatMoveList(test.moveList)
+			assert.True(t, strings.HasSuffix(str, test.exp), "expected %s to end with %s", str, test.exp)
+		})
+	}
+}
+
 func TestBoard_MarshalString(t *testing.T) {
 	type Test struct {
 		Moves  []Tile
@@ -199,3 +236,302 @@ func TestBoard_MarshalString(t *testing.T) {
 		})
 	}
 }
+
+func TestUnmarshalBoard_SquareCount(t *testing.T) {
+	type Test struct {
+		String string
+		expErr bool
+	}
+	tests := []Test{
+		{String: "b+27wb6bw27"},
+		{String: "w+64"},
+		// too short
+		{String: "b+27wb6bw26", expErr: true},
+		{String: "b+27wb6bw", expErr: true},
+		{String: "b+", expErr: true},
+		{String: "", expErr: true},
+		// too long
+		{String: "b+27wb6bw28", expErr: true},
+		{String: "b+27wb6bw27b", expErr: true},
+		{String: "w+64w", expErr: true},
+		{String: "b+0wb", expErr: true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			_, err := UnmarshalBoard(test.String)
+			if test.expErr {
+				assert.ErrorIs(t, err, ErrBoardUnmarshal)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestBoard_Mobility(t *testing.T) {
+	for i := range 60 {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			board, _ := RandomBoard(i)
+
+			blackBoard := board
+			blackBoard.IsBlackMove = true
+			whiteBoard := board
+			whiteBoard.IsBlackMove = false
+
+			expBlack := blackBoard.CountPotentialMoves(Black)
+			expWhite := whiteBoard.CountPotentialMoves(White)
+
+			black, white := board.Mobility()
+			assert.Equal(t, expBlack, black)
+			assert.Equal(t, expWhite, white)
+			assert.Equal(t, expBlack, board.MobilityFor(Black))
+			assert.Equal(t, expWhite, board.MobilityFor(White))
+		})
+	}
+}
+
+func TestBoard_HasCurrentMoves(t *testing.T) {
+	for i := range 61 {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			board, _ := RandomBoard(i)
+
+			// RandomBoard stops at a position without moves, so both sides of finished games are checked as well
+			for _, isBlackMove := range []bool{true, false} {
+				board.IsBlackMove = isBlackMove
+				assert.Equal(t, len(board.FindCurrentMoves()) > 0, board.HasCurrentMoves())
+			}
+		})
+	}
+
+	full := OthelloBoard{}
+	for _, tile := range AllTiles {
+		full.SetSquareByTile(tile, Black)
+	}
+	assert.False(t, full.HasCurrentMoves())
+}
+
+func TestBoard_IsLegalMove(t *testing.T) {
+	for i := range 61 {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			board, _ := RandomBoard(i)
+
+			for _, isBlackMove := range []bool{true, false} {
+				board.IsBlackMove = isBlackMove
+				moves := board.FindCurrentMoves()
+				for _, tile := range AllTiles {
+					assert.Equal(t, slices.Contains(moves, tile), board.IsLegalMove(tile), "tile %s", tile)
+				}
+			}
+		})
+	}
+
+	board := MakeInitialBoard()
+	assert.False(t, board.IsLegalMove(Tile{Row: -1, Col: 3}))
+	assert.False(t, board.IsLegalMove(Tile{Row: 3, Col: BoardSize}))
+}
+
+func TestBoard_CountFrontierDiscs(t *testing.T) {
+	// black fills the corner so only the edge of the block touches empty squares, white discs are all isolated
+	board := OthelloBoard{IsBlackMove: true}
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			board.SetSquare(row, col, Black)
+		}
+	}
+	for _, tile := range []Tile{{Row: 5, Col: 5}, {Row: 7, Col: 7}, {Row: 5, Col: 1}} {
+		board.SetSquareByTile(tile, White)
+	}
+
+	initial := MakeInitialBoard()
+	assert.Equal(t, 2, initial.CountFrontierDiscs(Black))
+	assert.Equal(t, 2, initial.CountFrontierDiscs(White))
+	assert.Equal(t, 5, board.CountFrontierDiscs(Black))
+	assert.Equal(t, 3, board.CountFrontierDiscs(White))
+
+	// every disc on a board without empty squares is interior
+	full := OthelloBoard{}
+	for _, tile := range AllTiles {
+		full.SetSquareByTile(tile, White)
+	}
+	assert.Equal(t, 0, full.CountFrontierDiscs(White))
+}
+
+func BenchmarkBoard_CountPotentialMoves(b *testing.B) {
+	board, _ := RandomBoard(30)
+	for b.Loop() {
+		blackBoard := board
+		blackBoard.IsBlackMove = true
+		whiteBoard := board
+		whiteBoard.IsBlackMove = false
+		blackBoard.CountPotentialMoves(Black)
+		whiteBoard.CountPotentialMoves(White)
+	}
+}
+
+func BenchmarkBoard_HasCurrentMoves(b *testing.B) {
+	board, _ := RandomBoard(30)
+	for b.Loop() {
+		board.HasCurrentMoves()
+	}
+}
+
+func BenchmarkBoard_FindCurrentMoves(b *testing.B) {
+	board, _ := RandomBoard(30)
+	for b.Loop() {
+		board.FindCurrentMoves()
+	}
+}
+
+func BenchmarkBoard_Mobility(b *testing.B) {
+	board, _ := RandomBoard(30)
+	for b.Loop() {
+		board.Mobility()
+	}
+}
+
+func TestTile_Classify(t *testing.T) {
+	type Test struct {
+		tile         string
+		isCorner     bool
+		isEdge       bool
+		isXSquare    bool
+		expNeighbors []string
+	}
+	tests := []Test{
+		{tile: "a1", isCorner: true, isEdge: true, expNeighbors: []string{"a2", "b1", "b2"}},
+		{tile: "h8", isCorner: true, isEdge: true, expNeighbors: []string{"g7", "g8", "h7"}},
+		{tile: "b1", isEdge: true, expNeighbors: []string{"a1", "a2", "b2", "c1", "c2"}},
+		{tile: "g7", isXSquare: true, expNeighbors: []string{"f6", "f7", "f8", "g6", "g8", "h6", "h7", "h8"}},
+		{tile: "d4", expNeighbors: []string{"c3", "c4", "c5", "d3", "d5", "e3", "e4", "e5"}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.tile, func(t *testing.T) {
+			tile := ParseTile(test.tile)
+			assert.Equal(t, test.isCorner, tile.IsCorner())
+			assert.Equal(t, test.isEdge, tile.IsEdge())
+			assert.Equal(t, test.isXSquare, tile.IsXSquare())
+
+			neighbors := tile.Neighbors()
+			sortTiles(neighbors)
+
+			var expNeighbors []Tile
+			for _, neighbor := range test.expNeighbors {
+				expNeighbors = append(expNeighbors, ParseTile(neighbor))
+			}
+			assert.Equal(t, expNeighbors, neighbors)
+		})
+	}
+}
+
+func TestBoard_FromBitboards(t *testing.T) {
+	board, _ := RandomBoard(30)
+	black, white := board.Bitboards()
+	assert.Equal(t, board, MakeBoardFromBitboards(black, white, board.IsBlackMove))
+}
+
+func TestBoard_Symmetries(t *testing.T) {
+	board, _ := RandomBoard(30)
+
+	transforms := []func(row, col int) (int, int){
+		func(row, col int) (int, int) { return row, col },
+		func(row, col int) (int, int) { return col, row },
+		func(row, col int) (int, int) { return BoardSize - 1 - row, col },
+		func(row, col int) (int, int) { return BoardSize - 1 - col, row },
+		func(row, col int) (int, int) { return row, BoardSize - 1 - col },
+		func(row, col int) (int, int) { return col, BoardSize - 1 - row },
+		func(row, col int) (int, int) { return BoardSize - 1 - row, BoardSize - 1 - col },
+		func(row, col int) (int, int) { return BoardSize - 1 - col, BoardSize - 1 - row },
+	}
+
+	symmetries := board.Symmetries()
+	for i, transform := range transforms {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			var expBoard OthelloBoard
+			expBoard.IsBlackMove = board.IsBlackMove
+			for row := range BoardSize {
+				for col := range BoardSize {
+					tRow, tCol := transform(row, col)
+					expBoard.SetSquare(tRow, tCol, board.GetSquare(row, col))
+				}
+			}
+			assert.Equal(t, expBoard, symmetries[i])
+			assert.Equal(t, len(board.FindCurrentMoves()), len(symmetries[i].FindCurrentMoves()))
+		})
+	}
+	assert.Equal(t, symmetries[1], board.Transpose())
+}
+
+func TestBoard_Canonical(t *testing.T) {
+	initial := MakeInitialBoard()
+	random, _ := RandomBoard(30)
+
+	for _, board := range []OthelloBoard{initial, random} {
+		canonical := board.Canonical()
+		for _, symmetry := range board.Symmetries() {
+			assert.Equal(t, canonical, symmetry.Canonical())
+		}
+		assert.Contains(t, board.Symmetries(), canonical)
+	}
+
+	// every first move of the game is the same position up to symmetry
+	var canonicals []OthelloBoard
+	for _, move := range initial.FindCurrentMoves() {
+		board := initial.MakeMoved(move)
+		canonicals = append(canonicals, board.Canonical())
+	}
+	assert.Len(t, canonicals, 4)
+	for _, canonical := range canonicals {
+		assert.Equal(t, canonicals[0], canonical)
+	}
+}
+
+func TestParseTileSafe(t *testing.T) {
+	type Test struct {
+		s       string
+		expTile Tile
+		expErr  error
+	}
+	tests := []Test{
+		{s: "a1", expTile: Tile{Row: 0, Col: 0}},
+		{s: "h8", expTile: Tile{Row: 7, Col: 7}},
+		{s: "H8", expTile: Tile{Row: 7, Col: 7}},
+		{s: "d3", expTile: Tile{Row: 2, Col: 3}},
+		{s: "a0", expErr: ErrInvalidTile},
+		{s: "a9", expErr: ErrInvalidTile},
+		{s: "i1", expErr: ErrInvalidTile},
+		{s: "z5", expErr: ErrInvalidTile},
+		{s: "@1", expErr: ErrInvalidTile},
+		{s: "a", expErr: ErrInvalidTile},
+		{s: "a10", expErr: ErrInvalidTile},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			tile, err := ParseTileSafe(test.s)
+			if test.expErr != nil {
+				assert.ErrorIs(t, err, test.expErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expTile, tile)
+		})
+	}
+}
+
+func TestBoard_Equal(t *testing.T) {
+	board := MakeInitialBoard()
+	copied := board
+	assert.True(t, board.Equal(copied))
+
+	// moving a copy leaves the original unchanged
+	copied.MakeMove(ParseTile("d3"))
+	assert.False(t, board.Equal(copied))
+	assert.True(t, board.Equal(MakeInitialBoard()))
+	assert.True(t, copied.Equal(board.MakeMoved(ParseTile("d3"))))
+
+	turned := board
+	turned.IsBlackMove = !turned.IsBlackMove
+	assert.False(t, board.Equal(turned))
+}
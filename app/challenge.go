@@ -1,44 +1,129 @@
 package app
 
 import (
+	"cmp"
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"sync"
 	"time"
 
 	"github.com/jellydator/ttlcache/v3"
 )
 
 var ChallengeTTl = time.Second * 60
+var ChallengeCooldown = time.Second * 15
 
 type Challenge struct {
 	Challenged Player
 	Challenger Player
+	BestOf     int // the number of games of a match, zero for a single game
 }
 
 func (c Challenge) Key() string {
 	return fmt.Sprintf("%s,%s", c.Challenged.ID, c.Challenger.ID)
 }
 
+// pendingChallenge is a challenge waiting to be accepted, sending on stopChan stops it from expiring
+type pendingChallenge struct {
+	challenge Challenge
+	stopChan  chan struct{}
+}
+
+// stop stops the challenge from expiring, a challenge that was already stopped is left as is
+func (pc pendingChallenge) stop() {
+	select {
+	case pc.stopChan <- struct{}{}:
+	default:
+	}
+}
+
+// ChallengeCache holds a single pending challenge for each challenger and challenged pair, mu guards replacing and
+// removing a pair's challenge along with the index of challenges to each player
 type ChallengeCache struct {
-	store *ttlcache.Cache[string, chan struct{}]
+	store    *ttlcache.Cache[string, pendingChallenge]
+	incoming map[string]map[string]Player // challenged ID to the players with a pending challenge to them
+	mu       *sync.Mutex
 }
 
 func MakeChallengeCache() ChallengeCache {
-	return ChallengeCache{store: ttlcache.New[string, chan struct{}]()}
+	return ChallengeCache{
+		store:    ttlcache.New[string, pendingChallenge](),
+		incoming: make(map[string]map[string]Player),
+		mu:       &sync.Mutex{},
+	}
+}
+
+// getPending finds the pair's pending challenge without refreshing its ttl
+func (cc ChallengeCache) getPending(key string) (pendingChallenge, bool) {
+	item := cc.store.Get(key, ttlcache.WithDisableTouchOnHit[string, pendingChallenge]())
+	if item == nil {
+		return pendingChallenge{}, false
+	}
+	return item.Value(), true
 }
 
+// deletePending removes the pair's challenge, unless it has been replaced by a newer challenge since. The store may
+// have already expired the challenge by itself, so the index is cleared whenever the pair has nothing pending.
+func (cc ChallengeCache) deletePending(challenge Challenge, stopChan chan struct{}) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	key := challenge.Key()
+	if pending, ok := cc.getPending(key); ok && pending.stopChan == stopChan {
+		cc.store.Delete(key)
+	}
+	if _, ok := cc.getPending(key); !ok {
+		challengers := cc.incoming[challenge.Challenged.ID]
+		delete(challengers, challenge.Challenger.ID)
+		if len(challengers) == 0 {
+			delete(cc.incoming, challenge.Challenged.ID)
+		}
+	}
+}
+
+// PendingChallengers lists the players with a pending challenge to the player, ordered by name
+func (cc ChallengeCache) PendingChallengers(challengedID string) []Player {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	var challengers []Player
+	for _, challenger := range cc.incoming[challengedID] {
+		// a challenge the store expired before its timer removed it from the index isn't pending
+		if _, ok := cc.getPending(Challenge{Challenged: Player{ID: challengedID}, Challenger: challenger}.Key()); ok {
+			challengers = append(challengers, challenger)
+		}
+	}
+	slices.SortFunc(challengers, func(a, b Player) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.ID, b.ID))
+	})
+	return challengers
+}
+
+// CreateChallenge sets the pair's pending challenge, a challenge already pending between the pair is replaced without
+// expiring so only the newest challenge times out
 func (cc ChallengeCache) CreateChallenge(ctx context.Context, challenge Challenge, handleExpire func()) {
 	trace := ctx.Value(TraceKey)
 
 	stopChan := make(chan struct{}, 1)
 
 	key := challenge.Key()
-	_ = cc.store.Set(key, stopChan, ChallengeTTl)
+	cc.mu.Lock()
+	if pending, ok := cc.getPending(key); ok {
+		pending.stop()
+		slog.Info("replaced pending challenge", "trace", trace, "key", key, "challenge", pending.challenge)
+	}
+	_ = cc.store.Set(key, pendingChallenge{challenge: challenge, stopChan: stopChan}, ChallengeTTl)
+	if cc.incoming[challenge.Challenged.ID] == nil {
+		cc.incoming[challenge.Challenged.ID] = make(map[string]Player)
+	}
+	cc.incoming[challenge.Challenged.ID][challenge.Challenger.ID] = challenge.Challenger
+	cc.mu.Unlock()
 	slog.Info("set challenge into challenge Cache", "trace", trace, "key", key, "challenge", challenge)
 
 	go func() {
-		defer cc.store.Delete(key)
+		defer cc.deletePending(challenge, stopChan)
 
 		timer := time.NewTimer(ChallengeTTl)
 		select {
@@ -53,21 +138,21 @@ func (cc ChallengeCache) CreateChallenge(ctx context.Context, challenge Challeng
 	}()
 }
 
-func (cc ChallengeCache) AcceptChallenge(ctx context.Context, challenge Challenge) bool {
+// AcceptChallenge stops the challenge between the players from expiring, the accepted challenge keeps the options the
+// challenger picked
+func (cc ChallengeCache) AcceptChallenge(ctx context.Context, challenge Challenge) (Challenge, bool) {
 	trace := ctx.Value(TraceKey)
 
 	key := challenge.Key()
 
-	item := cc.store.Get(key)
-	if item == nil {
-		return false
-	}
-
-	stopChan := item.Value()
-	if stopChan != nil {
-		stopChan <- struct{}{}
+	cc.mu.Lock()
+	pending, ok := cc.getPending(key)
+	cc.mu.Unlock()
+	if !ok {
+		return Challenge{}, false
 	}
+	pending.stop()
 
-	slog.Info("accepted challenge from challenge Cache", "trace", trace, "key", key, "challenge", challenge)
-	return true
+	slog.Info("accepted challenge from challenge Cache", "trace", trace, "key", key, "challenge", pending.challenge)
+	return pending.challenge, true
 }
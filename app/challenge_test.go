@@ -19,11 +19,28 @@ func TestChallenge(t *testing.T) {
 	challenge := Challenge{Challenged: Player{ID: "id1", Name: "name1"}, Challenger: Player{ID: "id2", Name: "name2"}}
 
 	cc.CreateChallenge(ctx, challenge, func() {})
-	didAccept := cc.AcceptChallenge(ctx, challenge)
+	_, didAccept := cc.AcceptChallenge(ctx, challenge)
 
 	assert.True(t, didAccept)
 }
 
+func TestChallenge_BestOf(t *testing.T) {
+	cc := MakeChallengeCache()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-challenge-best-of")
+	challenge := Challenge{Challenged: Player{ID: "id1", Name: "name1"}, Challenger: Player{ID: "id2", Name: "name2"}, BestOf: 5}
+
+	cc.CreateChallenge(ctx, challenge, func() {})
+
+	// the player accepting doesn't know the options, they're kept from the challenge
+	accepted, didAccept := cc.AcceptChallenge(ctx, Challenge{Challenged: challenge.Challenged, Challenger: challenge.Challenger})
+	assert.True(t, didAccept)
+	assert.Equal(t, challenge, accepted)
+
+	_, didAccept = cc.AcceptChallenge(ctx, Challenge{Challenged: challenge.Challenger, Challenger: challenge.Challenged})
+	assert.False(t, didAccept)
+}
+
 func TestChallenge_Expiry(t *testing.T) {
 	cc := MakeChallengeCache()
 
@@ -43,3 +60,80 @@ func TestChallenge_Expiry(t *testing.T) {
 		t.Fatal("challenge did not expire before timeout")
 	}
 }
+
+func TestChallenge_Replace(t *testing.T) {
+	ttl := ChallengeTTl
+	ChallengeTTl = time.Millisecond * 50
+	defer func() { ChallengeTTl = ttl }()
+
+	cc := MakeChallengeCache()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-challenge-replace")
+	challenge := Challenge{Challenged: Player{ID: "id1", Name: "name1"}, Challenger: Player{ID: "id2", Name: "name2"}}
+
+	expireChan := make(chan int, 2)
+	cc.CreateChallenge(ctx, challenge, func() { expireChan <- 1 })
+	cc.CreateChallenge(ctx, Challenge{Challenged: challenge.Challenged, Challenger: challenge.Challenger, BestOf: 3}, func() { expireChan <- 2 })
+
+	// the first challenge is replaced, and stopping it doesn't remove the second
+	time.Sleep(ChallengeTTl / 5)
+	assert.Equal(t, 1, cc.store.Len())
+
+	// a challenge the other way between the same players is its own challenge
+	cc.CreateChallenge(ctx, Challenge{Challenged: challenge.Challenger, Challenger: challenge.Challenged}, func() {})
+	assert.Equal(t, 2, cc.store.Len())
+
+	select {
+	case id := <-expireChan:
+		assert.Equal(t, 2, id)
+	case <-time.After(ChallengeTTl * 2):
+		t.Fatal("challenge did not expire before timeout")
+	}
+	select {
+	case id := <-expireChan:
+		t.Fatalf("replaced challenge %d expired", id)
+	case <-time.After(ChallengeTTl):
+	}
+}
+
+func TestChallenge_PendingChallengers(t *testing.T) {
+	ttl := ChallengeTTl
+	ChallengeTTl = time.Millisecond * 50
+	defer func() { ChallengeTTl = ttl }()
+
+	cc := MakeChallengeCache()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-pending-challengers")
+	player1 := Player{ID: "id1", Name: "name1"}
+	player2 := Player{ID: "id2", Name: "name2"}
+	player3 := Player{ID: "id3", Name: "name3"}
+
+	assert.Empty(t, cc.PendingChallengers(player1.ID))
+
+	cc.CreateChallenge(ctx, Challenge{Challenged: player1, Challenger: player3}, func() {})
+	assert.Equal(t, []Player{player3}, cc.PendingChallengers(player1.ID))
+
+	cc.CreateChallenge(ctx, Challenge{Challenged: player1, Challenger: player2}, func() {})
+	cc.CreateChallenge(ctx, Challenge{Challenged: player1, Challenger: player2}, func() {})
+	cc.CreateChallenge(ctx, Challenge{Challenged: player3, Challenger: player1}, func() {})
+	assert.Equal(t, []Player{player2, player3}, cc.PendingChallengers(player1.ID))
+	assert.Equal(t, []Player{player1}, cc.PendingChallengers(player3.ID))
+
+	// accepted and expired challenges are no longer pending
+	_, didAccept := cc.AcceptChallenge(ctx, Challenge{Challenged: player1, Challenger: player2})
+	assert.True(t, didAccept)
+	assert.Eventually(t, func() bool {
+		return len(cc.PendingChallengers(player1.ID)) == 1
+	}, ChallengeTTl/2, time.Millisecond)
+
+	assert.Eventually(t, func() bool {
+		return len(cc.PendingChallengers(player1.ID)) == 0 && len(cc.PendingChallengers(player3.ID)) == 0
+	}, ChallengeTTl*4, time.Millisecond)
+
+	// the index is cleared once the expired challenges are removed
+	assert.Eventually(t, func() bool {
+		cc.mu.Lock()
+		defer cc.mu.Unlock()
+		return len(cc.incoming) == 0
+	}, ChallengeTTl*4, time.Millisecond)
+}
@@ -7,10 +7,29 @@ import (
 
 const MinDelay = 1
 const MaxDelay = 5
+const MaxSeedCount = 500
+const MinAnalysisTime = 10
+const MaxAnalysisTime = 300
 
 var LevelDesc = fmt.Sprintf("Level of the vor between %d and %d", MinBotLevel, MaxBotLevel)
+var AnalysisLevelDesc = fmt.Sprintf("Level of the analysis between %d and %d, levels above %d search deeper than any bot", MinBotLevel, MaxAnalysisLevel, MaxBotLevel)
 var ExpectedTileValue = "be a string of the form 'a1' where 'a' is the column and '1' is the row"
+var ExpectedColorValue = "be a hex string of the form '#00ff00'"
+var ExpectedMoveOrderValue = fmt.Sprintf("one of %v", MoveOrders)
+var ExpectedSideValue = fmt.Sprintf("one of %v", BotChallengeSides)
+var ExpectedOrientationValue = fmt.Sprintf("one of %v", BoardOrientations)
+var ExpectedScopeValue = fmt.Sprintf("one of %v", LeaderboardScopes)
+var ExpectedBestOfValue = fmt.Sprintf("one of %v", MatchBestOfs)
+var ExpectedThemeValue = "be the name of a theme such as 'classic'"
+var ManageGuildPermission int64 = discordgo.PermissionManageGuild
+var AdminPermission int64 = discordgo.PermissionAdministrator
 var DelayDesc = fmt.Sprintf("Minimum delay between moves in seconds between %d and %d secs", MinDelay, MaxDelay)
+var LoopDesc = fmt.Sprintf("Starts a new game when one finishes, up to %d games", MaxSimGames)
+var MaxMovesDesc = fmt.Sprintf("Stops each game after this many moves between 1 and %d and scores it by its discs", MaxSimMoves)
+var KeyFramesDesc = fmt.Sprintf("Only shows the board every %d moves and the result instead of every move", KeyFrameInterval)
+var AnalysisTimeDesc = fmt.Sprintf("Seconds to wait for the analysis between %d and %d secs, higher levels need more time", MinAnalysisTime, MaxAnalysisTime)
+var PrivateDesc = "Only shows the response to you instead of the whole channel"
+var SeedCountDesc = fmt.Sprintf("Number of rows to insert between 1 and %d", MaxSeedCount)
 
 var Commands = []*discordgo.ApplicationCommand{
 	{
@@ -41,10 +60,36 @@ var Commands = []*discordgo.ApplicationCommand{
 						Description: LevelDesc,
 						Required:    false,
 					},
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "color",
+						Description: "Color to play as, black moves first and white lets the bot open",
+						Required:    false,
+						Choices:     sideChoices(),
+					},
 				},
 			},
 		},
 	},
+	{
+		Name:        "match",
+		Description: "Challenges another user to a best of series, the next game starts when one finishes",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "opponent",
+				Description: "The opponent to challenge",
+				Required:    true,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "best-of",
+				Description: "Number of games in the series, the first to win a majority takes it",
+				Required:    true,
+				Choices:     bestOfChoices(),
+			},
+		},
+	},
 	{
 		Name:        "accept",
 		Description: "Accepts a challenge from another discord user",
@@ -52,8 +97,8 @@ var Commands = []*discordgo.ApplicationCommand{
 			{
 				Type:        discordgo.ApplicationCommandOptionUser,
 				Name:        "challenger",
-				Description: "User who made the challenge",
-				Required:    true,
+				Description: "User who made the challenge, can be left out when you have a single challenge",
+				Required:    false,
 			},
 		},
 	},
@@ -61,6 +106,70 @@ var Commands = []*discordgo.ApplicationCommand{
 		Name:        "forfeit",
 		Description: "Forfeits the user's current game",
 	},
+	{
+		Name:        "watch",
+		Description: "Posts each move of a player's current game in this channel",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "player",
+				Description: "Player whose game to watch",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "unwatch",
+		Description: "Stops posting the moves of a player's game you're watching",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "player",
+				Description: "Player whose game to stop watching",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "abort",
+		Description: "Ends the user's current game without a result if no moves have been made",
+	},
+	{
+		Name:        "import",
+		Description: "Starts a game against yourself from a GGF game to continue playing or analyzing it",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "ggf",
+				Description: "Game in GGF format such as (;GM[Othello]BO[8 ... *]B[D3]W[C5];)",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "history",
+		Description: "Lists the moves made in the user's current game",
+	},
+	{
+		Name:        "export",
+		Description: "Attaches the user's current game as a JSON file",
+	},
+	{
+		Name:        "replay",
+		Description: "Shows an animated replay of the user's last finished game",
+	},
+	{
+		Name:        "moves",
+		Description: "Lists the legal moves in the user's current game as text",
+	},
+	{
+		Name:        "takeback",
+		Description: "Requests to take back the user's last move, the opponent must confirm",
+	},
+	{
+		Name:        "draw",
+		Description: "Offers or accepts a draw in the user's current game",
+	},
 	{
 		Name:        "move",
 		Description: "Makes a move on user's current game",
@@ -72,11 +181,25 @@ var Commands = []*discordgo.ApplicationCommand{
 				Required:     true,
 				Autocomplete: true,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "coach",
+				Description: "Shows the bot's top candidate replies and their heuristics before it moves",
+				Required:    false,
+			},
 		},
 	},
 	{
 		Name:        "view",
 		Description: "Displays the game state including all the moves that can be made this turn",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "analysis",
+				Description: "Highlights the best move from a recent analysis of the position",
+				Required:    false,
+			},
+		},
 	},
 	{
 		Name:        "analyze",
@@ -85,11 +208,25 @@ var Commands = []*discordgo.ApplicationCommand{
 			{
 				Type:        discordgo.ApplicationCommandOptionInteger,
 				Name:        "level",
-				Description: LevelDesc,
+				Description: AnalysisLevelDesc,
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "time",
+				Description: AnalysisTimeDesc,
 				Required:    false,
 			},
 		},
 	},
+	{
+		Name:        "hint",
+		Description: "Suggests a single good move for the current turn",
+	},
+	{
+		Name:        "help",
+		Description: "Explains the board coordinates, the rules of Othello and each command",
+	},
 	{
 		Name:        "simulate",
 		Description: "Simulates a game between two bots",
@@ -112,6 +249,24 @@ var Commands = []*discordgo.ApplicationCommand{
 				Description: DelayDesc,
 				Required:    false,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "loop",
+				Description: LoopDesc,
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "keyframes",
+				Description: KeyFramesDesc,
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionInteger,
+				Name:        "max-moves",
+				Description: MaxMovesDesc,
+				Required:    false,
+			},
 		},
 	},
 	{
@@ -124,10 +279,272 @@ var Commands = []*discordgo.ApplicationCommand{
 				Description: "Player to get stats profile for",
 				Required:    false,
 			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "private",
+				Description: PrivateDesc,
+				Required:    false,
+			},
+		},
+	},
+	{
+		Name:        "profile",
+		Description: "Retrieves the detailed stats profile for a player with their peak rating, streak and recent form",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionUser,
+				Name:        "player",
+				Description: "Player to get the profile for",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "private",
+				Description: PrivateDesc,
+				Required:    false,
+			},
+		},
+	},
+	{
+		Name:        "record",
+		Description: "Retrieves your head-to-head record against another user or a bot level",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "user",
+				Description: "Retrieves your record against another user",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "opponent",
+						Description: "The opponent to get your record against",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "bot",
+				Description: "Retrieves your record against a bot level",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "level",
+						Description: LevelDesc,
+						Required:    false,
+					},
+				},
+			},
 		},
 	},
 	{
 		Name:        "leaderboard",
 		Description: "Retrieves the highest rated players by ELO",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "scope",
+				Description: "Whether to show the global ladder or this server's ladder",
+				Required:    false,
+				Choices:     scopeChoices(),
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "private",
+				Description: PrivateDesc,
+				Required:    false,
+			},
+		},
+	},
+	{
+		Name:        "fastest",
+		Description: "Retrieves the players with the fastest wins",
+	},
+	{
+		Name:        "topbot",
+		Description: "Shows how often each bot level beats users",
+	},
+	{
+		Name:                     "branding",
+		Description:              "Sets the title prefix and color used for this server's embeds",
+		DefaultMemberPermissions: &ManageGuildPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "prefix",
+				Description: "Prefix added to the title of each embed, 'none' removes the prefix",
+				Required:    false,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "color",
+				Description: "Hex color of each embed such as '#00ff00'",
+				Required:    false,
+			},
+		},
+	},
+	{
+		Name:                     "guildstats",
+		Description:              "Rates games in this server on a ladder separate from the global one",
+		DefaultMemberPermissions: &ManageGuildPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionBoolean,
+				Name:        "enabled",
+				Description: "Whether this server has its own ladder",
+				Required:    true,
+			},
+		},
+	},
+	{
+		Name:        "settings",
+		Description: "Changes the user's personal settings",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "evalbar",
+				Description: "Shows an evaluation bar beside the board after each bot move",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Whether the evaluation bar is shown",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "showmoves",
+				Description: "Shows the legal move indicators on the board in bot games",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Whether the legal move indicators are shown",
+						Required:    true,
+					},
+				},
+			},
+		},
 	},
+	{
+		Name:        "theme",
+		Description: "Changes the colors of the boards drawn for your /view and /move",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "name",
+				Description: "Name of the theme",
+				Required:    true,
+				Choices:     themeChoices(),
+			},
+		},
+	},
+	{
+		Name:        "orientation",
+		Description: "Changes the side your /view and /move boards are drawn from",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionString,
+				Name:        "side",
+				Description: "Side at the bottom of the board, white turns the board around with h8 in the top left",
+				Required:    true,
+				Choices:     orientationChoices(),
+			},
+		},
+	},
+	{
+		Name:                     "seed",
+		Description:              "Manages synthetic stats used to populate the leaderboard for demos",
+		DefaultMemberPermissions: &AdminPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Inserts synthetic stats rows with random elo and records",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "count",
+						Description: SeedCountDesc,
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "purge",
+				Description: "Deletes all synthetic stats rows",
+			},
+		},
+	},
+	{
+		Name:                     "stats-admin",
+		Description:              "Manages players' stats",
+		DefaultMemberPermissions: &AdminPermission,
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "reset",
+				Description: "Resets a player's rating on the server's ladder to 1500 and clears their wins, losses, and draws",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionUser,
+						Name:        "player",
+						Description: "Player whose stats are reset",
+						Required:    true,
+					},
+				},
+			},
+		},
+	},
+}
+
+func themeChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, theme := range Themes {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: theme.Name, Value: theme.Name})
+	}
+	return choices
+}
+
+func bestOfChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, bestOf := range MatchBestOfs {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: fmt.Sprintf("best of %d", bestOf), Value: bestOf})
+	}
+	return choices
+}
+
+func sideChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, side := range BotChallengeSides {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: side, Value: side})
+	}
+	return choices
+}
+
+func orientationChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, side := range BoardOrientations {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: side, Value: side})
+	}
+	return choices
+}
+
+func scopeChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, scope := range LeaderboardScopes {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: scope, Value: scope})
+	}
+	return choices
+}
+
+func moveOrderChoices() []*discordgo.ApplicationCommandOptionChoice {
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, order := range MoveOrders {
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: order, Value: order})
+	}
+	return choices
 }
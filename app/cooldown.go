@@ -0,0 +1,62 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+)
+
+type CooldownCache struct {
+	store *ttlcache.Cache[string, struct{}]
+}
+
+func MakeCooldownCache() CooldownCache {
+	// touching an item on a hit would extend the cooldown every time a user is rejected
+	return CooldownCache{store: ttlcache.New[string, struct{}](ttlcache.WithDisableTouchOnHit[string, struct{}]())}
+}
+
+// Check succeeds if the key doesn't have a cooldown running, otherwise it returns the remaining time on the cooldown
+func (cc CooldownCache) Check(key string) (time.Duration, bool) {
+	item := cc.store.Get(key)
+	if item == nil {
+		return 0, true
+	}
+	return time.Until(item.ExpiresAt()), false
+}
+
+// Start starts a cooldown for the key, it's only started once the request it limits is accepted so a rejected request
+// can be fixed and sent again straight away
+func (cc CooldownCache) Start(key string, cooldown time.Duration) {
+	cc.store.Set(key, struct{}{}, cooldown)
+}
+
+// CommandCooldowns limit how often a user can run the commands that start engine work, commands without a cooldown
+// such as /view and /move are never limited
+var CommandCooldowns = map[string]time.Duration{
+	"analyze":  time.Second * 30,
+	"simulate": time.Second * 60,
+}
+
+func commandCooldownKey(cmd string, userID string) string {
+	return fmt.Sprintf("%s,%s", cmd, userID)
+}
+
+// CheckCommand checks the command's cooldown for the user, a command without a cooldown always succeeds
+func (cc CooldownCache) CheckCommand(cmd string, userID string) (time.Duration, bool) {
+	if _, ok := CommandCooldowns[cmd]; !ok {
+		return 0, true
+	}
+	return cc.Check(commandCooldownKey(cmd, userID))
+}
+
+// StartCommand starts the command's cooldown for the user, a command without a cooldown is ignored
+func (cc CooldownCache) StartCommand(cmd string, userID string) {
+	if cooldown, ok := CommandCooldowns[cmd]; ok {
+		cc.Start(commandCooldownKey(cmd, userID), cooldown)
+	}
+}
+
+func formatCooldown(remaining time.Duration) string {
+	return remaining.Round(time.Second).String()
+}
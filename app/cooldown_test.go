@@ -0,0 +1,65 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCooldownCache_Expiry(t *testing.T) {
+	cc := MakeCooldownCache()
+
+	cc.Start("id1", time.Millisecond)
+	_, ok := cc.Check("id1")
+	assert.False(t, ok)
+
+	time.Sleep(time.Millisecond * 2)
+
+	_, ok = cc.Check("id1")
+	assert.True(t, ok)
+}
+
+func TestCooldownCache_CheckAndStart(t *testing.T) {
+	cc := MakeCooldownCache()
+
+	// checking doesn't start the cooldown
+	for range 2 {
+		_, ok := cc.Check("id1")
+		assert.True(t, ok)
+	}
+
+	cc.Start("id1", time.Minute)
+	remaining, ok := cc.Check("id1")
+	assert.False(t, ok)
+	assert.Greater(t, remaining, time.Duration(0))
+	assert.LessOrEqual(t, remaining, time.Minute)
+
+	_, ok = cc.Check("id2")
+	assert.True(t, ok)
+}
+
+func TestCooldownCache_Command(t *testing.T) {
+	cc := MakeCooldownCache()
+
+	_, ok := cc.CheckCommand("analyze", "id1")
+	assert.True(t, ok)
+	cc.StartCommand("analyze", "id1")
+
+	remaining, ok := cc.CheckCommand("analyze", "id1")
+	assert.False(t, ok)
+	assert.LessOrEqual(t, remaining, CommandCooldowns["analyze"])
+
+	// each command and user has its own cooldown
+	_, ok = cc.CheckCommand("simulate", "id1")
+	assert.True(t, ok)
+	_, ok = cc.CheckCommand("analyze", "id2")
+	assert.True(t, ok)
+
+	// commands without a cooldown are never limited
+	for range 3 {
+		cc.StartCommand("move", "id1")
+		_, ok = cc.CheckCommand("move", "id1")
+		assert.True(t, ok)
+	}
+}
@@ -0,0 +1,39 @@
+package app
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+)
+
+var DrawOfferTTl = time.Second * 60
+
+type DrawOfferCache struct {
+	store *ttlcache.Cache[string, string] // maps a game id to the id of the player offering the draw
+}
+
+func MakeDrawOfferCache() DrawOfferCache {
+	return DrawOfferCache{store: ttlcache.New[string, string]()}
+}
+
+// OfferDraw records a pending draw offer for a game, or accepts the opponent's pending offer if there is one
+func (dc DrawOfferCache) OfferDraw(ctx context.Context, gameID string, playerID string) bool {
+	trace := ctx.Value(TraceKey)
+
+	item := dc.store.Get(gameID)
+	if item != nil && item.Value() != playerID {
+		dc.store.Delete(gameID)
+		slog.Info("accepted draw offer", "trace", trace, "gameID", gameID, "playerID", playerID, "offererID", item.Value())
+		return true
+	}
+
+	dc.store.Set(gameID, playerID, DrawOfferTTl)
+	slog.Info("set draw offer into draw offer cache", "trace", trace, "gameID", gameID, "playerID", playerID)
+	return false
+}
+
+func (dc DrawOfferCache) ClearOffer(gameID string) {
+	dc.store.Delete(gameID)
+}
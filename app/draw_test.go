@@ -0,0 +1,36 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDrawOfferCache(t *testing.T) {
+	dc := MakeDrawOfferCache()
+	ctx := context.WithValue(context.Background(), TraceKey, "test-draw-offer")
+
+	assert.False(t, dc.OfferDraw(ctx, "1", "id1"))
+	assert.False(t, dc.OfferDraw(ctx, "1", "id1")) // offering twice should not accept your own offer
+	assert.True(t, dc.OfferDraw(ctx, "1", "id2"))
+
+	// the accepted offer is consumed
+	assert.False(t, dc.OfferDraw(ctx, "1", "id1"))
+	dc.ClearOffer("1")
+	assert.False(t, dc.OfferDraw(ctx, "1", "id2"))
+}
+
+func TestDrawOfferCache_Expiry(t *testing.T) {
+	ttl := DrawOfferTTl
+	DrawOfferTTl = time.Millisecond
+	defer func() { DrawOfferTTl = ttl }()
+
+	dc := MakeDrawOfferCache()
+	ctx := context.WithValue(context.Background(), TraceKey, "test-draw-offer-expiry")
+
+	assert.False(t, dc.OfferDraw(ctx, "1", "id1"))
+	time.Sleep(DrawOfferTTl * 2)
+	assert.False(t, dc.OfferDraw(ctx, "1", "id2"))
+}
@@ -2,14 +2,20 @@ package app
 
 import (
 	"bytes"
+	"cmp"
+	"context"
 	"errors"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
 	"image"
-	"image/jpeg"
+	"image/png"
+	"io"
 	"log/slog"
+	"math"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const GreenEmbed = 0x00ff00
@@ -23,6 +29,18 @@ func createStringResponse(msg string) *discordgo.InteractionResponse {
 	}
 }
 
+func createEphemeralStringResponse(msg string) *discordgo.InteractionResponse {
+	resp := createStringResponse(msg)
+	resp.Data.Flags = discordgo.MessageFlagsEphemeral
+	return resp
+}
+
+const ExpiredGameMsg = "This game has expired."
+
+func createExpiredResponse() *discordgo.InteractionResponse {
+	return createEphemeralStringResponse(ExpiredGameMsg)
+}
+
 func createStringEdit(msg string) *discordgo.WebhookEdit {
 	return &discordgo.WebhookEdit{Content: &msg}
 }
@@ -32,7 +50,7 @@ func addEmbedFiles(embed *discordgo.MessageEmbed, img image.Image) []*discordgo.
 
 	if img != nil {
 		var buf bytes.Buffer
-		if err := jpeg.Encode(&buf, img, nil); err != nil {
+		if err := png.Encode(&buf, img); err != nil {
 			// we can't do anything if this fails, it would be an issue with the OthelloBoard renderer
 			slog.Error("failed to encode image", "err", err)
 			return nil
@@ -51,11 +69,28 @@ func addEmbedFiles(embed *discordgo.MessageEmbed, img image.Image) []*discordgo.
 	return files
 }
 
+func createFileResponse(name string, contentType string, b []byte) *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Files: []*discordgo.File{{Name: name, ContentType: contentType, Reader: bytes.NewReader(b)}},
+		},
+	}
+}
+
 func createEmbedResponse(embed *discordgo.MessageEmbed, img image.Image) *discordgo.InteractionResponse {
-	return createComponentResponse(embed, img, nil)
+	return createComponentResponse(embed, img, nil, 0)
+}
+
+// privateFlags are the flags of a response only visible to the user when private is set
+func privateFlags(private bool) discordgo.MessageFlags {
+	if private {
+		return discordgo.MessageFlagsEphemeral
+	}
+	return 0
 }
 
-func createComponentResponse(embed *discordgo.MessageEmbed, img image.Image, components []discordgo.MessageComponent) *discordgo.InteractionResponse {
+func createComponentResponse(embed *discordgo.MessageEmbed, img image.Image, components []discordgo.MessageComponent, flags discordgo.MessageFlags) *discordgo.InteractionResponse {
 	files := addEmbedFiles(embed, img)
 	return &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -63,6 +98,20 @@ func createComponentResponse(embed *discordgo.MessageEmbed, img image.Image, com
 			Embeds:     []*discordgo.MessageEmbed{embed},
 			Files:      files,
 			Components: components,
+			Flags:      flags,
+		},
+	}
+}
+
+func createComponentUpdate(embed *discordgo.MessageEmbed, img image.Image, components []discordgo.MessageComponent) *discordgo.InteractionResponse {
+	files := addEmbedFiles(embed, img)
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Embeds:      []*discordgo.MessageEmbed{embed},
+			Attachments: &[]*discordgo.MessageAttachment{},
+			Files:       files,
+			Components:  components,
 		},
 	}
 }
@@ -120,6 +169,82 @@ func createSimulationActionRow(simulationID string, isPaused bool) []discordgo.M
 	return nil
 }
 
+const ViewRefreshKey = "view-refresh-key"
+
+func createViewActionRow(game OthelloGame, playerID string) []discordgo.MessageComponent {
+	refreshID := fmt.Sprintf("%s+%s,%s", ViewRefreshKey, game.ID, playerID)
+
+	components := []discordgo.MessageComponent{discordgo.Button{CustomID: refreshID, Label: "Refresh", Style: discordgo.SecondaryButton}}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: components}}
+}
+
+const RematchKey = "rematch-key"
+
+// createRematchActionRow creates the rematch button of a finished game, the button carries when the game finished so
+// it expires after a while
+func createRematchActionRow(game OthelloGame, finishedTime time.Time) []discordgo.MessageComponent {
+	blackPlayer, whitePlayer := game.RematchPlayers()
+	rematchID := fmt.Sprintf("%s+%s,%s,%d", RematchKey, blackPlayer.ID, whitePlayer.ID, finishedTime.Unix())
+
+	components := []discordgo.MessageComponent{discordgo.Button{CustomID: rematchID, Label: "Rematch", Style: discordgo.PrimaryButton}}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: components}}
+}
+
+const LeaderboardPageKey = "leaderboard-page-key"
+
+// createLeaderboardActionRow creates buttons to move between pages, each button carries the page it shows, when the
+// leaderboard was posted and the ladder so the pages of each message are independent of every other message
+func createLeaderboardActionRow(guildID string, page int, pageCount int, createdTime time.Time) []discordgo.MessageComponent {
+	if pageCount <= 1 {
+		return nil
+	}
+	prevID := fmt.Sprintf("%s+%d,%d,%s", LeaderboardPageKey, page-1, createdTime.Unix(), guildID)
+	nextID := fmt.Sprintf("%s+%d,%d,%s", LeaderboardPageKey, page+1, createdTime.Unix(), guildID)
+
+	components := []discordgo.MessageComponent{
+		discordgo.Button{CustomID: prevID, Label: "Previous", Style: discordgo.SecondaryButton, Disabled: page <= 0},
+		discordgo.Button{CustomID: nextID, Label: "Next", Style: discordgo.SecondaryButton, Disabled: page >= pageCount-1},
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: components}}
+}
+
+const TakebackAcceptKey = "takeback-accept-key"
+const TakebackDeclineKey = "takeback-decline-key"
+
+func createTakebackActionRow(game OthelloGame, playerID string) []discordgo.MessageComponent {
+	key := fmt.Sprintf("%s,%s,%d", game.ID, playerID, len(game.MoveList))
+	acceptID := fmt.Sprintf("%s+%s", TakebackAcceptKey, key)
+	declineID := fmt.Sprintf("%s+%s", TakebackDeclineKey, key)
+
+	components := []discordgo.MessageComponent{
+		discordgo.Button{CustomID: acceptID, Label: "Accept", Style: discordgo.SuccessButton},
+		discordgo.Button{CustomID: declineID, Label: "Decline", Style: discordgo.DangerButton},
+	}
+	return []discordgo.MessageComponent{discordgo.ActionsRow{Components: components}}
+}
+
+func createTakebackResponse(game OthelloGame, playerID string, opponentID string) *discordgo.InteractionResponse {
+	resp := createStringResponse(fmt.Sprintf("<@%s> requested a takeback, <@%s> can accept or decline.", playerID, opponentID))
+	resp.Data.Components = createTakebackActionRow(game, playerID)
+	return resp
+}
+
+func createTakebackDeclinedUpdate() *discordgo.InteractionResponse {
+	return &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseUpdateMessage,
+		Data: &discordgo.InteractionResponseData{
+			Content:    "The takeback was declined.",
+			Components: []discordgo.MessageComponent{},
+		},
+	}
+}
+
+func createTakebackEmbed(game OthelloGame) *discordgo.MessageEmbed {
+	embed := createGameEmbed(game)
+	embed.Title = "The last move was taken back"
+	return embed
+}
+
 var empty = ""
 
 func createEmbedEdit(embed *discordgo.MessageEmbed, img image.Image) *discordgo.WebhookEdit {
@@ -132,6 +257,17 @@ func createEmbedEdit(embed *discordgo.MessageEmbed, img image.Image) *discordgo.
 	}
 }
 
+// createEmbedFileEdit attaches the file as the embed's image, used for images that aren't drawn as a png
+func createEmbedFileEdit(embed *discordgo.MessageEmbed, name string, contentType string, r io.Reader) *discordgo.WebhookEdit {
+	embed.Image = &discordgo.MessageEmbedImage{URL: "attachment://" + name}
+	return &discordgo.WebhookEdit{
+		Embeds:      &[]*discordgo.MessageEmbed{embed},
+		Attachments: &[]*discordgo.MessageAttachment{},
+		Files:       []*discordgo.File{{Name: name, ContentType: contentType, Reader: r}},
+		Content:     &empty,
+	}
+}
+
 func createEmbedTextEdit(edit string) *discordgo.WebhookEdit {
 	return &discordgo.WebhookEdit{
 		Embeds:      &[]*discordgo.MessageEmbed{},
@@ -152,21 +288,69 @@ func createGameStartEmbed(game OthelloGame) *discordgo.MessageEmbed {
 	}
 }
 
-func createSimulationStartEmbed(game OthelloGame) *discordgo.MessageEmbed {
+func createMatchStartEmbed(match Match, game OthelloGame) *discordgo.MessageEmbed {
+	embed := createGameStartEmbed(game)
+	embed.Title = fmt.Sprintf("Best of %d Match Started!", match.BestOf)
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("The first to win %d games takes the match", match.WinsNeeded())}
+	return embed
+}
+
+func createMatchEmbed(mr MatchResult) *discordgo.MessageEmbed {
+	match := mr.Match
+	player1, player2 := match.Player1(), match.Player2()
+
+	score := fmt.Sprintf("%s %d - %d %s", player1.Name, match.Player1Wins, match.Player2Wins, player2.Name)
+	if match.Draws > 0 {
+		score += fmt.Sprintf(", %d drawn", match.Draws)
+	}
+
+	var title, desc string
+	switch {
+	case mr.IsDrawn:
+		title = "Match drawn"
+		desc = fmt.Sprintf("The match between %s and %s ended level after %d drawn games\n%s", player1.Name, player2.Name, match.Draws, score)
+	case mr.IsOver:
+		title = "Match has ended"
+		desc = fmt.Sprintf("%s won the best of %d match\n%s", mr.Winner.Name, match.BestOf, score)
+	default:
+		game := mr.NextGame
+		title = fmt.Sprintf("Best of %d Match, Game %d", match.BestOf, match.Player1Wins+match.Player2Wins+match.Draws+1)
+		desc = fmt.Sprintf("%s\nBlack: %s\n White: %s\n Use `/move` to make a move.", score, game.BlackPlayer.Name, game.WhitePlayer.Name)
+	}
+	return &discordgo.MessageEmbed{
+		Title:       title,
+		Description: desc,
+		Color:       GreenEmbed,
+	}
+}
+
+var FloodingSimulationMsg = fmt.Sprintf("A simulation between two level %d bots with a %d second delay would flood the channel with edits, "+
+	"use a longer delay or the keyframes option.", MaxBotLevel, MinDelay)
+
+func createSimulationStartEmbed(game OthelloGame, estimate time.Duration) *discordgo.MessageEmbed {
 	desc := fmt.Sprintf("Black: %s\n White: %s", game.BlackPlayer.Name, game.WhitePlayer.Name)
 	return &discordgo.MessageEmbed{
 		Title:       "Simulation started!",
 		Description: desc,
+		Footer:      &discordgo.MessageEmbedFooter{Text: fmt.Sprintf("Estimated to take at least %s", estimate.Round(time.Second))},
 		Color:       GreenEmbed,
 	}
 }
 
-func createGameMoveEmbed(game OthelloGame, move Tile) *discordgo.MessageEmbed {
+func createGameMoveEmbed(game OthelloGame, move Tile, moveKind MoveKind) *discordgo.MessageEmbed {
 	desc := fmt.Sprintf("%sYour opponent has moved: %s", getScoreText(game), move.String())
 	footer := "White to move"
 	if game.Board.IsBlackMove {
 		footer = "Black to move"
 	}
+	if moveKind == Pass {
+		// the turn has returned to the player who moved, so the passing player is the other color
+		passed := "Black"
+		if game.Board.IsBlackMove {
+			passed = "White"
+		}
+		desc += fmt.Sprintf("\n%s had no moves and passed.", passed)
+	}
 	return &discordgo.MessageEmbed{
 		Title:       fmt.Sprintf("Your game with %s", game.OtherPlayer().Name),
 		Description: desc,
@@ -175,22 +359,41 @@ func createGameMoveEmbed(game OthelloGame, move Tile) *discordgo.MessageEmbed {
 	}
 }
 
-func createStepEdit(renderer Renderer, step SimStep) *discordgo.WebhookEdit {
+func createStepEdit(ctx context.Context, renderer Renderer, step SimStep) *discordgo.WebhookEdit {
 	var edit *discordgo.WebhookEdit
 	img := renderer.DrawBoardMoves(step.Game.Board, step.Game.Board.FindCurrentMoves())
 	if !step.Ok {
 		edit = createEmbedTextEdit("Failed to retrieve simulation data from engine.")
 	} else if step.Finished {
-		updtEmbed := createSimulationEndEmbed(step.Game, step.Move)
+		updtEmbed := brandEmbed(ctx, createSimulationEndEmbed(step.Game, step.Move))
+		if step.Capped {
+			updtEmbed.Description += "\nThe game was stopped at the move cap and scored by its discs."
+		}
+		addSimulationScore(updtEmbed, step)
 		edit = createEmbedEdit(updtEmbed, img)
-		edit.Components = &[]discordgo.MessageComponent{}
+		if !step.Restart {
+			edit.Components = &[]discordgo.MessageComponent{}
+		}
 	} else {
-		updtEmbed := createSimulationEmbed(step.Game, step.Move)
+		updtEmbed := brandEmbed(ctx, createSimulationEmbed(step.Game, step.Move))
+		addSimulationScore(updtEmbed, step)
 		edit = createEmbedEdit(updtEmbed, img)
 	}
 	return edit
 }
 
+// addSimulationScore shows the running score of a looping simulation in the embed footer
+func addSimulationScore(embed *discordgo.MessageEmbed, step SimStep) {
+	if step.GameCount <= 1 {
+		return
+	}
+	text := fmt.Sprintf("Game %d of %d | Black %d - %d White, %d drawn", step.GameNum, step.GameCount, step.Score.BlackWins, step.Score.WhiteWins, step.Score.Draws)
+	if embed.Footer != nil {
+		text = fmt.Sprintf("%s | %s", embed.Footer.Text, text)
+	}
+	embed.Footer = &discordgo.MessageEmbedFooter{Text: text}
+}
+
 func createSimulationEmbed(game OthelloGame, move Tile) *discordgo.MessageEmbed {
 	title := fmt.Sprintf("%s vs %s", game.BlackPlayer.Name, game.WhitePlayer.Name)
 	desc := fmt.Sprintf("%s%s has moved: %s", getScoreText(game), game.OtherPlayer().Name, move.String())
@@ -208,7 +411,7 @@ func createSimulationEmbed(game OthelloGame, move Tile) *discordgo.MessageEmbed
 
 func createGameEmbed(game OthelloGame) *discordgo.MessageEmbed {
 	title := fmt.Sprintf("%s vs %s", game.BlackPlayer.Name, game.WhitePlayer.Name)
-	desc := fmt.Sprintf("%s%s to move", getScoreText(game), game.CurrentPlayer().Name)
+	desc := fmt.Sprintf("%s%s%s to move", getScoreText(game), getProgressText(game), game.CurrentPlayer().Name)
 	footer := "White to move"
 	if game.Board.IsBlackMove {
 		footer = "Black to move"
@@ -221,10 +424,40 @@ func createGameEmbed(game OthelloGame) *discordgo.MessageEmbed {
 	}
 }
 
-func createAnalysisEmbed(game OthelloGame, level uint64) *discordgo.MessageEmbed {
-	desc := getScoreText(game)
+func createHistoryEmbed(game OthelloGame) *discordgo.MessageEmbed {
+	desc := "No moves have been made yet."
+	if len(game.MoveList) > 0 {
+		desc = fmt.Sprintf("```\n%s\n```", FormatMoveList(game.MoveList))
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Moves for %s vs %s", game.BlackPlayer.Name, game.WhitePlayer.Name),
+		Description: desc,
+		Color:       GreenEmbed,
+	}
+}
+
+// createMovesMessage lists the legal moves of the player to move as text, so they can be read without the board
+func createMovesMessage(game OthelloGame) string {
+	moves := game.Board.FindCurrentMoves()
+	if len(moves) == 0 {
+		return fmt.Sprintf("%s has no legal moves.", game.CurrentPlayer().Name)
+	}
+	var moveStrs []string
+	for _, move := range moves {
+		moveStrs = append(moveStrs, move.String())
+	}
+	noun := "moves"
+	if len(moves) == 1 {
+		noun = "move"
+	}
+	return fmt.Sprintf("%s has %d legal %s: %s", game.CurrentPlayer().Name, len(moves), noun, strings.Join(moveStrs, ", "))
+}
+
+func createAnalysisEmbed(game OthelloGame, level uint64, searchTime time.Duration, moves []RankTile, pv []RankTile) *discordgo.MessageEmbed {
+	desc := getScoreText(game) + getOpeningText(game, moves) + getEvalText(game, moves) + getLineText(pv)
 	title := fmt.Sprintf("Game analysis using service level %d", level)
-	footer := "Positive heuristics are better for the player to move, and negative heuristics are worse"
+	footer := fmt.Sprintf("Positive heuristics are better for the player to move, and negative heuristics are worse\n"+
+		"Searched to depth %d with a %s limit, each level searches deeper and needs more time", AnalysisLevelToDepth(level), searchTime)
 	return &discordgo.MessageEmbed{
 		Title:       title,
 		Description: desc,
@@ -232,6 +465,52 @@ func createAnalysisEmbed(game OthelloGame, level uint64) *discordgo.MessageEmbed
 	}
 }
 
+func createHelpEmbed(commands []*discordgo.ApplicationCommand) *discordgo.MessageEmbed {
+	corner := Tile{Row: 0, Col: 0}
+	example := Tile{Row: 2, Col: 3}
+	coordinates := fmt.Sprintf("Columns are lettered A to H from left to right and rows are numbered 1 to 8 from top to "+
+		"bottom, so %s is the top left corner and %s is the fourth column of the third row.", corner.String(), example.String())
+
+	var desc strings.Builder
+	for _, cmd := range commands {
+		desc.WriteString(fmt.Sprintf("`/%s` %s\n", cmd.Name, cmd.Description))
+	}
+
+	return &discordgo.MessageEmbed{
+		Title:       "How to play Othello",
+		Description: desc.String(),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Coordinates", Value: coordinates},
+			{Name: "Moving", Value: "Black moves first. Use `/move` with a coordinate such as `/move d3`, the dots on the board mark the legal moves."},
+			{Name: "Flipping", Value: "A move must trap a line of your opponent's discs between the new disc and another of yours, " +
+				"horizontally, vertically or diagonally. Every trapped disc flips to your color. If you have no legal move your turn is passed."},
+			{Name: "Winning", Value: "The game ends when neither player can move, and the player with the most discs wins."},
+		},
+		Color: GreenEmbed,
+	}
+}
+
+func createHintEmbed(game OthelloGame, move Tile) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Hint for %s", game.CurrentPlayer().Name),
+		Description: fmt.Sprintf("Try %s", move.String()),
+		Color:       GreenEmbed,
+	}
+}
+
+func createCoachEmbed(game OthelloGame, candidates []RankTile) *discordgo.MessageEmbed {
+	var desc strings.Builder
+	for i, candidate := range candidates {
+		desc.WriteString(fmt.Sprintf("%d. %s (%.1f)\n", i+1, candidate.Tile.String(), candidate.H))
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Candidate replies for %s", game.CurrentPlayer().Name),
+		Description: desc.String(),
+		Footer:      &discordgo.MessageEmbedFooter{Text: "Positive heuristics are better for the bot, the first candidate is the bot's choice"},
+		Color:       GreenEmbed,
+	}
+}
+
 func createGameOverEmbed(game OthelloGame, result GameResult, statsResult StatsResult, move Tile) *discordgo.MessageEmbed {
 	desc := fmt.Sprintf("%s%s\n%s",
 		getMoveMessage(result.Winner, move.String()),
@@ -243,7 +522,7 @@ func createGameOverEmbed(game OthelloGame, result GameResult, statsResult StatsR
 
 func createForfeitEmbed(result GameResult, statsResult StatsResult) *discordgo.MessageEmbed {
 	desc := fmt.Sprintf("%s\n%s",
-		getForfeitMessage(result.Winner),
+		getForfeitMessage(result),
 		getStatsMessage(result, statsResult),
 	)
 	return &discordgo.MessageEmbed{
@@ -253,6 +532,19 @@ func createForfeitEmbed(result GameResult, statsResult StatsResult) *discordgo.M
 	}
 }
 
+func createDrawEmbed(game OthelloGame, statsResult StatsResult) *discordgo.MessageEmbed {
+	desc := fmt.Sprintf("%s and %s agreed to a draw\n%s",
+		game.BlackPlayer.Name,
+		game.WhitePlayer.Name,
+		getStatsMessage(GameResult{Winner: game.BlackPlayer, Loser: game.WhitePlayer, IsDraw: true}, statsResult),
+	)
+	return &discordgo.MessageEmbed{
+		Title:       "Game has ended",
+		Description: desc,
+		Color:       GreenEmbed,
+	}
+}
+
 func createSimulationEndEmbed(game OthelloGame, move Tile) *discordgo.MessageEmbed {
 	result := game.CreateResult()
 	desc := fmt.Sprintf("%s%s",
@@ -275,6 +567,31 @@ func createStatsEmbed(user discordgo.User, stats Stats) *discordgo.MessageEmbed
 			{Name: "Won", Value: strconv.Itoa(stats.Won), Inline: true},
 			{Name: "Lost", Value: strconv.Itoa(stats.Lost), Inline: true},
 			{Name: "Drawn", Value: strconv.Itoa(stats.Drawn), Inline: true},
+			{Name: "Forfeits", Value: strconv.Itoa(stats.Forfeits), Inline: true},
+		},
+		Thumbnail: &discordgo.MessageEmbedThumbnail{
+			URL:    user.AvatarURL("1024"),
+			Width:  1024,
+			Height: 1024,
+		},
+		Color: GreenEmbed,
+	}
+}
+
+// createProfileEmbed is the detailed view of createStatsEmbed with the player's peak rating and recent games
+func createProfileEmbed(user discordgo.User, stats Stats, profile Profile) *discordgo.MessageEmbed {
+	return &discordgo.MessageEmbed{
+		Title: fmt.Sprintf("%s's profile", user.Username),
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Rating", Value: fmt.Sprintf("%0.2f", stats.Elo), Inline: true},
+			{Name: "Peak Rating", Value: fmt.Sprintf("%0.2f", stats.PeakElo), Inline: true},
+			{Name: "Win Rate", Value: stats.WinRate(), Inline: false},
+			{Name: "Won", Value: strconv.Itoa(stats.Won), Inline: true},
+			{Name: "Lost", Value: strconv.Itoa(stats.Lost), Inline: true},
+			{Name: "Drawn", Value: strconv.Itoa(stats.Drawn), Inline: true},
+			{Name: "Forfeits", Value: strconv.Itoa(stats.Forfeits), Inline: true},
+			{Name: "Streak", Value: profile.FormatStreak(), Inline: false},
+			{Name: fmt.Sprintf("Last %d Games", FormGames), Value: profile.FormatForm(), Inline: false},
 		},
 		Thumbnail: &discordgo.MessageEmbedThumbnail{
 			URL:    user.AvatarURL("1024"),
@@ -285,11 +602,60 @@ func createStatsEmbed(user discordgo.User, stats Stats) *discordgo.MessageEmbed
 	}
 }
 
-func createLeaderboardEmbed(stats []Stats) *discordgo.MessageEmbed {
+func createReplayEmbed(player Player, opponent Player, row GameResultRow) *discordgo.MessageEmbed {
+	desc := fmt.Sprintf("%s won.", opponent.Name)
+	if row.IsDraw {
+		desc = "The game was drawn."
+	} else if row.WinnerID == player.ID {
+		desc = fmt.Sprintf("%s won.", player.Name)
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("Replay of %s vs %s", player.Name, opponent.Name),
+		Description: desc,
+		Color:       GreenEmbed,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Finished %s", time.UnixMilli(row.FinishedTime).UTC().Format(time.DateOnly)),
+		},
+	}
+}
+
+func createRecordEmbed(player Player, opponent Player, wins int, losses int, draws int) *discordgo.MessageEmbed {
+	desc := fmt.Sprintf("%s hasn't finished a game against %s yet.", player.Name, opponent.Name)
+	if wins+losses+draws > 0 {
+		desc = fmt.Sprintf("%s leads %d - %d with %d drawn.", player.Name, wins, losses, draws)
+		if losses > wins {
+			desc = fmt.Sprintf("%s leads %d - %d with %d drawn.", opponent.Name, losses, wins, draws)
+		} else if losses == wins {
+			desc = fmt.Sprintf("Tied %d - %d with %d drawn.", wins, losses, draws)
+		}
+	}
+	return &discordgo.MessageEmbed{
+		Title:       fmt.Sprintf("%s vs %s", player.Name, opponent.Name),
+		Description: desc,
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Won", Value: strconv.Itoa(wins), Inline: true},
+			{Name: "Lost", Value: strconv.Itoa(losses), Inline: true},
+			{Name: "Drawn", Value: strconv.Itoa(draws), Inline: true},
+		},
+		Color: GreenEmbed,
+	}
+}
+
+const EmptyLeaderboardMsg = "No ranked players yet — play a game to get on the board!"
+
+func createLeaderboardEmbed(stats []Stats, page int, pageCount int) *discordgo.MessageEmbed {
+	if len(stats) == 0 {
+		return &discordgo.MessageEmbed{
+			Title:       "Leaderboard",
+			Description: EmptyLeaderboardMsg,
+			Color:       GreenEmbed,
+		}
+	}
+
 	var desc strings.Builder
 	desc.WriteString("```\n")
 	for i, stats := range stats {
-		desc.WriteString(rightPad(fmt.Sprintf("%d)", i+1), 4))
+		desc.WriteString(rightPad(fmt.Sprintf("%d)", page*LeaderboardSize+i+1), 5))
 		desc.WriteString(leftPad(stats.Player.Name, 32))
 		desc.WriteString(leftPad(fmt.Sprintf("%.2f", stats.Elo), 12))
 		desc.WriteString("\n")
@@ -301,15 +667,147 @@ func createLeaderboardEmbed(stats []Stats) *discordgo.MessageEmbed {
 		Description: desc.String(),
 		Color:       GreenEmbed,
 		Footer: &discordgo.MessageEmbedFooter{
-			Text: fmt.Sprintf("Top %d rated players", LeaderboardSize),
+			Text: fmt.Sprintf("Page %d of %d", page+1, pageCount),
+		},
+	}
+}
+
+const EmptyFastestMsg = "No wins recorded yet — finish a game to get on the board!"
+
+func createFastestEmbed(wins []FastestWin) *discordgo.MessageEmbed {
+	if len(wins) == 0 {
+		return &discordgo.MessageEmbed{
+			Title:       "Fastest Wins",
+			Description: EmptyFastestMsg,
+			Color:       GreenEmbed,
+		}
+	}
+
+	var desc strings.Builder
+	desc.WriteString("```\n")
+	for i, win := range wins {
+		desc.WriteString(rightPad(fmt.Sprintf("%d)", i+1), 4))
+		desc.WriteString(leftPad(win.Player.Name, 32))
+		desc.WriteString(leftPad(win.Duration.Round(time.Second).String(), 12))
+		desc.WriteString("\n")
+	}
+	desc.WriteString("```")
+
+	return &discordgo.MessageEmbed{
+		Title:       "Fastest Wins",
+		Description: desc.String(),
+		Color:       GreenEmbed,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: fmt.Sprintf("Top %d fastest games won, from challenge to final move", LeaderboardSize),
+		},
+	}
+}
+
+func createBotPerformanceEmbed(performances []BotPerformance) *discordgo.MessageEmbed {
+	var desc strings.Builder
+	desc.WriteString("```\n")
+	desc.WriteString(rightPad("Level", 7))
+	desc.WriteString(leftPad("Games", 8))
+	desc.WriteString(leftPad("W-L-D", 14))
+	desc.WriteString(leftPad("Win Rate", 10))
+	desc.WriteString("\n")
+	for _, p := range performances {
+		desc.WriteString(rightPad(strconv.FormatUint(p.Level, 10), 7))
+		desc.WriteString(leftPad(strconv.Itoa(p.Games()), 8))
+		desc.WriteString(leftPad(fmt.Sprintf("%d-%d-%d", p.Wins, p.Losses, p.Draws), 14))
+		desc.WriteString(leftPad(p.WinRate(), 10))
+		desc.WriteString("\n")
+	}
+	desc.WriteString("```")
+
+	return &discordgo.MessageEmbed{
+		Title:       "Bot Performance",
+		Description: desc.String(),
+		Color:       GreenEmbed,
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: "Results of each bot level against users, from the bot's perspective",
 		},
 	}
 }
 
+func createBrandingEmbed(cfg GuildConfig) *discordgo.MessageEmbed {
+	prefix := cfg.TitlePrefix
+	if prefix == "" {
+		prefix = "None"
+	}
+	return &discordgo.MessageEmbed{
+		Title: "Branding updated",
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: "Title Prefix", Value: prefix, Inline: true},
+			{Name: "Color", Value: fmt.Sprintf("#%06x", cfg.Color), Inline: true},
+		},
+		Color: GreenEmbed,
+	}
+}
+
+// getOpeningText names the opening of the game, noting when the engine's best move came from its opening book
+func getOpeningText(game OthelloGame, moves []RankTile) string {
+	name := IdentifyOpening(game.MoveList)
+	if len(moves) > 0 && moves[0].Book {
+		return fmt.Sprintf("Opening: %s, best move from the opening book\n", name)
+	}
+	return fmt.Sprintf("Opening: %s\n", name)
+}
+
+const EvalBarSquares = 10
+
+// FormatEvalBar draws an evaluation from black's perspective as a bar of squares, black's share is filled from the
+// left and extreme evaluations fill the whole bar
+func FormatEvalBar(h float64) string {
+	blackSquares := int(math.Round(EvalBarFill(h) * EvalBarSquares))
+	bar := strings.Repeat("■", blackSquares) + strings.Repeat("□", EvalBarSquares-blackSquares)
+
+	favored := "Even"
+	if h > 0 {
+		favored = fmt.Sprintf("Black +%.2f", h)
+	} else if h < 0 {
+		favored = fmt.Sprintf("White +%.2f", -h)
+	}
+	return fmt.Sprintf("[%s] %s", bar, favored)
+}
+
+// getEvalText shows the evaluation of the position as the heuristic of the best move, the heuristics are from the
+// perspective of the player to move so it's negated when white is to move
+func getEvalText(game OthelloGame, moves []RankTile) string {
+	if len(moves) == 0 {
+		return ""
+	}
+	h := slices.MaxFunc(moves, func(a RankTile, b RankTile) int {
+		return cmp.Compare(a.H, b.H)
+	}).H
+	if !game.Board.IsBlackMove {
+		h = -h
+	}
+	return fmt.Sprintf("Evaluation: %s\n", FormatEvalBar(h))
+}
+
+// getLineText shows the expected line of play, engines that can't find one have no line
+func getLineText(pv []RankTile) string {
+	if len(pv) == 0 {
+		return ""
+	}
+	var tiles []string
+	for _, move := range pv {
+		tiles = append(tiles, move.Tile.String())
+	}
+	return fmt.Sprintf("Expected line: %s\n", strings.Join(tiles, " "))
+}
+
 func getScoreText(game OthelloGame) string {
 	return fmt.Sprintf("Black: %d points\nWhite: %d points\n", game.Board.BlackScore(), game.Board.WhiteScore())
 }
 
+// getProgressText shows how far into the game the board is, the discs placed include the four starting discs
+func getProgressText(game OthelloGame) string {
+	empty := game.Board.CountEmpty()
+	return fmt.Sprintf("Discs placed: %d\nEmpties remaining: %d\n", BoardSize*BoardSize-empty, empty)
+}
+
 func getStatsMessage(gameRes GameResult, statsRes StatsResult) string {
 	return fmt.Sprintf("%s's new rating is %d (%s) \n %s's new rating is %d (%s)\n",
 		gameRes.Winner.Name,
@@ -320,8 +818,36 @@ func getStatsMessage(gameRes GameResult, statsRes StatsResult) string {
 		statsRes.FormatLoserEloDiff())
 }
 
-func getForfeitMessage(winner Player) string {
-	return fmt.Sprintf("%s won by forfeit\n", winner.Name)
+// createChallengersMessage lists the players with a pending challenge so the user can pick which one to accept
+func createChallengersMessage(challengers []Player) string {
+	var mentions []string
+	for _, challenger := range challengers {
+		mentions = append(mentions, fmt.Sprintf("<@%s>", challenger.ID))
+	}
+	return fmt.Sprintf("You have challenges from %s, use `/accept` with the challenger you want to play.", strings.Join(mentions, ", "))
+}
+
+// createAbortMessage notifies the human players that their game was aborted, either color may be played by a bot
+func createAbortMessage(game OthelloGame) string {
+	var mentions []string
+	if game.BlackPlayer.IsHuman() {
+		mentions = append(mentions, fmt.Sprintf("<@%s>", game.BlackPlayer.ID))
+	}
+	if game.WhitePlayer.IsHuman() && game.WhitePlayer.ID != game.BlackPlayer.ID {
+		mentions = append(mentions, fmt.Sprintf("<@%s>", game.WhitePlayer.ID))
+	}
+	return fmt.Sprintf("%s The game between %s and %s was aborted before any moves, no ratings were changed.",
+		strings.Join(mentions, " "), game.BlackPlayer.Name, game.WhitePlayer.Name)
+}
+
+func getForfeitMessage(result GameResult) string {
+	if result.Kind == Aborted {
+		return fmt.Sprintf("Game between %s and %s was ended without a winner\n", result.Winner.Name, result.Loser.Name)
+	}
+	if result.Kind == Timeout {
+		return fmt.Sprintf("%s won on time\n", result.Winner.Name)
+	}
+	return fmt.Sprintf("%s won by forfeit\n", result.Winner.Name)
 }
 
 func getScoreMessage(whiteScore, blackScore int) string {
@@ -0,0 +1,242 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCreateLeaderboardEmbed_Empty(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-empty-leaderboard")
+
+	uc := MakeUserCache(&MockUserFetcher{})
+	stats, err := ReadTopStats(ctx, db, &uc, "", LeaderboardSize, 0)
+	if err != nil {
+		t.Fatalf("failed to read top stats: %v", err)
+	}
+
+	embed := createLeaderboardEmbed(stats, 0, 1)
+	assert.Equal(t, EmptyLeaderboardMsg, embed.Description)
+}
+
+func TestCreateLeaderboardEmbed_Page(t *testing.T) {
+	stats := []Stats{{Player: Player{ID: "id1", Name: "Player1"}, Elo: 1500}}
+
+	embed := createLeaderboardEmbed(stats, 2, 3)
+	assert.Contains(t, embed.Description, fmt.Sprintf("%d)", 2*LeaderboardSize+1))
+	assert.Equal(t, "Page 3 of 3", embed.Footer.Text)
+}
+
+func TestCreateLeaderboardActionRow(t *testing.T) {
+	createdTime := time.Unix(1700000000, 0)
+
+	assert.Nil(t, createLeaderboardActionRow("", 0, 1, createdTime))
+
+	row := createLeaderboardActionRow("guild1", 0, 3, createdTime)[0].(discordgo.ActionsRow)
+	prev := row.Components[0].(discordgo.Button)
+	next := row.Components[1].(discordgo.Button)
+	assert.True(t, prev.Disabled)
+	assert.False(t, next.Disabled)
+
+	cond, key := parseCustomId(next.CustomID)
+	page, created, guildID := parseLeaderboardKey(key)
+	assert.Equal(t, LeaderboardPageKey, cond)
+	assert.Equal(t, 1, page)
+	assert.Equal(t, createdTime, created)
+	assert.Equal(t, "guild1", guildID)
+}
+
+func TestCreateRematchActionRow(t *testing.T) {
+	game := OthelloGame{BlackPlayer: Player{ID: "id1", Name: "Player1"}, WhitePlayer: MakeBotPlayer(4)}
+
+	finishedTime := time.Unix(1700000000, 0)
+
+	row := createRematchActionRow(game, finishedTime)[0].(discordgo.ActionsRow)
+	button := row.Components[0].(discordgo.Button)
+
+	cond, key := parseCustomId(button.CustomID)
+	blackID, whiteID, finished := parseRematchKey(key)
+	assert.Equal(t, RematchKey, cond)
+	assert.Equal(t, "id1", blackID)
+	assert.Equal(t, "4", whiteID)
+	assert.Equal(t, finishedTime, finished)
+}
+
+func TestCreateComponentResponse_Private(t *testing.T) {
+	embed := &discordgo.MessageEmbed{Title: "Leaderboard"}
+
+	resp := createComponentResponse(embed, nil, nil, privateFlags(true))
+	assert.Equal(t, discordgo.MessageFlagsEphemeral, resp.Data.Flags)
+
+	resp = createComponentResponse(embed, nil, nil, privateFlags(false))
+	assert.Zero(t, resp.Data.Flags)
+}
+
+func TestCreateGameEmbed_Progress(t *testing.T) {
+	game := OthelloGame{WhitePlayer: Player{ID: "id1", Name: "Player1"}, BlackPlayer: Player{ID: "id2", Name: "Player2"}, Board: MakeInitialBoard()}
+	game.MakeMove(game.Board.FindCurrentMoves()[0])
+
+	embed := createGameEmbed(game)
+	assert.Contains(t, embed.Description, "Discs placed: 5\nEmpties remaining: 59\n")
+}
+
+func TestCreateMovesMessage(t *testing.T) {
+	game := OthelloGame{WhitePlayer: Player{ID: "id1", Name: "Player1"}, BlackPlayer: Player{ID: "id2", Name: "Player2"}, Board: MakeInitialBoard()}
+
+	msg := createMovesMessage(game)
+	assert.Equal(t, "Player2 has 4 legal moves: C4, E6, F5, D3", msg)
+}
+
+func TestCreateAnalysisEmbed_Opening(t *testing.T) {
+	game := OthelloGame{WhitePlayer: Player{ID: "id1", Name: "Player1"}, BlackPlayer: Player{ID: "id2", Name: "Player2"}, Board: MakeInitialBoard()}
+	game.MakeMove(ParseTile("f5"))
+	game.MakeMove(ParseTile("f6"))
+
+	embed := createAnalysisEmbed(game, 3, DefaultAnalysisTime, []RankTile{{Tile: ParseTile("e6"), H: 2, Book: true}}, nil)
+	assert.Contains(t, embed.Description, "Opening: Diagonal, best move from the opening book")
+
+	embed = createAnalysisEmbed(game, 3, DefaultAnalysisTime, []RankTile{{Tile: ParseTile("e6"), H: 2}}, nil)
+	assert.Contains(t, embed.Description, "Opening: Diagonal\n")
+}
+
+func TestFormatEvalBar(t *testing.T) {
+	type Test struct {
+		h      float64
+		expBar string
+	}
+	tests := []Test{
+		{h: 0, expBar: "[■■■■■□□□□□] Even"},
+		{h: 12.8, expBar: "[■■■■■■□□□□] Black +12.80"},
+		{h: -32, expBar: "[■■■□□□□□□□] White +32.00"},
+		{h: 500, expBar: "[■■■■■■■■■■] Black +500.00"},
+		{h: -500, expBar: "[□□□□□□□□□□] White +500.00"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			assert.Equal(t, test.expBar, FormatEvalBar(test.h))
+		})
+	}
+}
+
+func TestCreateAnalysisEmbed_Eval(t *testing.T) {
+	game := OthelloGame{WhitePlayer: Player{ID: "id1", Name: "Player1"}, BlackPlayer: Player{ID: "id2", Name: "Player2"}, Board: MakeInitialBoard()}
+	game.MakeMove(ParseTile("f5"))
+
+	// white is to move, so white's best move of +32 favors white
+	moves := []RankTile{{Tile: ParseTile("f6"), H: -4}, {Tile: ParseTile("d6"), H: 32}}
+	embed := createAnalysisEmbed(game, 3, DefaultAnalysisTime, moves, nil)
+	assert.Contains(t, embed.Description, "Evaluation: [■■■□□□□□□□] White +32.00\n")
+
+	embed = createAnalysisEmbed(game, 3, DefaultAnalysisTime, nil, nil)
+	assert.NotContains(t, embed.Description, "Evaluation")
+}
+
+func TestCreateAnalysisEmbed_Line(t *testing.T) {
+	game := OthelloGame{WhitePlayer: Player{ID: "id1", Name: "Player1"}, BlackPlayer: Player{ID: "id2", Name: "Player2"}, Board: MakeInitialBoard()}
+
+	le := MakeLocalEngineShell()
+	pv, ok := le.FindPrincipalVariation(game.Board, 4)
+	if !ok {
+		t.Fatal("expected a principal variation from the initial board")
+	}
+	var tiles []string
+	for _, move := range pv {
+		tiles = append(tiles, move.Tile.String())
+	}
+
+	embed := createAnalysisEmbed(game, 3, DefaultAnalysisTime, nil, pv)
+	assert.Contains(t, embed.Description, fmt.Sprintf("Expected line: %s\n", strings.Join(tiles, " ")))
+
+	embed = createAnalysisEmbed(game, 3, DefaultAnalysisTime, nil, nil)
+	assert.NotContains(t, embed.Description, "Expected line")
+}
+
+func TestCreateHintEmbed(t *testing.T) {
+	game := OthelloGame{WhitePlayer: Player{ID: "id1", Name: "Player1"}, BlackPlayer: Player{ID: "id2", Name: "Player2"}, Board: MakeInitialBoard()}
+
+	embed := createHintEmbed(game, Tile{Row: 3, Col: 2})
+	assert.Equal(t, "Hint for Player2", embed.Title)
+	assert.Equal(t, "Try C4", embed.Description)
+}
+
+func TestCreateMatchEmbed(t *testing.T) {
+	match := Match{Player1ID: "id1", Player2ID: "id2", Player1Name: "Player1", Player2Name: "Player2", BestOf: 5, Player1Wins: 2, Player2Wins: 1, Draws: 1}
+	next := OthelloGame{BlackPlayer: match.Player2(), WhitePlayer: match.Player1(), Board: MakeInitialBoard()}
+
+	embed := createMatchEmbed(MatchResult{Match: match, NextGame: next})
+	assert.Equal(t, "Best of 5 Match, Game 5", embed.Title)
+	assert.Contains(t, embed.Description, "Player1 2 - 1 Player2, 1 drawn")
+	assert.Contains(t, embed.Description, "Black: Player2")
+
+	match.Player1Wins = 3
+	embed = createMatchEmbed(MatchResult{Match: match, IsOver: true, Winner: match.Player1()})
+	assert.Equal(t, "Match has ended", embed.Title)
+	assert.Contains(t, embed.Description, "Player1 won the best of 5 match")
+
+	match = Match{Player1ID: "id1", Player2ID: "id2", Player1Name: "Player1", Player2Name: "Player2", BestOf: 3, Draws: MaxMatchDraws + 1}
+	embed = createMatchEmbed(MatchResult{Match: match, IsOver: true, IsDrawn: true})
+	assert.Equal(t, "Match drawn", embed.Title)
+	assert.Contains(t, embed.Description, fmt.Sprintf("ended level after %d drawn games", MaxMatchDraws+1))
+}
+
+func TestCreateChallengersMessage(t *testing.T) {
+	msg := createChallengersMessage([]Player{{ID: "id1", Name: "Player1"}, {ID: "id2", Name: "Player2"}})
+	assert.Contains(t, msg, "<@id1>, <@id2>")
+	assert.Contains(t, msg, "`/accept`")
+}
+
+func TestCreateHelpEmbed(t *testing.T) {
+	embed := createHelpEmbed(Commands)
+
+	for _, cmd := range Commands {
+		assert.Contains(t, embed.Description, fmt.Sprintf("`/%s`", cmd.Name))
+	}
+	assert.Contains(t, embed.Fields[0].Value, "A1")
+	assert.Contains(t, embed.Fields[0].Value, ParseTile("d3").String())
+	assert.LessOrEqual(t, len(embed.Description), 4096)
+}
+
+func TestAddEmbedFiles_Png(t *testing.T) {
+	board := MakeInitialBoard()
+	img := MakeRenderCache().DrawBoardMoves(board, board.FindCurrentMoves())
+
+	embed := &discordgo.MessageEmbed{}
+	files := addEmbedFiles(embed, img)
+	assert.Len(t, files, 1)
+	assert.Equal(t, "image/png", files[0].ContentType)
+	assert.Equal(t, "attachment://"+files[0].Name, embed.Image.URL)
+
+	// flat colors compress well, so the lossless image stays small enough for a quick upload
+	data, err := io.ReadAll(files[0].Reader)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+	assert.Less(t, len(data), 256*1024)
+
+	decoded, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("failed to decode png: %v", err)
+	}
+	assert.Equal(t, img.Bounds(), decoded.Bounds())
+}
+
+func TestCreateBotPerformanceEmbed(t *testing.T) {
+	embed := createBotPerformanceEmbed([]BotPerformance{{Level: 1, Wins: 3, Losses: 1}, {Level: 2}})
+
+	assert.Equal(t, "Bot Performance", embed.Title)
+	assert.Contains(t, embed.Description, "3-1-0")
+	assert.Contains(t, embed.Description, "75.00%")
+	assert.Contains(t, embed.Description, "0-0-0")
+}
@@ -2,14 +2,18 @@ package app
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
-	"log"
+	"io"
 	"log/slog"
+	"os"
 	"os/exec"
-	"slices"
 	"strings"
+	"sync"
 	"time"
+
+	"go.uber.org/atomic"
 )
 
 type MoveRequestKind int
@@ -24,6 +28,7 @@ type MoveReq struct {
 	Game   OthelloGame
 	Depth  uint64
 	RespCh chan MoveResp
+	Ctx    context.Context // stops the search once done, a nil context searches until the engine timeout
 }
 
 type MoveResp struct {
@@ -31,33 +36,88 @@ type MoveResp struct {
 	Err   error
 }
 
+// EngineShell finds moves for a game, responses are sent on the returned channel once the search completes
+type EngineShell interface {
+	FindBestMove(game OthelloGame, depth uint64) chan MoveResp
+	FindRankedMoves(game OthelloGame, depth uint64) chan MoveResp
+}
+
+// CancelableEngineShell is an engine whose ranked search stops once the context is done, so a search nobody waits for
+// doesn't hold up the engine
+type CancelableEngineShell interface {
+	FindRankedMovesContext(ctx context.Context, game OthelloGame, depth uint64) chan MoveResp
+}
+
+// findRankedMovesContext ranks the moves with a search tied to the context when the engine can stop its searches
+func findRankedMovesContext(ctx context.Context, sh EngineShell, game OthelloGame, depth uint64) chan MoveResp {
+	if cs, ok := sh.(CancelableEngineShell); ok {
+		return cs.FindRankedMovesContext(ctx, game, depth)
+	}
+	return sh.FindRankedMoves(game, depth)
+}
+
+// PrincipalVariationFinder is an engine that can find the expected line of play from a position
+type PrincipalVariationFinder interface {
+	FindPrincipalVariation(board OthelloBoard, maxDepth uint64) ([]RankTile, bool)
+}
+
 type NTestShell struct {
-	stdout    *bufio.Scanner
-	stdin     *bufio.Writer
-	moveReqCh chan MoveReq
+	path       string
+	cmd        *exec.Cmd
+	stdout     *bufio.Scanner
+	stdoutPipe io.Closer
+	stdin      *bufio.Writer
+	timeout    time.Duration // the longest a request may take before the process is considered stuck
+	eof        bool          // the last scan of stdout failed, so the process has exited or its pipe is broken
+	mu         sync.Mutex    // guards restarting and closed, the process is only replaced while restarting is set
+	restarting bool
+	closed     bool // the shell was closed and the process must not be restarted
+	moveReqCh  chan MoveReq
+	busy       *atomic.Int32 // counts the requests being searched by the processes of a pool, nil outside a pool
 }
 
 var ErrEmptyPath = errors.New("path argument should not be empty")
+var ErrEngineDied = errors.New("ntest process stopped responding")
+var ErrEngineRestarting = errors.New("ntest process is restarting")
+var ErrEngineTimeout = errors.New("ntest process timed out")
+var ErrEngineCancelled = errors.New("ntest search was cancelled")
+
+// EngineTimeout is longer than any analysis waits, so only an engine that has stopped responding reaches it
+const EngineTimeout = time.Second * (MaxAnalysisTime + 60)
+const RankedMovesAttempts = 2
 
 func StartNTestShell(path string) (*NTestShell, error) {
-	if path == "" {
-		return nil, ErrEmptyPath
+	sh := &NTestShell{path: path, timeout: EngineTimeout, moveReqCh: make(chan MoveReq)}
+	if err := sh.start(); err != nil {
+		return nil, err
+	}
+	return sh, nil
+}
+
+// start launches the ntest process and waits for its greeting
+func (sh *NTestShell) start() error {
+	if sh.path == "" {
+		return ErrEmptyPath
 	}
-	cmd := exec.Command(path)
+	cmd := exec.Command(sh.path)
 
 	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open stdout pipe to ntest: %v", err)
+		return fmt.Errorf("failed to open stdout pipe to ntest: %v", err)
 	}
 	stdin, err := cmd.StdinPipe()
 	if err != nil {
-		return nil, fmt.Errorf("failed to open stdin pipe to ntest: %v", err)
+		return fmt.Errorf("failed to open stdin pipe to ntest: %v", err)
 	}
 
-	sh := &NTestShell{stdout: bufio.NewScanner(stdout), stdin: bufio.NewWriter(stdin), moveReqCh: make(chan MoveReq)}
+	sh.cmd = cmd
+	sh.stdout = bufio.NewScanner(stdout)
+	sh.stdoutPipe = stdout
+	sh.stdin = bufio.NewWriter(stdin)
+	sh.eof = false
 
 	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start ntest: %v", err)
+		return fmt.Errorf("failed to start ntest: %v", err)
 	}
 
 	var startLines = []string{
@@ -68,23 +128,134 @@ func StartNTestShell(path string) (*NTestShell, error) {
 	}
 	for _, line := range startLines {
 		if err := sh.expect(line); err != nil {
-			return nil, err
+			return err
 		}
 	}
-	return sh, nil
+	return nil
+}
+
+func (sh *NTestShell) isRestarting() bool {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	return sh.restarting
+}
+
+// restart replaces a dead ntest process in the background, requests made until it is ready receive
+// ErrEngineRestarting so they don't wait on a process that may never start
+func (sh *NTestShell) restart() {
+	sh.mu.Lock()
+	if sh.restarting || sh.closed {
+		sh.mu.Unlock()
+		return
+	}
+	sh.restarting = true
+	sh.mu.Unlock()
+
+	go func() {
+		defer func() {
+			sh.mu.Lock()
+			sh.restarting = false
+			if sh.closed {
+				// the shell was closed during the restart, so the new process is stopped here
+				_ = sh.stop()
+			}
+			sh.mu.Unlock()
+		}()
+
+		_ = sh.stop()
+		slog.Warn("restarting ntest process", "path", sh.path)
+		if err := sh.start(); err != nil {
+			// the next request fails on the dead process again and tries another restart
+			slog.Error("failed to restart ntest process", "path", sh.path, "err", err)
+			return
+		}
+		slog.Info("restarted ntest process", "path", sh.path)
+	}()
+}
+
+// stop kills the process and waits for it to exit
+func (sh *NTestShell) stop() error {
+	if sh.cmd == nil || sh.cmd.Process == nil {
+		return nil
+	}
+	if err := sh.cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+		return fmt.Errorf("failed to kill ntest: %w", err)
+	}
+	_ = sh.cmd.Wait()
+	return nil
+}
+
+// Close stops the process, a request in flight fails and later requests fail without restarting it
+func (sh *NTestShell) Close() error {
+	sh.mu.Lock()
+	defer sh.mu.Unlock()
+	sh.closed = true
+	if sh.restarting {
+		return nil
+	}
+	return sh.stop()
+}
+
+// withDeadline runs a request against the process, closing stdout if the request takes longer than the timeout so a
+// read blocked on a stuck engine returns instead of holding up every request behind it. Stdout is closed the same way
+// once the context is done, ntest can't abandon a search so this is the only way to stop one
+func (sh *NTestShell) withDeadline(ctx context.Context, request func() error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	var timedOut, cancelled atomic.Bool
+	timer := time.AfterFunc(sh.timeout, func() {
+		timedOut.Store(true)
+		slog.Error("ntest request timed out, closing stdout", "timeout", sh.timeout)
+		_ = sh.stdoutPipe.Close()
+	})
+	stopCancel := context.AfterFunc(ctx, func() {
+		cancelled.Store(true)
+		slog.Warn("ntest request was cancelled, closing stdout", "err", ctx.Err())
+		_ = sh.stdoutPipe.Close()
+	})
+	err := request()
+	timer.Stop()
+	stopCancel()
+
+	// the process can't be trusted after stdout was closed, so it is restarted like a dead process
+	if timedOut.Load() {
+		return fmt.Errorf("%w after %s: %w", ErrEngineTimeout, sh.timeout, ErrEngineDied)
+	}
+	if cancelled.Load() {
+		return fmt.Errorf("%w: %w: %w", ErrEngineCancelled, ctx.Err(), ErrEngineDied)
+	}
+	return err
 }
 
 func (sh *NTestShell) stdinWrite(cmd string) error {
 	slog.Info("writing cmd to stdin", "cmd", cmd)
 	if _, err := sh.stdin.WriteString(cmd); err != nil {
-		return fmt.Errorf("failed to stdinWrite to ntest stdin: %v", err)
+		return fmt.Errorf("%w: failed to stdinWrite to ntest stdin: %v", ErrEngineDied, err)
 	}
 	if err := sh.stdin.Flush(); err != nil {
-		return fmt.Errorf("failed to flush ntest stdin: %v", err)
+		return fmt.Errorf("%w: failed to flush ntest stdin: %v", ErrEngineDied, err)
 	}
 	return nil
 }
 
+// scan reads the next line of stdout, remembering when stdout has ended
+func (sh *NTestShell) scan() bool {
+	sh.eof = !sh.stdout.Scan()
+	return !sh.eof
+}
+
+// scanErr is the reason the last scan of stdout failed, stdout only ends without an error when the process has exited
+func (sh *NTestShell) scanErr() error {
+	if !sh.eof {
+		return nil
+	}
+	if err := sh.stdout.Err(); err != nil {
+		return fmt.Errorf("%w: %v", ErrEngineDied, err)
+	}
+	return ErrEngineDied
+}
+
 func (sh *NTestShell) stdoutText() string {
 	line := sh.stdout.Text()
 	if line != "" {
@@ -94,16 +265,13 @@ func (sh *NTestShell) stdoutText() string {
 }
 
 func (sh *NTestShell) expect(expected string) error {
-	if sh.stdout.Scan() {
+	if sh.scan() {
 		line := sh.stdoutText()
 		if line != expected {
 			return fmt.Errorf("expected: %s from ntest stdout, got: %s", expected, line)
 		}
 	}
-	if err := sh.stdout.Err(); err != nil {
-		return err
-	}
-	return nil
+	return sh.scanErr()
 }
 
 func (sh *NTestShell) depthCmd(depth uint64) error {
@@ -111,24 +279,45 @@ func (sh *NTestShell) depthCmd(depth uint64) error {
 		return err
 	}
 
-	for sh.stdout.Scan() {
+	for sh.scan() {
 		line := sh.stdoutText()
 		if strings.Contains(line, "set myname") {
 			break
 		}
 	}
-	if err := sh.stdout.Err(); err != nil {
+	if err := sh.scanErr(); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+var ErrGGFMismatch = errors.New("serialized game does not match the game board")
+
+// verifyGGF re-parses the serialized game to check the engine will analyze the same position as the in memory board
+func verifyGGF(game OthelloGame, ggf string) error {
+	parsed, err := UnmarshalGGF(ggf)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrGGFMismatch, err)
+	}
+	if parsed.Board != game.Board {
+		return ErrGGFMismatch
+	}
+	return nil
+}
+
 func (sh *NTestShell) setGameCmd(game OthelloGame) error {
-	return sh.stdinWrite(fmt.Sprintf("set game %s\n", game.MarshalGGF()))
+	ggf := game.MarshalGGF()
+	if err := verifyGGF(game, ggf); err != nil {
+		slog.Error("aborting engine request for mismatched game", "game", ggf, "board", game.Board.MarshalString(), "err", err)
+		return err
+	}
+	return sh.stdinWrite(fmt.Sprintf("set game %s\n", ggf))
 }
 
-var ErrInvalidGameState = errors.New("game state GGF format is invalid")
+// ErrEnginePass is returned when the engine replies with a pass instead of a move, MakeMove already passes for a side
+// without moves so the engine is only asked to move when it has one and a pass is an engine error
+var ErrEnginePass = errors.New("engine passed instead of moving")
 
 func (sh *NTestShell) goCmd() (RankTile, error) {
 	if err := sh.stdinWrite("go\n"); err != nil {
@@ -138,19 +327,19 @@ func (sh *NTestShell) goCmd() (RankTile, error) {
 	var target string
 	const head = "=== "
 
-	for sh.stdout.Scan() {
+	for sh.scan() {
 		line := sh.stdoutText()
 		if strings.Contains(line, head) {
 			target = strings.TrimPrefix(line, head)
 			break
 		}
 	}
-	if err := sh.stdout.Err(); err != nil {
+	if err := sh.scanErr(); err != nil {
 		return RankTile{}, err
 	}
 
 	if strings.Contains(target, "PA") {
-		return RankTile{}, ErrInvalidGameState
+		return RankTile{}, ErrEnginePass
 	}
 
 	tokens := strings.Split(target, "/")
@@ -178,7 +367,7 @@ func (sh *NTestShell) hintCmd() ([]RankTile, []error) {
 	var errs []error
 	var tileMap [BoardSize][BoardSize]Pair
 
-	for sh.stdout.Scan() {
+	for sh.scan() {
 		line := sh.stdoutText()
 		if line == "status" {
 			break
@@ -190,6 +379,7 @@ func (sh *NTestShell) hintCmd() ([]RankTile, []error) {
 				continue
 			}
 			tile, err := ParseRankTile(tokens[1], tokens[2])
+			tile.Book = tokens[0] == "book"
 			if err == nil {
 				tileMap[tile.Tile.Row][tile.Tile.Col] = Pair{set: true, tile: tile}
 			} else {
@@ -197,7 +387,7 @@ func (sh *NTestShell) hintCmd() ([]RankTile, []error) {
 			}
 		}
 	}
-	if err := sh.stdout.Err(); err != nil {
+	if err := sh.scanErr(); err != nil {
 		errs = append(errs, err)
 	}
 
@@ -213,6 +403,30 @@ func (sh *NTestShell) hintCmd() ([]RankTile, []error) {
 	return tiles, errs
 }
 
+const (
+	MidgameMobility   = 8
+	MidgameEmpties    = 20
+	MidgameDepthBonus = 2
+)
+
+// AllocateDepth scales the depth of a bot move by the phase of the game, searching deeper in a complex midgame and
+// shallower when the move is forced or the game is close to finished
+func AllocateDepth(board OthelloBoard, depth uint64) uint64 {
+	moves := len(board.FindCurrentMoves())
+	empty := uint64(board.CountEmpty())
+
+	switch {
+	case moves <= 1:
+		return 1
+	case empty <= depth:
+		// the engine can't search past the last empty square
+		return empty
+	case moves >= MidgameMobility && empty >= MidgameEmpties:
+		return depth + MidgameDepthBonus
+	}
+	return depth
+}
+
 var ErrNoMoves = errors.New("no moves for game")
 
 func (sh *NTestShell) findBestMove(game OthelloGame, depth uint64) (RankTile, error) {
@@ -238,7 +452,22 @@ func (sh *NTestShell) findBestMove(game OthelloGame, depth uint64) (RankTile, er
 	return tile, err
 }
 
+// findRankedMoves retries a search that produced unreadable rankings, a dead process is not retried since it will be
+// restarted instead
 func (sh *NTestShell) findRankedMoves(game OthelloGame, depth uint64) ([]RankTile, error) {
+	var tiles []RankTile
+	var err error
+	for attempt := 1; attempt <= RankedMovesAttempts; attempt++ {
+		tiles, err = sh.searchRankedMoves(game, depth)
+		if err == nil || errors.Is(err, ErrEngineDied) {
+			break
+		}
+		slog.Warn("failed ranked moves attempt", "attempt", attempt, "err", err)
+	}
+	return tiles, err
+}
+
+func (sh *NTestShell) searchRankedMoves(game OthelloGame, depth uint64) ([]RankTile, error) {
 	if err := sh.depthCmd(depth); err != nil {
 		return nil, err
 	}
@@ -259,24 +488,52 @@ func (sh *NTestShell) findRankedMoves(game OthelloGame, depth uint64) ([]RankTil
 
 func (sh *NTestShell) ListenRequests() {
 	for req := range sh.moveReqCh {
+		if sh.isRestarting() {
+			req.RespCh <- MoveResp{Err: ErrEngineRestarting}
+			continue
+		}
+		if req.Ctx != nil && req.Ctx.Err() != nil {
+			// the requester stopped waiting while the request was queued
+			req.RespCh <- MoveResp{Err: fmt.Errorf("%w: %w", ErrEngineCancelled, req.Ctx.Err())}
+			continue
+		}
+		if sh.busy != nil {
+			sh.busy.Inc()
+		}
 		start := time.Now()
+		var err error
 		switch req.Kind {
 		case BestMoveKind:
-			move, err := sh.findBestMove(req.Game, req.Depth)
+			var move RankTile
+			err = sh.withDeadline(req.Ctx, func() (err error) {
+				move, err = sh.findBestMove(req.Game, req.Depth)
+				return err
+			})
 			if err != nil {
 				slog.Error("failed to find best tile", "err", err)
 			}
 			req.RespCh <- MoveResp{Moves: []RankTile{move}, Err: err}
 		case RankMovesKind:
-			moves, err := sh.findRankedMoves(req.Game, req.Depth)
+			var moves []RankTile
+			err = sh.withDeadline(req.Ctx, func() (err error) {
+				moves, err = sh.findRankedMoves(req.Game, req.Depth)
+				return err
+			})
 			if err != nil {
 				slog.Error("failed to find ranked tiles", "err", err)
 			}
 			req.RespCh <- MoveResp{Moves: moves, Err: err}
 		default:
-			log.Fatalf("invalid move request Kind: %d", req.Kind)
+			slog.Error("invalid move request", "kind", req.Kind)
+			req.RespCh <- MoveResp{Err: fmt.Errorf("invalid move request kind: %d", req.Kind)}
 		}
 		slog.Info("move request complete", "duration", start.Sub(time.Now()))
+		if sh.busy != nil {
+			sh.busy.Dec()
+		}
+		if errors.Is(err, ErrEngineDied) {
+			sh.restart()
+		}
 	}
 }
 
@@ -292,25 +549,66 @@ func (sh *NTestShell) FindRankedMoves(game OthelloGame, depth uint64) chan MoveR
 	return ch
 }
 
-func (resp MoveResp) assertValidMove(game OthelloGame) RankTile {
+func (sh *NTestShell) FindRankedMovesContext(ctx context.Context, game OthelloGame, depth uint64) chan MoveResp {
+	return sendMoveReq(ctx, sh.moveReqCh, MoveReq{Kind: RankMovesKind, Game: game, Depth: depth, Ctx: ctx})
+}
+
+// sendMoveReq queues the request until a process takes it or the context is done, the response channel receives the
+// context's error if no process was free in time
+func sendMoveReq(ctx context.Context, moveReqCh chan MoveReq, req MoveReq) chan MoveResp {
+	req.RespCh = make(chan MoveResp, 1)
+	select {
+	case moveReqCh <- req:
+	case <-ctx.Done():
+		req.RespCh <- MoveResp{Err: fmt.Errorf("%w: %w", ErrEngineCancelled, ctx.Err())}
+	}
+	return req.RespCh
+}
+
+var ErrEngineNoMoves = errors.New("engine produced no moves")
+
+// validMove checks the engine's best move is legal for the side to move, an illegal move means the engine searched a
+// different position than the game so the move must not be played
+func (resp MoveResp) validMove(game OthelloGame) (RankTile, error) {
 	if len(resp.Moves) == 0 {
-		log.Fatalf("engine produced no moves for best move request for game: %s", game.MarshalGGF())
+		return RankTile{}, fmt.Errorf("%w for best move request for game: %s", ErrEngineNoMoves, game.MarshalGGF())
 	}
 	move := resp.Moves[0]
-	if !slices.Contains(game.Board.FindCurrentMoves(), move.Tile) {
-		log.Fatalf("engine produced an illegal tile: %s for game: %s", move.Tile, game.MarshalGGF())
+	err := checkMoveSide(game.Board, move.Tile)
+	if errors.Is(err, ErrWrongSideMove) {
+		return RankTile{}, fmt.Errorf("engine produced tile: %s, the side to move may be wrong in game: %s: %w", move.Tile, game.MarshalGGF(), err)
 	}
-	return move
+	if err != nil {
+		return RankTile{}, fmt.Errorf("engine produced tile: %s for game: %s: %w", move.Tile, game.MarshalGGF(), err)
+	}
+	return move, nil
 }
 
-func (resp MoveResp) assertValidMoves(game OthelloGame) {
+var ErrWrongSideMove = errors.New("move is only legal for the side not to move")
+
+func checkMoveSide(board OthelloBoard, tile Tile) error {
+	if board.IsLegalMove(tile) {
+		return nil
+	}
+	// a tile that is legal for the opponent means the engine searched the position with the wrong side to move
+	oppBoard := board
+	oppBoard.IsBlackMove = !oppBoard.IsBlackMove
+	if oppBoard.IsLegalMove(tile) {
+		return ErrWrongSideMove
+	}
+	return ErrInvalidMove
+}
+
+// validMoves checks the engine ranked every legal move of the game
+func (resp MoveResp) validMoves(game OthelloGame) error {
 	var tileMap [BoardSize][BoardSize]bool
 	for _, tile := range resp.Moves {
 		tileMap[tile.Row][tile.Col] = true
 	}
 	for _, tile := range game.Board.FindCurrentMoves() {
 		if !tileMap[tile.Row][tile.Col] {
-			log.Fatalf("engine produced illegal tiles: %s for game: %s", resp.Moves, game.MarshalGGF())
+			return fmt.Errorf("engine produced tiles: %s missing %s for game: %s", resp.Moves, tile, game.MarshalGGF())
 		}
 	}
+	return nil
 }
@@ -1,10 +1,14 @@
 package app
 
 import (
+	"bufio"
+	"context"
 	"fmt"
 	"github.com/joho/godotenv"
 	"github.com/stretchr/testify/assert"
+	"io"
 	"os"
+	"strings"
 	"testing"
 	"time"
 )
@@ -95,3 +99,219 @@ func TestNTestShell_FindRankedMoves(t *testing.T) {
 		})
 	}
 }
+
+// deadShell is a shell whose process has exited after writing stdout, it can't be restarted since it has no path
+func deadShell(stdout string) *NTestShell {
+	reader := strings.NewReader(stdout)
+	return &NTestShell{
+		stdout:     bufio.NewScanner(reader),
+		stdoutPipe: io.NopCloser(reader),
+		stdin:      bufio.NewWriter(io.Discard),
+		timeout:    EngineTimeout,
+		moveReqCh:  make(chan MoveReq),
+	}
+}
+
+func TestNTestShell_DetectsDeadProcess(t *testing.T) {
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: MakeInitialBoard()}
+
+	_, err := deadShell("").findBestMove(game, 5)
+	assert.ErrorIs(t, err, ErrEngineDied)
+
+	// stdout ends before the engine prints its move
+	_, err = deadShell("set myname ntest\n").findBestMove(game, 5)
+	assert.ErrorIs(t, err, ErrEngineDied)
+
+	_, err = deadShell("set myname ntest\n").findRankedMoves(game, 5)
+	assert.ErrorIs(t, err, ErrEngineDied)
+
+	assert.ErrorIs(t, deadShell("Ntest version as of Dec 31 2004\n").start(), ErrEmptyPath)
+	assert.ErrorIs(t, deadShell("").expect("Ntest version as of Dec 31 2004"), ErrEngineDied)
+}
+
+func TestNTestShell_RestartingRequests(t *testing.T) {
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: MakeInitialBoard()}
+
+	sh := deadShell("")
+	sh.restarting = true
+	go sh.ListenRequests()
+	defer close(sh.moveReqCh)
+
+	select {
+	case resp := <-sh.FindBestMove(game, 5):
+		assert.ErrorIs(t, resp.Err, ErrEngineRestarting)
+	case <-time.After(time.Second):
+		t.Fatal("request hung while the engine was restarting")
+	}
+
+	// a request on the dead process fails, the restart without a path fails, and the next request fails again
+	sh.mu.Lock()
+	sh.restarting = false
+	sh.mu.Unlock()
+	for i := 0; i < 2; i++ {
+		select {
+		case resp := <-sh.FindRankedMoves(game, 5):
+			assert.Error(t, resp.Err)
+		case <-time.After(time.Second):
+			t.Fatal("request hung on a dead engine")
+		}
+	}
+}
+
+// stuckShell is a shell whose process never writes to stdout, closing the pipe ends the process
+func stuckShell(timeout time.Duration) (*NTestShell, *io.PipeReader) {
+	reader, _ := io.Pipe()
+	sh := &NTestShell{
+		stdout:     bufio.NewScanner(reader),
+		stdoutPipe: reader,
+		stdin:      bufio.NewWriter(io.Discard),
+		timeout:    timeout,
+		moveReqCh:  make(chan MoveReq),
+	}
+	return sh, reader
+}
+
+func TestNTestShell_Timeout(t *testing.T) {
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: MakeInitialBoard()}
+
+	sh, _ := stuckShell(time.Millisecond * 50)
+	go sh.ListenRequests()
+	defer close(sh.moveReqCh)
+
+	select {
+	case resp := <-sh.FindRankedMoves(game, 5):
+		assert.ErrorIs(t, resp.Err, ErrEngineTimeout)
+		assert.ErrorIs(t, resp.Err, ErrEngineDied)
+	case <-time.After(time.Second):
+		t.Fatal("request hung on a stuck engine")
+	}
+}
+
+func TestNTestShell_Cancel(t *testing.T) {
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: MakeInitialBoard()}
+
+	sh, _ := stuckShell(EngineTimeout)
+	go sh.ListenRequests()
+	defer close(sh.moveReqCh)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+
+	select {
+	case resp := <-sh.FindRankedMovesContext(ctx, game, 32):
+		assert.ErrorIs(t, resp.Err, ErrEngineCancelled)
+		assert.ErrorIs(t, resp.Err, ErrEngineDied)
+	case <-time.After(time.Second):
+		t.Fatal("search continued after its context was done")
+	}
+
+	// a request that no process takes before its context is done fails without waiting
+	queued := &NTestShell{moveReqCh: make(chan MoveReq)}
+	select {
+	case resp := <-queued.FindRankedMovesContext(ctx, game, 32):
+		assert.ErrorIs(t, resp.Err, ErrEngineCancelled)
+	case <-time.After(time.Second):
+		t.Fatal("request waited for a busy engine after its context was done")
+	}
+}
+
+func TestNTestShell_RetryRankedMoves(t *testing.T) {
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: MakeInitialBoard()}
+
+	// the first search prints an unreadable ranking, the retry prints a readable one
+	sh := deadShell("set myname ntest\nsearch Z9 1.00\nstatus\nset myname ntest\nsearch D3 2.00\nstatus\n")
+	tiles, err := sh.findRankedMoves(game, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []RankTile{{Tile: ParseTile("d3"), H: 2}}, tiles)
+
+	sh = deadShell("set myname ntest\nsearch Z9 1.00\nstatus\nset myname ntest\nsearch Z9 2.00\nstatus\n")
+	_, err = sh.findRankedMoves(game, 5)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrEngineDied)
+}
+
+func TestNTestShell_RankedMovesDeduplicated(t *testing.T) {
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: MakeInitialBoard()}
+
+	// a tile ranked by both the book and the search keeps only its last ranking
+	sh := deadShell("set myname ntest\nbook D3 1.00\nsearch C4 0.50\nsearch D3 2.00\nstatus\n")
+	tiles, err := sh.findRankedMoves(game, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, []RankTile{{Tile: ParseTile("d3"), H: 2}, {Tile: ParseTile("c4"), H: 0.5}}, tiles)
+}
+
+func TestAllocateDepth(t *testing.T) {
+	midBoard, _ := RandomBoard(25)
+	endBoard, _ := RandomBoard(56)
+	t.Logf("midgame board:\n%s", midBoard.String())
+	t.Logf("endgame board:\n%s", endBoard.String())
+
+	depth := LevelToDepth(3)
+	midDepth := AllocateDepth(midBoard, depth)
+	endDepth := AllocateDepth(endBoard, depth)
+
+	assert.Greater(t, midDepth, depth)
+	assert.Less(t, endDepth, depth)
+	assert.Greater(t, midDepth, endDepth)
+	assert.Equal(t, uint64(endBoard.CountEmpty()), endDepth)
+}
+
+func TestCheckMoveSide(t *testing.T) {
+	type Test struct {
+		tile   string
+		expErr error
+	}
+	tests := []Test{
+		{tile: "d3", expErr: nil},
+		{tile: "e3", expErr: ErrWrongSideMove},
+		{tile: "a1", expErr: ErrInvalidMove},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			err := checkMoveSide(MakeInitialBoard(), ParseTile(test.tile))
+			assert.Equal(t, test.expErr, err)
+		})
+	}
+}
+
+func TestMoveResp_ValidMove(t *testing.T) {
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: MakeInitialBoard()}
+
+	type Test struct {
+		moves  []RankTile
+		expErr error
+	}
+	tests := []Test{
+		{moves: []RankTile{{Tile: ParseTile("d3"), H: 2}}},
+		{moves: nil, expErr: ErrEngineNoMoves},
+		{moves: []RankTile{{Tile: ParseTile("e3")}}, expErr: ErrWrongSideMove},
+		{moves: []RankTile{{Tile: ParseTile("a1")}}, expErr: ErrInvalidMove},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			move, err := MoveResp{Moves: test.moves}.validMove(game)
+			if test.expErr != nil {
+				assert.ErrorIs(t, err, test.expErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.moves[0], move)
+		})
+	}
+}
+
+func TestVerifyGGF(t *testing.T) {
+	board, moveList := RandomBoard(20)
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: board, MoveList: moveList}
+
+	assert.NoError(t, verifyGGF(game, game.MarshalGGF()))
+
+	// a board that was changed without recording the move can't be reproduced from the serialized move list
+	mismatched := game
+	mismatched.Board.MakeMove(mismatched.Board.FindCurrentMoves()[0])
+	assert.ErrorIs(t, verifyGGF(mismatched, mismatched.MarshalGGF()), ErrGGFMismatch)
+
+	assert.ErrorIs(t, verifyGGF(game, "(;GM[Othello]B[A1];)"), ErrGGFMismatch)
+}
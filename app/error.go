@@ -13,7 +13,7 @@ func (e OptionError) Error() string {
 	if e.ExpectedValue != "" {
 		expMsg = fmt.Sprintf(", expected value to be: %s", e.ExpectedValue)
 	}
-	if e.InvalidValue == "" {
+	if e.InvalidValue == nil || e.InvalidValue == "" {
 		return fmt.Sprintf("Expected an option '%s' to be provided%s", e.Name, expMsg)
 	} else {
 		return fmt.Sprintf("Option '%s' received invalid value '%v'%s", e.Name, e.InvalidValue, expMsg)
@@ -0,0 +1,108 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"golang.org/x/sync/errgroup"
+)
+
+type FastestWinRow struct {
+	PlayerID     string `db:"player_id"`
+	GameID       string `db:"game_id"`
+	Duration     int64  `db:"duration"`
+	FinishedTime int64  `db:"finished_time"`
+}
+
+type FastestWin struct {
+	Player   Player
+	GameID   string
+	Duration time.Duration
+}
+
+func MapFastestWin(row FastestWinRow) FastestWin {
+	return FastestWin{
+		Player:   MakePlayer(row.PlayerID, ""),
+		GameID:   row.GameID,
+		Duration: time.Duration(row.Duration) * time.Millisecond,
+	}
+}
+
+// UpdateFastestWin stores the win as the player's fastest, unless the player already has a faster one
+func UpdateFastestWin(ctx context.Context, q CtxQuerier, row FastestWinRow) error {
+	_, err := q.ExecContext(ctx,
+		`INSERT INTO fastest_wins (player_id, game_id, duration, finished_time) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (player_id) DO UPDATE SET game_id = excluded.game_id, duration = excluded.duration, finished_time = excluded.finished_time
+		WHERE excluded.duration < fastest_wins.duration;`,
+		row.PlayerID, row.GameID, row.Duration, row.FinishedTime,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to upsert fastest win: %w", err)
+	}
+	return nil
+}
+
+// recordFastestWin updates the winner's fastest win if the game was played to the end by two different players,
+// forfeits, timeouts and games created before timestamps were stored are not counted
+func recordFastestWin(ctx context.Context, q CtxQuerier, game OthelloGame, gr GameResult, finishedTime time.Time) error {
+	if gr.IsDraw || gr.Kind != Normal || !gr.Winner.IsHuman() || gr.Winner.ID == gr.Loser.ID || game.CreatedTime.IsZero() {
+		return nil
+	}
+	row := FastestWinRow{
+		PlayerID:     gr.Winner.ID,
+		GameID:       game.ID,
+		Duration:     finishedTime.Sub(game.CreatedTime).Milliseconds(),
+		FinishedTime: finishedTime.UnixMilli(),
+	}
+	if err := UpdateFastestWin(ctx, q, row); err != nil {
+		return err
+	}
+
+	slog.Info("recorded win duration", "trace", ctx.Value(TraceKey), "row", row)
+	return nil
+}
+
+func GetFastestWins(ctx context.Context, db *sqlx.DB, count int) ([]FastestWinRow, error) {
+	trace := ctx.Value(TraceKey)
+
+	var rows []FastestWinRow
+	err := db.SelectContext(ctx, &rows, "SELECT player_id, game_id, duration, finished_time FROM fastest_wins ORDER BY duration ASC LIMIT $1;", count)
+	if err != nil {
+		slog.Error("failed to get fastest wins", "trace", trace, "err", err)
+		return nil, err
+	}
+
+	slog.Info("selected fastest wins", "trace", trace, "rows", rows)
+	return rows, nil
+}
+
+func ReadFastestWins(ctx context.Context, db *sqlx.DB, uc UserCacheApi, count int) ([]FastestWin, error) {
+	rows, err := GetFastestWins(ctx, db, count)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get fastest wins: %w", err)
+	}
+
+	eg, ctx := errgroup.WithContext(ctx)
+	wins := make([]FastestWin, len(rows))
+
+	for i, row := range rows {
+		win := &wins[i]
+		*win = MapFastestWin(row)
+
+		eg.Go(func() error {
+			username, err := uc.GetUsername(ctx, win.Player.ID)
+			if err != nil {
+				return fmt.Errorf("failed in get user task: %d: %w", i, err)
+			}
+			win.Player.Name = username
+			return nil
+		})
+	}
+	if err := eg.Wait(); err != nil {
+		return nil, err
+	}
+	return wins, nil
+}
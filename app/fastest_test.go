@@ -0,0 +1,54 @@
+package app
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordFastestWin(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-record-fastest-win")
+
+	// a finished board with no moves for either side, black wins with every disc
+	board := OthelloBoard{IsBlackMove: true}
+	for row := 0; row < BoardSize; row++ {
+		for col := 0; col < BoardSize; col++ {
+			board.SetSquare(row, col, Black)
+		}
+	}
+	winner := Player{ID: "id1", Name: "Player1"}
+	loser := Player{ID: "id2", Name: "Player2"}
+	finishedTime := time.UnixMilli(time.Now().UnixMilli())
+
+	type Test struct {
+		gameID   string
+		duration time.Duration
+		expRows  []FastestWinRow
+	}
+	tests := []Test{
+		{gameID: "1", duration: 10 * time.Minute, expRows: []FastestWinRow{{PlayerID: "id1", GameID: "1", Duration: 600000, FinishedTime: finishedTime.UnixMilli()}}},
+		// a faster win replaces the stored record
+		{gameID: "2", duration: 5 * time.Minute, expRows: []FastestWinRow{{PlayerID: "id1", GameID: "2", Duration: 300000, FinishedTime: finishedTime.UnixMilli()}}},
+		// a slower win leaves the stored record alone
+		{gameID: "3", duration: 20 * time.Minute, expRows: []FastestWinRow{{PlayerID: "id1", GameID: "2", Duration: 300000, FinishedTime: finishedTime.UnixMilli()}}},
+	}
+
+	for _, test := range tests {
+		game := OthelloGame{ID: test.gameID, Board: board, BlackPlayer: winner, WhitePlayer: loser, CreatedTime: finishedTime.Add(-test.duration)}
+		gr := GameResult{Winner: winner, Loser: loser}
+		if err := recordFastestWin(ctx, db, game, gr, finishedTime); err != nil {
+			t.Fatalf("failed to record fastest win: %v", err)
+		}
+
+		rows, err := GetFastestWins(ctx, db, LeaderboardSize)
+		if err != nil {
+			t.Fatalf("failed to get fastest wins: %v", err)
+		}
+		assert.Equal(t, test.expRows, rows)
+	}
+}
@@ -5,8 +5,10 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"github.com/bwmarrin/discordgo"
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"log"
 	"log/slog"
 	"slices"
 	"time"
@@ -18,6 +20,10 @@ type OthelloGame struct {
 	WhitePlayer Player
 	BlackPlayer Player
 	MoveList    []Move
+	CreatedTime time.Time
+	GuildID     string // the guild whose ladder the game is rated on, empty for the global ladder
+	ChannelID   string // the channel the game was started in, expiry warnings are sent there
+	ServerID    string // the guild the game was started in, even when it's rated on the global ladder
 }
 
 type Move struct {
@@ -40,20 +46,63 @@ const (
 	Pass
 )
 
+// MakeMove plays the move and records it, the move list is clipped first so a copy of the game never appends into the
+// move list it shares with the original. If neither player has a move afterward both would pass, so the game ends on
+// the move without recording a pass and the opponent stays to move, which makes IsOver true
 func (o *OthelloGame) MakeMove(move Tile) MoveKind {
 	o.Board.MakeMove(move)
-	o.MoveList = append(o.MoveList, Move{Tile: move, Pass: false})
+	o.MoveList = append(slices.Clip(o.MoveList), Move{Tile: move, Pass: false})
 
-	if len(o.Board.FindCurrentMoves()) == 0 {
-		o.Board.IsBlackMove = !o.Board.IsBlackMove
+	if !o.Board.HasCurrentMoves() {
+		passed := o.Board
+		passed.IsBlackMove = !passed.IsBlackMove
+		if !passed.HasCurrentMoves() {
+			return Regular
+		}
+		o.Board = passed
 		o.MoveList = append(o.MoveList, Move{Pass: true})
 		return Pass
 	}
 	return Regular
 }
 
+var ErrNoMovesToUndo = errors.New("game has no moves to undo")
+
+// LastMoveKind is Pass if the opponent had no moves after the last move, MakeMove records the pass in the move list
+func (o *OthelloGame) LastMoveKind() MoveKind {
+	if len(o.MoveList) > 0 && o.MoveList[len(o.MoveList)-1].Pass {
+		return Pass
+	}
+	return Regular
+}
+
+// UndoMove removes the last move along with any pass recorded after it, then rebuilds the board by replaying the moves
+func (o *OthelloGame) UndoMove() error {
+	moveList := slices.Clone(o.MoveList)
+	for len(moveList) > 0 && moveList[len(moveList)-1].Pass {
+		moveList = moveList[:len(moveList)-1]
+	}
+	if len(moveList) == 0 {
+		return ErrNoMovesToUndo
+	}
+	moveList = moveList[:len(moveList)-1]
+
+	board := InitialBoard
+	for _, move := range moveList {
+		if move.Pass {
+			board.IsBlackMove = !board.IsBlackMove
+		} else {
+			board.MakeMove(move.Tile)
+		}
+	}
+
+	o.Board = board
+	o.MoveList = moveList
+	return nil
+}
+
 func (o *OthelloGame) HasMoves() bool {
-	return len(o.Board.FindCurrentMoves()) > 0
+	return o.Board.HasCurrentMoves()
 }
 
 func (o *OthelloGame) IsOver() bool {
@@ -68,6 +117,11 @@ func (o *OthelloGame) CurrentPlayer() Player {
 	}
 }
 
+// IsTurn checks if the player is the one to move, a player in a game against themselves can always move
+func (o *OthelloGame) IsTurn(playerID string) bool {
+	return o.CurrentPlayer().ID == playerID
+}
+
 func (o *OthelloGame) OtherPlayer() Player {
 	if o.Board.IsBlackMove {
 		return o.WhitePlayer
@@ -87,20 +141,41 @@ func (o *OthelloGame) CreateResult() GameResult {
 	}
 }
 
+// RematchPlayers returns the black and white players of a rematch, the players swap colors except against a bot where
+// the player keeps the color they picked
+func (o *OthelloGame) RematchPlayers() (Player, Player) {
+	if o.WhitePlayer.IsBot() || o.BlackPlayer.IsBot() {
+		return o.BlackPlayer, o.WhitePlayer
+	}
+	return o.WhitePlayer, o.BlackPlayer
+}
+
+// CreateForfeitResult creates the result of a player forfeiting, a forfeit by neither player (such as an admin ending
+// the game) is aborted as a draw between the players
 func (o *OthelloGame) CreateForfeitResult(forfeitId string) GameResult {
 	if o.WhitePlayer.ID == forfeitId {
-		return GameResult{Winner: o.BlackPlayer, Loser: o.WhitePlayer, IsDraw: false}
+		return GameResult{Winner: o.BlackPlayer, Loser: o.WhitePlayer, IsDraw: false, Kind: Forfeit}
 	} else if o.BlackPlayer.ID == forfeitId {
-		return GameResult{Winner: o.WhitePlayer, Loser: o.BlackPlayer, IsDraw: false}
+		return GameResult{Winner: o.WhitePlayer, Loser: o.BlackPlayer, IsDraw: false, Kind: Forfeit}
 	} else {
-		return GameResult{IsDraw: true}
+		return GameResult{Winner: o.BlackPlayer, Loser: o.WhitePlayer, IsDraw: true, Kind: Aborted}
 	}
 }
 
+type ResultKind int
+
+const (
+	Normal ResultKind = iota
+	Forfeit
+	Timeout
+	Aborted // ended by neither player, always a draw so no ratings change
+)
+
 type GameResult struct {
 	Winner Player
 	Loser  Player
 	IsDraw bool
+	Kind   ResultKind
 }
 
 type GameRow struct {
@@ -111,10 +186,14 @@ type GameRow struct {
 	BlackID     string `db:"black_id"`
 	WhiteName   string `db:"white_name"`
 	BlackName   string `db:"black_name"`
+	CreatedTime int64  `db:"created_time"`
+	GuildID     string `db:"guild_id"`
+	ChannelID   string `db:"channel_id"`
+	ServerID    string `db:"server_id"`
 }
 
 func mapGameRow(row GameRow) (OthelloGame, error) {
-	game := OthelloGame{ID: row.ID, WhitePlayer: MakePlayer(row.WhiteID, row.WhiteName), BlackPlayer: MakePlayer(row.BlackID, row.BlackName)}
+	game := OthelloGame{ID: row.ID, WhitePlayer: MakePlayer(row.WhiteID, row.WhiteName), BlackPlayer: MakePlayer(row.BlackID, row.BlackName), GuildID: row.GuildID, ChannelID: row.ChannelID, ServerID: row.ServerID}
 
 	board, err := UnmarshalBoard(row.BoardStr)
 	if err != nil {
@@ -127,14 +206,22 @@ func mapGameRow(row GameRow) (OthelloGame, error) {
 
 	game.Board = board
 	game.MoveList = moveList
+	if row.CreatedTime > 0 {
+		game.CreatedTime = time.UnixMilli(row.CreatedTime)
+	}
 	return game, nil
 }
 
+// GameStoreTtl is the default time a game can go without a move before it expires
 const GameStoreTtl = time.Hour * 24
 
+// ExpiryWarning is how long before a game expires the player to move is warned, games with short ttls are warned at
+// half their ttl instead
+const ExpiryWarning = time.Hour
+
 var ErrGameNotFound = errors.New("game not found")
 
-func GetGame(ctx context.Context, db *sqlx.DB, playerID string) (OthelloGame, error) {
+func GetGame(ctx context.Context, q CtxQuerier, playerID string) (OthelloGame, error) {
 	trace := ctx.Value(TraceKey)
 
 	fail := func(err error) (OthelloGame, error) {
@@ -143,7 +230,7 @@ func GetGame(ctx context.Context, db *sqlx.DB, playerID string) (OthelloGame, er
 	}
 
 	var row GameRow
-	err := db.GetContext(ctx, &row, "SELECT id, board, moves, white_id, black_id, white_name, black_name FROM games WHERE white_id = $1 OR black_id = $1;", playerID)
+	err := q.GetContext(ctx, &row, "SELECT id, board, moves, white_id, black_id, white_name, black_name, created_time, guild_id, channel_id, server_id FROM games WHERE white_id = $1 OR black_id = $1;", playerID)
 	if errors.Is(err, sql.ErrNoRows) {
 		return OthelloGame{}, ErrGameNotFound
 	}
@@ -159,9 +246,9 @@ func GetGame(ctx context.Context, db *sqlx.DB, playerID string) (OthelloGame, er
 	return game, nil
 }
 
-func CheckGameParticipation(ctx context.Context, tx *sqlx.Tx, player1Id string, player2Id *string) error {
+func CheckGameParticipation(ctx context.Context, q CtxQuerier, player1Id string, player2Id *string) error {
 	var count int
-	if err := tx.GetContext(ctx, &count, "SELECT COUNT(*) FROM games WHERE white_id = $1 OR black_id = $1 OR white_id = $2 OR black_id = $2;", player1Id, player2Id); err != nil {
+	if err := q.GetContext(ctx, &count, "SELECT COUNT(*) FROM games WHERE white_id = $1 OR black_id = $1 OR white_id = $2 OR black_id = $2;", player1Id, player2Id); err != nil {
 		return fmt.Errorf("failed to get games count: %w", err)
 	}
 	if count > 0 {
@@ -171,15 +258,19 @@ func CheckGameParticipation(ctx context.Context, tx *sqlx.Tx, player1Id string,
 }
 
 func SetGame(ctx context.Context, ext sqlx.ExtContext, game OthelloGame) error {
-	return SetGameTimeWithTime(ctx, ext, game, gameExpireTime())
+	return SetGameTimeWithTime(ctx, ext, game, gameExpireTime(ctx))
 }
 
 func SetGameTimeWithTime(ctx context.Context, ext sqlx.ExtContext, game OthelloGame, expireTime time.Time) error {
 	boardStr := game.Board.MarshalString()
 	moveListStr := MarshalMoveList(game.MoveList)
+	var createdTime int64
+	if !game.CreatedTime.IsZero() {
+		createdTime = game.CreatedTime.UnixMilli()
+	}
 
 	_, err := ext.ExecContext(ctx,
-		"INSERT OR REPLACE INTO games (id, board, white_id, black_id, white_name, black_name, moves, expire_time) VALUES ($1, $2, $3, $4, $5, $6, $7, $8);",
+		"INSERT OR REPLACE INTO games (id, board, white_id, black_id, white_name, black_name, moves, expire_time, created_time, guild_id, channel_id, server_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12);",
 		game.ID,
 		boardStr,
 		game.WhitePlayer.ID,
@@ -188,6 +279,10 @@ func SetGameTimeWithTime(ctx context.Context, ext sqlx.ExtContext, game OthelloG
 		game.BlackPlayer.Name,
 		moveListStr,
 		expireTime,
+		createdTime,
+		game.GuildID,
+		game.ChannelID,
+		game.ServerID,
 	)
 	if err != nil {
 		return fmt.Errorf("failed to insert or replace games: %w", err)
@@ -197,7 +292,7 @@ func SetGameTimeWithTime(ctx context.Context, ext sqlx.ExtContext, game OthelloG
 }
 
 func UpdateGame(ctx context.Context, db *sqlx.DB, game OthelloGame) (StatsResult, error) {
-	if len(game.Board.FindCurrentMoves()) == 0 {
+	if !game.Board.HasCurrentMoves() {
 		return GameOverTx(ctx, db, game, game.CreateResult())
 	} else {
 		return StatsResult{}, SetGame(ctx, db, game)
@@ -221,10 +316,20 @@ func GameOverTx(ctx context.Context, db *sqlx.DB, game OthelloGame, gr GameResul
 	if _, err := tx.ExecContext(ctx, "DELETE FROM games WHERE white_id = $1 AND black_id = $2;", game.WhitePlayer.ID, game.BlackPlayer.ID); err != nil {
 		return fail(fmt.Errorf("failed to delete game: %w", err))
 	}
-	sr, err := UpdateStats(ctx, tx, gr)
+	sr, err := UpdateStats(ctx, tx, game.GuildID, gr)
 	if err != nil {
 		return fail(fmt.Errorf("failed to update stats for result=%v: %s", gr, err))
 	}
+	finishedTime := time.Now()
+	if err := recordFastestWin(ctx, tx, game, gr, finishedTime); err != nil {
+		return fail(fmt.Errorf("failed to record fastest win for result=%v: %s", gr, err))
+	}
+	if err := recordGameResult(ctx, tx, game, gr, finishedTime); err != nil {
+		return fail(fmt.Errorf("failed to record game result for result=%v: %s", gr, err))
+	}
+	if sr.Match, err = advanceMatch(ctx, tx, game, gr); err != nil {
+		return fail(fmt.Errorf("failed to advance match for result=%v: %s", gr, err))
+	}
 
 	if err := tx.Commit(); err != nil {
 		return fail(fmt.Errorf("failed to commit game over tx: %w", err))
@@ -233,6 +338,10 @@ func GameOverTx(ctx context.Context, db *sqlx.DB, game OthelloGame, gr GameResul
 	return sr, nil
 }
 
+func DrawGameTx(ctx context.Context, db *sqlx.DB, game OthelloGame) (StatsResult, error) {
+	return GameOverTx(ctx, db, game, GameResult{Winner: game.BlackPlayer, Loser: game.WhitePlayer, IsDraw: true})
+}
+
 func CountGames(db *sqlx.DB) (int, error) {
 	var count int
 	if err := db.Get(&count, "SELECT COUNT(*) FROM games;"); err != nil {
@@ -243,32 +352,79 @@ func CountGames(db *sqlx.DB) (int, error) {
 
 var ErrAlreadyPlaying = errors.New("one or more players are already in a game")
 
-func gameExpireTime() time.Time {
-	return time.Now().Add(GameStoreTtl)
+// DefaultChannelGameLimit is the default number of games that can be in progress in a channel at once
+const DefaultChannelGameLimit = 10
+
+var ErrChannelFull = errors.New("channel has too many games in progress")
+
+// CheckChannelGames checks the channel has room for another game, games outside a channel or without a limit in the
+// context are never limited
+func CheckChannelGames(ctx context.Context, q CtxQuerier, channelID string) error {
+	limit, ok := ctx.Value(ChannelGameLimitKey).(int)
+	if !ok || channelID == "" {
+		return nil
+	}
+	var count int
+	if err := q.GetContext(ctx, &count, "SELECT COUNT(*) FROM games WHERE channel_id = $1;", channelID); err != nil {
+		return fmt.Errorf("failed to count channel games: %w", err)
+	}
+	if count >= limit {
+		return ErrChannelFull
+	}
+	return nil
+}
+
+// gameExpireTime is the time a game expires after a move, replacing a game resets its warning as the time is extended
+func gameExpireTime(ctx context.Context) time.Time {
+	ttl, ok := ctx.Value(GameTtlKey).(time.Duration)
+	if !ok {
+		ttl = GameStoreTtl
+	}
+	return time.Now().Add(ttl)
+}
+
+// gameChannelID is the channel the interaction creating a game was sent from
+func gameChannelID(ctx context.Context) string {
+	channelID, _ := ctx.Value(ChannelKey).(string)
+	return channelID
+}
+
+// gameServerID is the guild the interaction creating a game was sent from, empty outside a guild
+func gameServerID(ctx context.Context) string {
+	cfg, _ := ctx.Value(GuildConfigKey).(GuildConfig)
+	return cfg.GuildID
+}
+
+// gameCreatedTime is truncated to the millisecond precision the games table stores
+func gameCreatedTime() time.Time {
+	return time.UnixMilli(time.Now().UnixMilli())
+}
+
+// makeGame creates a game from the starting position in the guild and channel of the interaction
+func makeGame(ctx context.Context, blackPlayer Player, whitePlayer Player) OthelloGame {
+	return OthelloGame{ID: uuid.NewString(), WhitePlayer: whitePlayer, BlackPlayer: blackPlayer, Board: MakeInitialBoard(), CreatedTime: gameCreatedTime(), GuildID: statsGuildID(ctx), ChannelID: gameChannelID(ctx), ServerID: gameServerID(ctx)}
 }
 
 func CreateGameTx(ctx context.Context, db *sqlx.DB, blackPlayer Player, whitePlayer Player) (OthelloGame, error) {
+	return InsertGameTx(ctx, db, makeGame(ctx, blackPlayer, whitePlayer))
+}
+
+// InsertGameTx stores a new game as long as neither player is in a game and the channel has room for it
+func InsertGameTx(ctx context.Context, db *sqlx.DB, game OthelloGame) (OthelloGame, error) {
 	trace := ctx.Value(TraceKey)
 
 	fail := func(err error) (OthelloGame, error) {
-		slog.Error("failed to create game", "trace", trace, "whitePlayer", whitePlayer, "blackPlayer", blackPlayer, "err", err)
+		slog.Error("failed to create game", "trace", trace, "whitePlayer", game.WhitePlayer, "blackPlayer", game.BlackPlayer, "err", err)
 		return OthelloGame{}, err
 	}
 
-	game := OthelloGame{ID: uuid.NewString(), WhitePlayer: whitePlayer, BlackPlayer: blackPlayer, Board: MakeInitialBoard()}
-	var player2Id *string
-	if whitePlayer.IsHuman() {
-		player2Id = &whitePlayer.ID
-	}
-
 	tx, err := db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
 	if err != nil {
 		return fail(err)
 	}
 	defer tx.Rollback()
 
-	err = CheckGameParticipation(ctx, tx, blackPlayer.ID, player2Id)
-	if err != nil {
+	if err := checkCreateGame(ctx, tx, game); err != nil {
 		return OthelloGame{}, err
 	}
 	if err := SetGame(ctx, tx, game); err != nil {
@@ -283,8 +439,105 @@ func CreateGameTx(ctx context.Context, db *sqlx.DB, blackPlayer Player, whitePla
 	return game, nil
 }
 
-func CreateBotGameTx(ctx context.Context, db *sqlx.DB, blackPlayer Player, level uint64) (OthelloGame, error) {
-	return CreateGameTx(ctx, db, blackPlayer, MakeBotPlayer(level))
+// gameParticipants returns the IDs of the players checked for existing games, a bot is never a participant as its ID is
+// the level shared by every game against it, so a stored game needs at least one human
+func gameParticipants(blackPlayer Player, whitePlayer Player) (string, *string) {
+	if blackPlayer.IsBot() {
+		blackPlayer, whitePlayer = whitePlayer, blackPlayer
+	}
+	if blackPlayer.IsBot() {
+		log.Panicf("bot players: %v and %v cannot participate in a stored game", blackPlayer, whitePlayer)
+	}
+	if whitePlayer.IsHuman() {
+		return blackPlayer.ID, &whitePlayer.ID
+	}
+	return blackPlayer.ID, nil
+}
+
+func checkCreateGame(ctx context.Context, q CtxQuerier, game OthelloGame) error {
+	player1Id, player2Id := gameParticipants(game.BlackPlayer, game.WhitePlayer)
+	if err := CheckGameParticipation(ctx, q, player1Id, player2Id); err != nil {
+		return err
+	}
+	return CheckChannelGames(ctx, q, game.ChannelID)
+}
+
+// PrepareBotOpening creates a game where the bot plays black without storing it, the game is stored with InsertGameTx
+// once the bot has made its opening move so a failed engine request can't leave a stored game waiting on the bot
+func PrepareBotOpening(ctx context.Context, db *sqlx.DB, blackPlayer Player, whitePlayer Player) (OthelloGame, error) {
+	game := makeGame(ctx, blackPlayer, whitePlayer)
+	if err := checkCreateGame(ctx, db, game); err != nil {
+		return OthelloGame{}, err
+	}
+	return game, nil
+}
+
+// CreateBotGameTx creates a game between the player and the bot, the bot plays the other color. A game where the bot
+// plays black isn't stored until the bot makes its opening move, see PrepareBotOpening
+func CreateBotGameTx(ctx context.Context, db *sqlx.DB, player Player, level uint64, isBlack bool) (OthelloGame, error) {
+	if isBlack {
+		return CreateGameTx(ctx, db, player, MakeBotPlayer(level))
+	}
+	return PrepareBotOpening(ctx, db, MakeBotPlayer(level), player)
+}
+
+var ErrUnsupportedPosition = errors.New("game must be played from the standard starting position")
+var ErrImportFinished = errors.New("game is already finished")
+
+// CreateGameFromPosition creates a game for a player against themselves from an imported game, the game must be
+// reproducible from the standard start as the move list is replayed from the initial board
+func CreateGameFromPosition(ctx context.Context, db *sqlx.DB, player Player, imported OthelloGame) (OthelloGame, error) {
+	trace := ctx.Value(TraceKey)
+
+	fail := func(err error) (OthelloGame, error) {
+		slog.Error("failed to create game from position", "trace", trace, "player", player, "err", err)
+		return OthelloGame{}, err
+	}
+
+	replayed := OthelloGame{Board: InitialBoard}
+	for _, move := range imported.MoveList {
+		if move.Pass {
+			replayed.Board.IsBlackMove = !replayed.Board.IsBlackMove
+		} else {
+			replayed.Board.MakeMove(move.Tile)
+		}
+	}
+	if replayed.Board != imported.Board {
+		return OthelloGame{}, ErrUnsupportedPosition
+	}
+
+	game := OthelloGame{ID: uuid.NewString(), WhitePlayer: player, BlackPlayer: player, Board: imported.Board, MoveList: imported.MoveList, CreatedTime: gameCreatedTime(), ChannelID: gameChannelID(ctx), ServerID: gameServerID(ctx)}
+	if !game.HasMoves() {
+		// the side to move had no moves, but the pass wasn't recorded
+		game.Board.IsBlackMove = !game.Board.IsBlackMove
+		game.MoveList = append(game.MoveList, Move{Pass: true})
+	}
+	if !game.HasMoves() {
+		return OthelloGame{}, ErrImportFinished
+	}
+
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fail(err)
+	}
+	defer tx.Rollback()
+
+	if err := CheckGameParticipation(ctx, tx, player.ID, nil); err != nil {
+		return OthelloGame{}, err
+	}
+	if err := CheckChannelGames(ctx, tx, game.ChannelID); err != nil {
+		return OthelloGame{}, err
+	}
+	if err := SetGame(ctx, tx, game); err != nil {
+		return fail(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+
+	slog.Info("created game from position", "trace", trace, "game", game.MarshalGGF())
+	return game, nil
 }
 
 var ErrTurn = errors.New("not players turn")
@@ -304,10 +557,10 @@ func MakeMoveAgainstHuman(ctx context.Context, db *sqlx.DB, playerID string, mov
 		return fail(fmt.Errorf("failed to get game: %w", err))
 	}
 
-	if game.CurrentPlayer().ID != playerID {
+	if !game.IsTurn(playerID) {
 		return OthelloGame{}, StatsResult{}, ErrTurn
 	}
-	if !slices.Contains(game.Board.FindCurrentMoves(), move) {
+	if !game.Board.IsLegalMove(move) {
 		return OthelloGame{}, StatsResult{}, ErrInvalidMove
 	}
 
@@ -327,7 +580,106 @@ func MakeMoveAgainstHuman(ctx context.Context, db *sqlx.DB, playerID string, mov
 	return game, sr, nil
 }
 
-func ExpireGamesCron(db *sqlx.DB) {
+var ErrGameStarted = errors.New("game already has moves")
+
+// AbortGameTx deletes the player's game without rating it, a game can only be aborted before any moves are made and
+// only the first game of a match can be aborted, which cancels the series
+func AbortGameTx(ctx context.Context, db *sqlx.DB, playerID string) (OthelloGame, error) {
+	trace := ctx.Value(TraceKey)
+
+	fail := func(err error) (OthelloGame, error) {
+		slog.Error("failed to abort game", "trace", trace, "playerID", playerID, "err", err)
+		return OthelloGame{}, err
+	}
+
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fail(err)
+	}
+	defer tx.Rollback()
+
+	game, err := GetGame(ctx, tx, playerID)
+	if err != nil {
+		return OthelloGame{}, err
+	}
+	if len(game.MoveList) > 0 {
+		return OthelloGame{}, ErrGameStarted
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM games WHERE id = $1;", game.ID); err != nil {
+		return fail(fmt.Errorf("failed to delete game: %w", err))
+	}
+	if err := cancelMatch(ctx, tx, game.ID); errors.Is(err, ErrMatchStarted) {
+		return OthelloGame{}, err
+	} else if err != nil {
+		return fail(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+
+	slog.Info("aborted game", "trace", trace, "game", game.MarshalGGF(), "playerID", playerID)
+	return game, nil
+}
+
+var ErrTakebackTurn = errors.New("only the player who made the last move can take it back")
+var ErrStaleTakeback = errors.New("game has changed since the takeback was requested")
+
+// CheckTakeback returns the game as it would be after taking back the requesting player's last move
+func CheckTakeback(game OthelloGame, playerID string) (OthelloGame, error) {
+	if game.WhitePlayer.IsBot() || game.BlackPlayer.IsBot() {
+		return OthelloGame{}, ErrIsAgainstBot
+	}
+	if err := game.UndoMove(); err != nil {
+		return OthelloGame{}, err
+	}
+	if game.CurrentPlayer().ID != playerID {
+		return OthelloGame{}, ErrTakebackTurn
+	}
+	return game, nil
+}
+
+// TakebackTx takes back the last move of a game, the move count guards against a move made since the takeback request
+func TakebackTx(ctx context.Context, db *sqlx.DB, gameID string, playerID string, moveCount int) (OthelloGame, error) {
+	trace := ctx.Value(TraceKey)
+
+	fail := func(err error) (OthelloGame, error) {
+		slog.Error("failed to take back move", "trace", trace, "gameID", gameID, "playerID", playerID, "err", err)
+		return OthelloGame{}, err
+	}
+
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fail(err)
+	}
+	defer tx.Rollback()
+
+	game, err := GetGame(ctx, tx, playerID)
+	if err != nil {
+		return OthelloGame{}, err
+	}
+	if game.ID != gameID || len(game.MoveList) != moveCount {
+		return OthelloGame{}, ErrStaleTakeback
+	}
+	if game, err = CheckTakeback(game, playerID); err != nil {
+		return OthelloGame{}, err
+	}
+	if err := SetGame(ctx, tx, game); err != nil {
+		return fail(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+
+	slog.Info("took back move", "trace", trace, "game", game.MarshalGGF(), "playerID", playerID)
+	return game, nil
+}
+
+// ExpireListener is called with the result of each game that expired without a move
+type ExpireListener func(ctx context.Context, game OthelloGame, result GameResult, sr StatsResult)
+
+func ExpireGamesCron(db *sqlx.DB, ms MessageSender, ttl time.Duration, onExpire ExpireListener) {
 	trace := "expire-games-task"
 	ctx := context.WithValue(context.Background(), TraceKey, trace)
 
@@ -335,16 +687,56 @@ func ExpireGamesCron(db *sqlx.DB) {
 	defer ticker.Stop()
 
 	for range ticker.C {
-		if err := ExpireGames(ctx, db); err != nil {
+		if err := WarnExpiringGames(ctx, db, ms, min(ExpiryWarning, ttl/2)); err != nil {
+			slog.Error("failed to warn expiring games", "trace", trace, "err", err)
+		}
+		if err := ExpireGames(ctx, db, onExpire); err != nil {
 			slog.Error("failed to expire games", "trace", trace, "err", err)
 		}
 	}
 }
 
-func ExpireGames(ctx context.Context, db *sqlx.DB) error {
+type MessageSender interface {
+	ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// WarnExpiringGames warns the player to move in each game expiring within the window, each game is only warned once
+// until a move extends its expiry
+func WarnExpiringGames(ctx context.Context, db *sqlx.DB, ms MessageSender, window time.Duration) error {
+	trace := ctx.Value(TraceKey)
 	t := time.Now()
 
-	rows, err := db.QueryxContext(ctx, "SELECT id, board, moves, white_id, black_id, white_name, black_name FROM games WHERE expire_time < $1;", t)
+	var rows []GameRow
+	err := db.SelectContext(ctx, &rows, "SELECT id, board, moves, white_id, black_id, white_name, black_name, created_time, guild_id, channel_id, server_id FROM games WHERE expire_time < $1 AND expire_time >= $2 AND NOT warned AND channel_id != '';", t.Add(window), t)
+	if err != nil {
+		return fmt.Errorf("failed to select expiring games: %w", err)
+	}
+
+	for _, row := range rows {
+		game, err := mapGameRow(row)
+		if err != nil {
+			return fmt.Errorf("failed to map game row: %w", err)
+		}
+		// the game is marked before sending so a failed send isn't retried every tick
+		if _, err := db.ExecContext(ctx, "UPDATE games SET warned = TRUE WHERE id = $1;", game.ID); err != nil {
+			return fmt.Errorf("failed to mark game as warned: %w", err)
+		}
+
+		msg := fmt.Sprintf("<@%s> Your game against %s expires in under %s, make a move or you'll lose on time!", game.CurrentPlayer().ID, game.OtherPlayer().Name, window)
+		if _, err := ms.ChannelMessageSend(game.ChannelID, msg); err != nil {
+			slog.Error("failed to send expiry warning", "trace", trace, "gameID", game.ID, "err", err)
+		}
+		slog.Info("warned expiring game", "trace", trace, "game", game.MarshalGGF())
+	}
+	return nil
+}
+
+// ExpireGames ends each game that went without a move past its expiry as a loss on time for the player to move,
+// onExpire may be nil
+func ExpireGames(ctx context.Context, db *sqlx.DB, onExpire ExpireListener) error {
+	t := time.Now()
+
+	rows, err := db.QueryxContext(ctx, "SELECT id, board, moves, white_id, black_id, white_name, black_name, created_time, guild_id, channel_id, server_id FROM games WHERE expire_time < $1;", t)
 	if err != nil {
 		return fmt.Errorf("failed to select expired games: %w", err)
 	}
@@ -364,10 +756,14 @@ func ExpireGames(ctx context.Context, db *sqlx.DB) error {
 	}
 
 	for _, game := range games {
-		sr, err := GameOverTx(ctx, db, game, GameResult{Winner: game.OtherPlayer(), Loser: game.CurrentPlayer(), IsDraw: false})
+		result := GameResult{Winner: game.OtherPlayer(), Loser: game.CurrentPlayer(), IsDraw: false, Kind: Timeout}
+		sr, err := GameOverTx(ctx, db, game, result)
 		if err != nil {
 			return fmt.Errorf("failed to update stats: %v for expired games: %w", sr, err)
 		}
+		if onExpire != nil {
+			onExpire(ctx, game, result, sr)
+		}
 	}
 
 	return nil
@@ -3,8 +3,11 @@ package app
 import (
 	"context"
 	"fmt"
+	"github.com/bwmarrin/discordgo"
 	"github.com/jmoiron/sqlx"
 	"math"
+	"slices"
+	"strings"
 	"testing"
 	"time"
 
@@ -54,7 +57,7 @@ func TestGameStore_CreateGame(t *testing.T) {
 		t.Fatalf("failed to get game: %v", err)
 	}
 
-	expGame := OthelloGame{ID: game.ID, Board: MakeInitialBoard(), BlackPlayer: Player{ID: "id3", Name: "Player3"}, WhitePlayer: Player{ID: "id4", Name: "Player4"}}
+	expGame := OthelloGame{ID: game.ID, Board: MakeInitialBoard(), BlackPlayer: Player{ID: "id3", Name: "Player3"}, WhitePlayer: Player{ID: "id4", Name: "Player4"}, CreatedTime: game.CreatedTime}
 
 	assert.Equal(t, expGame, game)
 	assert.Equal(t, expGame, dbGame)
@@ -65,7 +68,7 @@ func TestGameStore_CreateBotGame(t *testing.T) {
 	defer cleanup()
 
 	ctx := context.WithValue(context.Background(), TraceKey, "test-create-bot-game")
-	game, err := CreateBotGameTx(ctx, db, Player{ID: "id3", Name: "Player3"}, 5)
+	game, err := CreateBotGameTx(ctx, db, Player{ID: "id3", Name: "Player3"}, 5, true)
 	if err != nil {
 		t.Fatalf("failed to create the game: %v", err)
 	}
@@ -75,12 +78,78 @@ func TestGameStore_CreateBotGame(t *testing.T) {
 		t.Fatalf("failed to get game: %v", err)
 	}
 
-	expGame := OthelloGame{ID: game.ID, Board: MakeInitialBoard(), BlackPlayer: Player{ID: "id3", Name: "Player3"}, WhitePlayer: MakeBotPlayer(5)}
+	expGame := OthelloGame{ID: game.ID, Board: MakeInitialBoard(), BlackPlayer: Player{ID: "id3", Name: "Player3"}, WhitePlayer: MakeBotPlayer(5), CreatedTime: game.CreatedTime}
 
 	assert.Equal(t, expGame, game)
 	assert.Equal(t, expGame, dbGame)
 }
 
+func TestGameStore_CreateBotGameNoCollision(t *testing.T) {
+	db, cleanup := setupGamesTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-create-bot-game-no-collision")
+
+	// a human whose ID matches the bot level is already playing a game
+	if _, err := CreateGameTx(ctx, db, Player{ID: "3", Name: "Player3"}, Player{ID: "id4", Name: "Player4"}); err != nil {
+		t.Fatalf("failed to create the game: %v", err)
+	}
+
+	game, err := CreateBotGameTx(ctx, db, Player{ID: "id5", Name: "Player5"}, 3, true)
+	if err != nil {
+		t.Fatalf("failed to create the bot game: %v", err)
+	}
+	dbGame, err := GetGame(ctx, db, "id5")
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+	assert.Equal(t, game, dbGame)
+
+	// the bot level isn't a participant, so another game against the same level doesn't collide
+	_, err = CreateBotGameTx(ctx, db, Player{ID: "id6", Name: "Player6"}, 3, true)
+	assert.NoError(t, err)
+	_, err = CreateBotGameTx(ctx, db, Player{ID: "id7", Name: "Player7"}, 3, false)
+	assert.NoError(t, err)
+
+	assert.Panics(t, func() {
+		_, _ = CreateGameTx(ctx, db, MakeBotPlayer(3), MakeBotPlayer(4))
+	})
+}
+
+func TestGameStore_CreateBotGameAsWhite(t *testing.T) {
+	db, cleanup := setupGamesTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-create-bot-game-as-white")
+	game, err := CreateBotGameTx(ctx, db, Player{ID: "id3", Name: "Player3"}, 5, false)
+	if err != nil {
+		t.Fatalf("failed to create the game: %v", err)
+	}
+
+	expGame := OthelloGame{ID: game.ID, Board: MakeInitialBoard(), BlackPlayer: MakeBotPlayer(5), WhitePlayer: Player{ID: "id3", Name: "Player3"}, CreatedTime: game.CreatedTime}
+	assert.Equal(t, expGame, game)
+	assert.True(t, game.CurrentPlayer().IsBot())
+
+	// the bot opens, so the game isn't stored until the bot has moved
+	_, err = GetGame(ctx, db, "id3")
+	assert.ErrorIs(t, err, ErrGameNotFound)
+
+	game.MakeMove(ParseTile("d3"))
+	if _, err := InsertGameTx(ctx, db, game); err != nil {
+		t.Fatalf("failed to insert the game: %v", err)
+	}
+	dbGame, err := GetGame(ctx, db, "id3")
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+	assert.Equal(t, game, dbGame)
+
+	_, err = CreateBotGameTx(ctx, db, Player{ID: "id3", Name: "Player3"}, 2, false)
+	assert.ErrorIs(t, err, ErrAlreadyPlaying)
+	_, err = CreateBotGameTx(ctx, db, Player{ID: "id3", Name: "Player3"}, 2, true)
+	assert.ErrorIs(t, err, ErrAlreadyPlaying)
+}
+
 func TestGameStore_GetGame(t *testing.T) {
 	db, cleanup := setupGamesTest(t)
 	defer cleanup()
@@ -106,7 +175,7 @@ func TestGameStore_ExpireGames(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to count games: %v", err)
 	}
-	err = ExpireGames(ctx, db)
+	err = ExpireGames(ctx, db, nil)
 	if err != nil {
 		t.Fatalf("failed to expire games: %v", err)
 	}
@@ -114,43 +183,48 @@ func TestGameStore_ExpireGames(t *testing.T) {
 	if err != nil {
 		t.Fatalf("failed to count games: %v", err)
 	}
-	stats, err := GetTopStats(ctx, db, 10)
+	stats, err := GetTopStats(ctx, db, "", 10, 0)
 	if err != nil {
 		t.Fatalf("failed to get top stats: %v", err)
 	}
 
 	for i := range stats {
 		stats[i].Elo = math.Round(stats[i].Elo)
+		stats[i].PeakElo = math.Round(stats[i].PeakElo)
 	}
 
 	expStats := []StatsRow{
 		{
 			PlayerID: "id20",
-			Elo:      1515,
+			Elo:      1520,
 			Won:      1,
 			Drawn:    0,
 			Lost:     0,
+			PeakElo:  1520,
 		},
 		{
 			PlayerID: "id2",
-			Elo:      1515,
+			Elo:      1520,
 			Won:      1,
 			Drawn:    0,
 			Lost:     0,
+			PeakElo:  1520,
 		},
 		{
 			PlayerID: "id10",
-			Elo:      1486,
+			Elo:      1481,
 			Won:      0,
 			Drawn:    0,
 			Lost:     1,
+			PeakElo:  1500,
 		},
 		{
 			PlayerID: "id1",
-			Elo:      1486,
+			Elo:      1481,
 			Won:      0,
 			Drawn:    0,
 			Lost:     1,
+			PeakElo:  1500,
 		},
 	}
 
@@ -159,6 +233,51 @@ func TestGameStore_ExpireGames(t *testing.T) {
 	assert.Equal(t, expStats, stats)
 }
 
+type MockMessageSender struct {
+	channelIDs []string
+	messages   []string
+}
+
+func (m *MockMessageSender) ChannelMessageSend(channelID string, content string, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	m.channelIDs = append(m.channelIDs, channelID)
+	m.messages = append(m.messages, content)
+	return &discordgo.Message{}, nil
+}
+
+func TestGameStore_WarnExpiringGames(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-warn-expiring-games")
+
+	expiring := OthelloGame{ID: "1", Board: MakeInitialBoard(), BlackPlayer: Player{ID: "id1", Name: "Player1"}, WhitePlayer: Player{ID: "id2", Name: "Player2"}, ChannelID: "channel1"}
+	later := OthelloGame{ID: "2", Board: MakeInitialBoard(), BlackPlayer: Player{ID: "id3", Name: "Player3"}, WhitePlayer: Player{ID: "id4", Name: "Player4"}, ChannelID: "channel2"}
+	if err := SetGameTimeWithTime(ctx, db, expiring, time.Now().Add(time.Minute*30)); err != nil {
+		t.Fatalf("failed to insert game: %v", err)
+	}
+	if err := SetGameTimeWithTime(ctx, db, later, time.Now().Add(time.Hour*5)); err != nil {
+		t.Fatalf("failed to insert game: %v", err)
+	}
+
+	ms := &MockMessageSender{}
+	for range 2 {
+		if err := WarnExpiringGames(ctx, db, ms, ExpiryWarning); err != nil {
+			t.Fatalf("failed to warn expiring games: %v", err)
+		}
+	}
+	assert.Equal(t, []string{"channel1"}, ms.channelIDs)
+	assert.Contains(t, ms.messages[0], "<@id1>")
+
+	// a move extends the expiry, so the game is warned again when it next nears expiry
+	if err := SetGameTimeWithTime(ctx, db, expiring, time.Now().Add(time.Minute*30)); err != nil {
+		t.Fatalf("failed to update game: %v", err)
+	}
+	if err := WarnExpiringGames(ctx, db, ms, ExpiryWarning); err != nil {
+		t.Fatalf("failed to warn expiring games: %v", err)
+	}
+	assert.Len(t, ms.messages, 2)
+}
+
 func TestGameStore_MakeMove(t *testing.T) {
 	db, cleanup := setupGamesTest(t)
 	defer cleanup()
@@ -201,3 +320,321 @@ func TestGameStore_MakeMove(t *testing.T) {
 		})
 	}
 }
+
+func TestGameStore_DrawGame(t *testing.T) {
+	db, cleanup := setupGamesTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-draw-game")
+
+	game, err := GetGame(ctx, db, "id1")
+	if err != nil {
+		t.Fatalf("failed to get the game: %v", err)
+	}
+
+	sr, err := DrawGameTx(ctx, db, game)
+	if err != nil {
+		t.Fatalf("failed to draw the game: %v", err)
+	}
+	assert.Equal(t, StatsResult{WinnerElo: 1500, LoserElo: 1500}, sr)
+
+	_, err = GetGame(ctx, db, "id1")
+	assert.ErrorIs(t, err, ErrGameNotFound)
+	_, err = GetGame(ctx, db, "id2")
+	assert.ErrorIs(t, err, ErrGameNotFound)
+}
+
+func TestGameStore_ForfeitByNeither(t *testing.T) {
+	db, cleanup := setupGamesTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-forfeit-by-neither")
+
+	game, err := GetGame(ctx, db, "id1")
+	if err != nil {
+		t.Fatalf("failed to get the game: %v", err)
+	}
+
+	gr := game.CreateForfeitResult("id3")
+	assert.Equal(t, GameResult{Winner: game.BlackPlayer, Loser: game.WhitePlayer, IsDraw: true, Kind: Aborted}, gr)
+
+	sr, err := GameOverTx(ctx, db, game, gr)
+	if err != nil {
+		t.Fatalf("failed to end the game: %v", err)
+	}
+	assert.Equal(t, StatsResult{WinnerElo: 1500, LoserElo: 1500}, sr)
+
+	// no stats are created for a player outside the game
+	var count int
+	if err := db.Get(&count, "SELECT COUNT(*) FROM stats WHERE player_id NOT IN ('id1', 'id2');"); err != nil {
+		t.Fatalf("failed to count stats: %v", err)
+	}
+	assert.Equal(t, 0, count)
+
+	embed := createForfeitEmbed(gr, sr)
+	assert.Contains(t, embed.Description, "ended without a winner")
+}
+
+func TestGameStore_AbortGame(t *testing.T) {
+	db, cleanup := setupGamesTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-abort-game")
+
+	if _, _, err := MakeMoveAgainstHuman(ctx, db, "id1", ParseTile("d3")); err != nil {
+		t.Fatalf("failed to make move: %v", err)
+	}
+	_, err := AbortGameTx(ctx, db, "id2")
+	assert.ErrorIs(t, err, ErrGameStarted)
+
+	game, err := AbortGameTx(ctx, db, "id20")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", game.ID)
+
+	_, err = GetGame(ctx, db, "id10")
+	assert.ErrorIs(t, err, ErrGameNotFound)
+
+	// aborting doesn't rate the game
+	stats, err := GetTopStats(ctx, db, "", 10, 0)
+	assert.NoError(t, err)
+	assert.Empty(t, stats)
+
+	msg := createAbortMessage(game)
+	assert.Contains(t, msg, "<@id10> <@id20>")
+
+	// a bot playing black isn't mentioned
+	msg = createAbortMessage(OthelloGame{BlackPlayer: MakeBotPlayer(3), WhitePlayer: Player{ID: "id20", Name: "Player20"}})
+	assert.True(t, strings.HasPrefix(msg, "<@id20> The game"))
+}
+
+func TestGame_UndoMove(t *testing.T) {
+	board, moveList := RandomBoard(20)
+	game := OthelloGame{Board: board, MoveList: moveList}
+
+	before := game
+	before.MoveList = slices.Clone(moveList)
+	game.MakeMove(game.Board.FindCurrentMoves()[0])
+
+	assert.NoError(t, game.UndoMove())
+	assert.Equal(t, before, game)
+
+	// a trailing pass is taken back along with the move before it
+	passGame := OthelloGame{Board: MakeInitialBoard(), MoveList: []Move{{Tile: ParseTile("d3")}, {Pass: true}}}
+	assert.NoError(t, passGame.UndoMove())
+	assert.Equal(t, OthelloGame{Board: MakeInitialBoard(), MoveList: []Move{}}, passGame)
+
+	emptyGame := OthelloGame{Board: MakeInitialBoard()}
+	assert.ErrorIs(t, emptyGame.UndoMove(), ErrNoMovesToUndo)
+}
+
+func TestGame_MakeMovePass(t *testing.T) {
+	// black takes b1 from a1, leaving white with only e1 which can't flank any of black's discs
+	var board OthelloBoard
+	board.IsBlackMove = true
+	board.SetSquare(0, 1, White)
+	board.SetSquare(0, 2, Black)
+	board.SetSquare(0, 4, White)
+	board.SetSquare(0, 5, Black)
+	board.SetSquare(0, 6, Black)
+	board.SetSquare(0, 7, Black)
+
+	game := OthelloGame{BlackPlayer: Player{ID: "id1", Name: "Player1"}, WhitePlayer: Player{ID: "id2", Name: "Player2"}, Board: board}
+	assert.Equal(t, Regular, game.LastMoveKind())
+
+	moveKind := game.MakeMove(Tile{Row: 0, Col: 0})
+	assert.Equal(t, Pass, moveKind)
+	assert.Equal(t, Pass, game.LastMoveKind())
+	assert.True(t, game.Board.IsBlackMove)
+	assert.False(t, game.IsOver())
+
+	embed := createGameMoveEmbed(game, Tile{Row: 0, Col: 0}, moveKind)
+	assert.Contains(t, embed.Description, "White had no moves and passed.")
+}
+
+func TestGame_MakeMoveDoublePass(t *testing.T) {
+	// black takes b1 from a1, leaving no white discs so neither player can move
+	makeBoard := func(empties ...Tile) OthelloBoard {
+		var board OthelloBoard
+		board.IsBlackMove = true
+		for _, tile := range AllTiles {
+			board.SetSquareByTile(tile, Black)
+		}
+		board.SetSquare(0, 0, Empty)
+		board.SetSquare(0, 1, White)
+		for _, tile := range empties {
+			board.SetSquareByTile(tile, Empty)
+		}
+		return board
+	}
+
+	tests := []OthelloBoard{
+		makeBoard(),
+		makeBoard(ParseTile("h8"), ParseTile("d5")),
+	}
+
+	for i, board := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			game := OthelloGame{BlackPlayer: Player{ID: "id1", Name: "Player1"}, WhitePlayer: Player{ID: "id2", Name: "Player2"}, Board: board}
+
+			moveKind := game.MakeMove(Tile{Row: 0, Col: 0})
+			assert.Equal(t, Regular, moveKind)
+			assert.Equal(t, []Move{{Tile: Tile{Row: 0, Col: 0}}}, game.MoveList)
+			assert.True(t, game.IsOver())
+			assert.False(t, game.HasMoves())
+			assert.Equal(t, 0, game.Board.WhiteScore())
+			assert.Equal(t, Player{ID: "id1", Name: "Player1"}, game.CreateResult().Winner)
+		})
+	}
+}
+
+func TestGame_RematchPlayers(t *testing.T) {
+	player1 := Player{ID: "id1", Name: "Player1"}
+	player2 := Player{ID: "id2", Name: "Player2"}
+	bot := MakeBotPlayer(3)
+
+	type Test struct {
+		game     OthelloGame
+		expBlack Player
+		expWhite Player
+	}
+	tests := []Test{
+		{game: OthelloGame{BlackPlayer: player1, WhitePlayer: player2}, expBlack: player2, expWhite: player1},
+		{game: OthelloGame{BlackPlayer: player1, WhitePlayer: bot}, expBlack: player1, expWhite: bot},
+		{game: OthelloGame{BlackPlayer: bot, WhitePlayer: player1}, expBlack: bot, expWhite: player1},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			blackPlayer, whitePlayer := test.game.RematchPlayers()
+			assert.Equal(t, test.expBlack, blackPlayer)
+			assert.Equal(t, test.expWhite, whitePlayer)
+		})
+	}
+}
+
+func TestGameStore_Takeback(t *testing.T) {
+	db, cleanup := setupGamesTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-takeback")
+
+	move := ParseTile("d3")
+	if _, _, err := MakeMoveAgainstHuman(ctx, db, "id1", move); err != nil {
+		t.Fatalf("failed to make move: %v", err)
+	}
+
+	_, err := TakebackTx(ctx, db, "1", "id2", 1)
+	assert.ErrorIs(t, err, ErrTakebackTurn)
+	_, err = TakebackTx(ctx, db, "1", "id1", 0)
+	assert.ErrorIs(t, err, ErrStaleTakeback)
+
+	game, err := TakebackTx(ctx, db, "1", "id1", 1)
+	if err != nil {
+		t.Fatalf("failed to take back move: %v", err)
+	}
+	dbGame, err := GetGame(ctx, db, "id1")
+	if err != nil {
+		t.Fatalf("failed to get the game: %v", err)
+	}
+
+	expGame := OthelloGame{ID: "1", Board: MakeInitialBoard(), BlackPlayer: Player{ID: "id1", Name: "Player1"}, WhitePlayer: Player{ID: "id2", Name: "Player2"}, MoveList: []Move{}}
+	assert.Equal(t, expGame, game)
+	assert.Equal(t, expGame.Board, dbGame.Board)
+	assert.Empty(t, dbGame.MoveList)
+}
+
+func TestGameStore_CreateGameFromPosition(t *testing.T) {
+	db, cleanup := setupGamesTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-create-game-from-position")
+
+	board, moveList := RandomBoard(20)
+	exported := OthelloGame{WhitePlayer: Player{Name: "White"}, BlackPlayer: Player{Name: "Black"}, Board: board, MoveList: moveList}
+
+	imported, err := UnmarshalGGF(exported.MarshalGGF())
+	if err != nil {
+		t.Fatalf("failed to unmarshal ggf: %v", err)
+	}
+
+	player := Player{ID: "id3", Name: "Player3"}
+	game, err := CreateGameFromPosition(ctx, db, player, imported)
+	if err != nil {
+		t.Fatalf("failed to create game from position: %v", err)
+	}
+
+	dbGame, err := GetGame(ctx, db, "id3")
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+
+	expGame := OthelloGame{ID: game.ID, Board: board, BlackPlayer: player, WhitePlayer: player, MoveList: moveList, CreatedTime: game.CreatedTime}
+	assert.Equal(t, expGame, game)
+	assert.Equal(t, expGame, dbGame)
+
+	// a position that can't be reached from the standard start is rejected
+	custom, err := UnmarshalGGF("(;GM[Othello]BO[8 ---------------------------O*------**--------------------------- O];)")
+	if err != nil {
+		t.Fatalf("failed to unmarshal ggf: %v", err)
+	}
+	_, err = CreateGameFromPosition(ctx, db, Player{ID: "id4", Name: "Player4"}, custom)
+	assert.ErrorIs(t, err, ErrUnsupportedPosition)
+}
+
+func TestGame_MakeMoveCopy(t *testing.T) {
+	game := OthelloGame{Board: MakeInitialBoard()}
+	game.MakeMove(ParseTile("d3"))
+	game.MoveList = slices.Grow(game.MoveList, 8) // spare capacity a copy could append into
+
+	copied := game
+	copied.MakeMove(ParseTile("c3"))
+	game.MakeMove(ParseTile("e3"))
+
+	assert.Equal(t, []Move{{Tile: ParseTile("d3")}, {Tile: ParseTile("c3")}}, copied.MoveList)
+	assert.Equal(t, []Move{{Tile: ParseTile("d3")}, {Tile: ParseTile("e3")}}, game.MoveList)
+	assert.False(t, game.Board.Equal(copied.Board))
+}
+
+func TestCreateGameTx_ChannelLimit(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-channel-limit")
+	ctx = context.WithValue(ctx, ChannelKey, "channel1")
+	limitCtx := context.WithValue(ctx, ChannelGameLimitKey, 2)
+
+	player := func(i int) Player {
+		return Player{ID: fmt.Sprintf("id%d", i), Name: fmt.Sprintf("Player%d", i)}
+	}
+
+	first, err := CreateGameTx(limitCtx, db, player(1), player(2))
+	if err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	if _, err := CreateBotGameTx(limitCtx, db, player(3), 1, true); err != nil {
+		t.Fatalf("failed to create bot game: %v", err)
+	}
+
+	_, err = CreateBotGameTx(limitCtx, db, player(4), 1, true)
+	assert.ErrorIs(t, err, ErrChannelFull)
+	_, _, err = CreateMatchTx(limitCtx, db, player(4), player(5), 3)
+	assert.ErrorIs(t, err, ErrChannelFull)
+
+	// the limit is per channel, and only applies when it's set
+	otherCtx := context.WithValue(limitCtx, ChannelKey, "channel2")
+	if _, err := CreateGameTx(otherCtx, db, player(4), player(5)); err != nil {
+		t.Fatalf("failed to create game in another channel: %v", err)
+	}
+
+	// a finished game frees up the channel
+	if _, err := GameOverTx(ctx, db, first, first.CreateForfeitResult(player(1).ID)); err != nil {
+		t.Fatalf("failed to finish game: %v", err)
+	}
+	if _, err := CreateGameTx(limitCtx, db, player(6), player(7)); err != nil {
+		t.Fatalf("failed to create game after one finished: %v", err)
+	}
+
+	if _, err := CreateGameTx(ctx, db, player(8), player(9)); err != nil {
+		t.Fatalf("failed to create game without a limit: %v", err)
+	}
+}
@@ -1,7 +1,9 @@
 package app
 
 import (
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -35,8 +37,14 @@ func (o *OthelloGame) MarshalGGF() string {
 	fmt.Fprintf(&sb, "TY[%d]", BoardSize)
 	fmt.Fprintf(&sb, "BO[%s]", expBo)
 
-	for i, move := range o.MoveList {
-		if i%2 == 0 {
+	// replay the moves to track the side to move, passes consume a turn so the color can't be derived from the index
+	board := InitialBoard
+	for _, move := range o.MoveList {
+		if !move.Pass && len(board.FindCurrentMoves()) == 0 {
+			// the side to move had no moves, but the pass wasn't recorded in the move list
+			board.IsBlackMove = !board.IsBlackMove
+		}
+		if board.IsBlackMove {
 			sb.WriteString("B")
 		} else {
 			sb.WriteString("W")
@@ -44,8 +52,186 @@ func (o *OthelloGame) MarshalGGF() string {
 		sb.WriteString("[")
 		sb.WriteString(move.String())
 		sb.WriteString("]")
+
+		if move.Pass {
+			board.IsBlackMove = !board.IsBlackMove
+		} else {
+			board.MakeMove(move.Tile)
+		}
 	}
 	sb.WriteString(";)")
 
 	return sb.String()
 }
+
+var ErrInvalidGGF = errors.New("game is not valid GGF")
+
+type ggfProperty struct {
+	Key   string
+	Value string
+}
+
+// parseGGFProperties splits a GGF game of the form "(;KEY[value]KEY[value];)" into its properties in order
+func parseGGFProperties(s string) ([]ggfProperty, error) {
+	s = strings.TrimSpace(s)
+	if !strings.HasPrefix(s, "(;") || !strings.HasSuffix(s, ";)") {
+		return nil, ErrInvalidGGF
+	}
+	s = s[2 : len(s)-2]
+
+	var props []ggfProperty
+	for i := 0; i < len(s); {
+		if s[i] == ' ' || s[i] == '\n' || s[i] == '\r' || s[i] == '\t' {
+			i++
+			continue
+		}
+		start := i
+		for i < len(s) && s[i] >= 'A' && s[i] <= 'Z' {
+			i++
+		}
+		if i == start || i >= len(s) || s[i] != '[' {
+			return nil, fmt.Errorf("%w: expected a property at offset %d", ErrInvalidGGF, start)
+		}
+		key := s[start:i]
+		i++
+
+		var value strings.Builder
+		for ; i < len(s) && s[i] != ']'; i++ {
+			if s[i] == '\\' && i+1 < len(s) {
+				i++
+			}
+			value.WriteByte(s[i])
+		}
+		if i >= len(s) {
+			return nil, fmt.Errorf("%w: unterminated property %s", ErrInvalidGGF, key)
+		}
+		i++
+
+		props = append(props, ggfProperty{Key: key, Value: value.String()})
+	}
+	return props, nil
+}
+
+// unmarshalGGFBoard parses a BO property of the form "8 <64 squares> <side to move>", the squares may be split into
+// rows separated by spaces as GGS writes them
+func unmarshalGGFBoard(s string) (OthelloBoard, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 3 {
+		return OthelloBoard{}, fmt.Errorf("%w: board should have a size, squares and a side to move", ErrInvalidGGF)
+	}
+	if size, err := strconv.Atoi(fields[0]); err != nil || size != BoardSize {
+		return OthelloBoard{}, fmt.Errorf("%w: unsupported board size %s", ErrInvalidGGF, fields[0])
+	}
+	squares := strings.Join(fields[1:len(fields)-1], "")
+	side := fields[len(fields)-1]
+	if len(squares) != BoardSize*BoardSize {
+		return OthelloBoard{}, fmt.Errorf("%w: board should have %d squares", ErrInvalidGGF, BoardSize*BoardSize)
+	}
+
+	var board OthelloBoard
+	for i, ch := range []byte(squares) {
+		switch ch {
+		case '-':
+		case 'O':
+			board.SetSquareByPosition(i, White)
+		case '*':
+			board.SetSquareByPosition(i, Black)
+		default:
+			return OthelloBoard{}, fmt.Errorf("%w: invalid square %c", ErrInvalidGGF, ch)
+		}
+	}
+
+	switch side {
+	case "*":
+		board.IsBlackMove = true
+	case "O":
+		board.IsBlackMove = false
+	default:
+		return OthelloBoard{}, fmt.Errorf("%w: invalid side to move %s", ErrInvalidGGF, side)
+	}
+	return board, nil
+}
+
+var ErrInconsistentGGF = errors.New("game moves do not lead to the board in the GGF")
+
+// UnmarshalGGF parses a GGF game, replaying its moves from the standard start so the returned board is the final
+// position. A BO that isn't the standard start is the position reached by the moves, so it must match the replay.
+func UnmarshalGGF(s string) (OthelloGame, error) {
+	props, err := parseGGFProperties(s)
+	if err != nil {
+		return OthelloGame{}, err
+	}
+
+	game := OthelloGame{Board: InitialBoard}
+	bo := InitialBoard
+	var moves []ggfProperty
+
+	for _, prop := range props {
+		switch prop.Key {
+		case "GM":
+			if !strings.EqualFold(prop.Value, "Othello") {
+				return OthelloGame{}, fmt.Errorf("%w: unsupported game %s", ErrInvalidGGF, prop.Value)
+			}
+		case "TY":
+			if prop.Value != strconv.Itoa(BoardSize) {
+				return OthelloGame{}, fmt.Errorf("%w: unsupported game type %s", ErrInvalidGGF, prop.Value)
+			}
+		case "PB":
+			game.BlackPlayer.Name = prop.Value
+		case "PW":
+			game.WhitePlayer.Name = prop.Value
+		case "BO":
+			if bo, err = unmarshalGGFBoard(prop.Value); err != nil {
+				return OthelloGame{}, err
+			}
+		case "B", "W":
+			moves = append(moves, prop)
+		}
+	}
+
+	if bo != InitialBoard && len(moves) == 0 {
+		// a position without a transcript
+		game.Board = bo
+		return game, nil
+	}
+
+	for _, move := range moves {
+		if err := game.replayGGFMove(move.Key == "B", move.Value); err != nil {
+			return OthelloGame{}, err
+		}
+	}
+	if bo != InitialBoard && bo != game.Board {
+		return OthelloGame{}, ErrInconsistentGGF
+	}
+	return game, nil
+}
+
+func (o *OthelloGame) replayGGFMove(isBlack bool, value string) error {
+	// moves may be annotated with an evaluation and time such as "d3/1.00/0"
+	notation, _, _ := strings.Cut(value, "/")
+
+	if isBlack != o.Board.IsBlackMove {
+		if len(o.Board.FindCurrentMoves()) > 0 {
+			return fmt.Errorf("%w: move %s played out of turn", ErrInvalidGGF, value)
+		}
+		// the side to move had no moves, but the pass wasn't recorded
+		o.Board.IsBlackMove = !o.Board.IsBlackMove
+	}
+
+	if strings.EqualFold(notation, "PA") {
+		if len(o.Board.FindCurrentMoves()) > 0 {
+			return fmt.Errorf("%w: pass played with legal moves available", ErrInvalidGGF)
+		}
+		o.Board.IsBlackMove = !o.Board.IsBlackMove
+		o.MoveList = append(o.MoveList, Move{Pass: true})
+		return nil
+	}
+
+	tile, err := ParseTileSafe(notation)
+	if err != nil || !o.Board.IsLegalMove(tile) {
+		return fmt.Errorf("%w: illegal move %s", ErrInvalidGGF, value)
+	}
+	o.Board.MakeMove(tile)
+	o.MoveList = append(o.MoveList, Move{Tile: tile})
+	return nil
+}
@@ -1,7 +1,9 @@
 package app
 
 import (
+	"fmt"
 	"github.com/stretchr/testify/assert"
+	"strings"
 	"testing"
 )
 
@@ -17,3 +19,112 @@ func TestGame_MarshalGGF(t *testing.T) {
 
 	assert.Equal(t, str, "(;GM[Othello]PB[Player2]PW[Player1]TY[8]BO[8 ---------------------------O*------*O--------------------------- *]B[A1]W[A2]B[B1]W[B2];)")
 }
+
+func TestGame_MarshalGGF_Pass(t *testing.T) {
+	moveListStr := "F5,D6,C4,G5,D7,E3,H5,G6,G7,B4,B3,E6,B5,A4,D3,A2,F6,F3,F4,G4,C5,B6,G2,C3,D2,F7,H4,H8,A5,B2,C7,D8,G8,E2,F2,A3,C8,G1,B1,C1,C2,E7,D1,B8,F8,E1,A1,A6,A7,E8,C6,H2,H1,G3,H3,B7,A8,PA,H7,H6,F1"
+	moveList, err := UnmarshalMoveList(moveListStr)
+	if err != nil {
+		t.Fatalf("failed to unmarshal move list: %v", err)
+	}
+
+	var passlessList []Move
+	for _, move := range moveList {
+		if !move.Pass {
+			passlessList = append(passlessList, move)
+		}
+	}
+
+	type Test struct {
+		moveList []Move
+		expTail  string
+	}
+	tests := []Test{
+		{moveList: moveList, expTail: "W[B7]B[A8]W[PA]B[H7]W[H6]B[F1];)"},
+		{moveList: passlessList, expTail: "W[B7]B[A8]B[H7]W[H6]B[F1];)"},
+	}
+
+	for _, test := range tests {
+		game := OthelloGame{WhitePlayer: Player{ID: "id1", Name: "Player1"}, BlackPlayer: Player{ID: "id2", Name: "Player2"}, MoveList: test.moveList}
+		str := game.MarshalGGF()
+		assert.True(t, strings.HasSuffix(str, test.expTail), "expected %s to end with %s", str, test.expTail)
+	}
+}
+
+func TestGame_UnmarshalGGF(t *testing.T) {
+	board, moveList := RandomBoard(40)
+	game := OthelloGame{WhitePlayer: Player{Name: "Player1"}, BlackPlayer: Player{Name: "Player2"}, Board: board, MoveList: moveList}
+
+	parsed, err := UnmarshalGGF(game.MarshalGGF())
+	if err != nil {
+		t.Fatalf("failed to unmarshal ggf: %v", err)
+	}
+	assert.Equal(t, game, parsed)
+
+	invalid := []string{
+		"",
+		"(;GM[Othello]B[D3]",
+		"(;GM[Chess];)",
+		"(;GM[Othello]BO[8 ---- *];)",
+		"(;GM[Othello]B[A1];)",
+		"(;GM[Othello]W[D3];)",
+		"(;GM[Othello]B[PA];)",
+	}
+	for _, s := range invalid {
+		_, err := UnmarshalGGF(s)
+		assert.ErrorIs(t, err, ErrInvalidGGF, "expected %s to be invalid", s)
+	}
+}
+
+func TestGame_UnmarshalGGF_Inconsistent(t *testing.T) {
+	board, moveList := RandomBoard(10)
+	game := OthelloGame{Board: board, MoveList: moveList}
+
+	// replace the standard start with the board reached by the moves
+	ggf := game.MarshalGGF()
+	consistent := strings.Replace(ggf, expBo, fmt.Sprintf("%d %s %s", BoardSize, board.MarshallGGF(), ggfSide(board)), 1)
+
+	parsed, err := UnmarshalGGF(consistent)
+	if err != nil {
+		t.Fatalf("failed to unmarshal ggf: %v", err)
+	}
+	assert.Equal(t, board, parsed.Board)
+	assert.Equal(t, moveList, parsed.MoveList)
+
+	// drop the last move, so the moves no longer lead to the board
+	lastMove := fmt.Sprintf("[%s];)", moveList[len(moveList)-1].String())
+	inconsistent := consistent[:strings.LastIndex(consistent, lastMove)-1] + ";)"
+
+	_, err = UnmarshalGGF(inconsistent)
+	assert.ErrorIs(t, err, ErrInconsistentGGF)
+}
+
+func TestGame_UnmarshalGGF_RowSeparated(t *testing.T) {
+	board, moveList := RandomBoard(10)
+	game := OthelloGame{Board: board, MoveList: moveList}
+
+	// GGS writes the board with each row separated by a space
+	squares := board.MarshallGGF()
+	var rows []string
+	for i := 0; i < len(squares); i += BoardSize {
+		rows = append(rows, squares[i:i+BoardSize])
+	}
+	ggf := strings.Replace(game.MarshalGGF(), expBo, fmt.Sprintf("%d %s %s", BoardSize, strings.Join(rows, " "), ggfSide(board)), 1)
+
+	parsed, err := UnmarshalGGF(ggf)
+	if err != nil {
+		t.Fatalf("failed to unmarshal ggf: %v", err)
+	}
+	assert.Equal(t, board, parsed.Board)
+	assert.Equal(t, moveList, parsed.MoveList)
+
+	// the rows still have to make up every square
+	_, err = UnmarshalGGF(strings.Replace(ggf, rows[0]+" ", "", 1))
+	assert.ErrorIs(t, err, ErrInvalidGGF)
+}
+
+func ggfSide(board OthelloBoard) string {
+	if board.IsBlackMove {
+		return "*"
+	}
+	return "O"
+}
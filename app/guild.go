@@ -0,0 +1,135 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jellydator/ttlcache/v3"
+	"github.com/jmoiron/sqlx"
+)
+
+type GuildConfig struct {
+	GuildID     string `db:"guild_id"`
+	TitlePrefix string `db:"title_prefix"`
+	Color       int    `db:"color"`
+	GuildStats  bool   `db:"guild_stats"` // games in the guild are rated on a ladder separate from the global one
+}
+
+func DefaultGuildConfig(guildID string) GuildConfig {
+	return GuildConfig{GuildID: guildID, TitlePrefix: "", Color: GreenEmbed, GuildStats: false}
+}
+
+// StatsGuildID is the guild whose ladder games are rated on, empty for the global ladder
+func (cfg GuildConfig) StatsGuildID() string {
+	if !cfg.GuildStats {
+		return ""
+	}
+	return cfg.GuildID
+}
+
+// Brand applies the guild's title prefix and color to an embed, only embeds using the default color are recolored
+func (cfg GuildConfig) Brand(embed *discordgo.MessageEmbed) *discordgo.MessageEmbed {
+	if cfg.TitlePrefix != "" && embed.Title != "" {
+		embed.Title = fmt.Sprintf("[%s] %s", cfg.TitlePrefix, embed.Title)
+	}
+	if embed.Color == GreenEmbed {
+		embed.Color = cfg.Color
+	}
+	return embed
+}
+
+var ErrInvalidColor = errors.New("color should be a hex string of the form '#00ff00'")
+
+func ParseColor(s string) (int, error) {
+	color, err := strconv.ParseInt(strings.TrimPrefix(s, "#"), 16, 32)
+	if err != nil || color < 0 || color > 0xffffff {
+		return 0, ErrInvalidColor
+	}
+	return int(color), nil
+}
+
+func GetGuildConfig(ctx context.Context, db *sqlx.DB, guildID string) (GuildConfig, error) {
+	var cfg GuildConfig
+	err := db.GetContext(ctx, &cfg, "SELECT guild_id, title_prefix, color, guild_stats FROM guilds WHERE guild_id = $1;", guildID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultGuildConfig(guildID), nil
+	}
+	if err != nil {
+		return GuildConfig{}, fmt.Errorf("failed to select guild config: %w", err)
+	}
+	return cfg, nil
+}
+
+func SetGuildConfig(ctx context.Context, db *sqlx.DB, cfg GuildConfig) error {
+	_, err := db.ExecContext(ctx,
+		"INSERT OR REPLACE INTO guilds (guild_id, title_prefix, color, guild_stats) VALUES ($1, $2, $3, $4);",
+		cfg.GuildID, cfg.TitlePrefix, cfg.Color, cfg.GuildStats,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert or replace guild config: %w", err)
+	}
+	return nil
+}
+
+const GuildCacheTTl = time.Minute * 10
+
+type GuildCache struct {
+	Cache *ttlcache.Cache[string, GuildConfig]
+	Db    *sqlx.DB
+}
+
+func MakeGuildCache(db *sqlx.DB) GuildCache {
+	return GuildCache{Cache: ttlcache.New[string, GuildConfig](), Db: db}
+}
+
+func (gc GuildCache) GetConfig(ctx context.Context, guildID string) GuildConfig {
+	trace := ctx.Value(TraceKey)
+
+	if guildID == "" {
+		return DefaultGuildConfig(guildID)
+	}
+	if item := gc.Cache.Get(guildID); item != nil {
+		return item.Value()
+	}
+
+	cfg, err := GetGuildConfig(ctx, gc.Db, guildID)
+	if err != nil {
+		// branding is cosmetic so a failure shouldn't stop the command from being handled
+		slog.Error("failed to get guild config", "trace", trace, "guildID", guildID, "err", err)
+		return DefaultGuildConfig(guildID)
+	}
+	gc.Cache.Set(guildID, cfg, GuildCacheTTl)
+	return cfg
+}
+
+func (gc GuildCache) SetConfig(ctx context.Context, cfg GuildConfig) error {
+	if err := SetGuildConfig(ctx, gc.Db, cfg); err != nil {
+		return err
+	}
+	gc.Cache.Set(cfg.GuildID, cfg, GuildCacheTTl)
+	return nil
+}
+
+// statsGuildID is the ladder for the guild the interaction was sent from
+func statsGuildID(ctx context.Context) string {
+	cfg, ok := ctx.Value(GuildConfigKey).(GuildConfig)
+	if !ok {
+		return ""
+	}
+	return cfg.StatsGuildID()
+}
+
+func brandEmbed(ctx context.Context, embed *discordgo.MessageEmbed) *discordgo.MessageEmbed {
+	cfg, ok := ctx.Value(GuildConfigKey).(GuildConfig)
+	if !ok {
+		return embed
+	}
+	return cfg.Brand(embed)
+}
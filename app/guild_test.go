@@ -0,0 +1,46 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGuildConfig_Brand(t *testing.T) {
+	game := OthelloGame{WhitePlayer: Player{ID: "id1", Name: "Player1"}, BlackPlayer: Player{ID: "id2", Name: "Player2"}, Board: MakeInitialBoard()}
+
+	cfg := GuildConfig{GuildID: "guild1", TitlePrefix: "MyServer", Color: 0x0000ff}
+	ctx := context.WithValue(context.Background(), GuildConfigKey, cfg)
+
+	embed := brandEmbed(ctx, createGameStartEmbed(game))
+	assert.Equal(t, "[MyServer] Game Started!", embed.Title)
+	assert.Equal(t, 0x0000ff, embed.Color)
+
+	embed = brandEmbed(context.Background(), createGameStartEmbed(game))
+	assert.Equal(t, "Game Started!", embed.Title)
+	assert.Equal(t, GreenEmbed, embed.Color)
+}
+
+func TestGuildConfig_Store(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-guild-config")
+
+	cfg, err := GetGuildConfig(ctx, db, "guild1")
+	if err != nil {
+		t.Fatalf("failed to get guild config: %v", err)
+	}
+	assert.Equal(t, DefaultGuildConfig("guild1"), cfg)
+
+	expCfg := GuildConfig{GuildID: "guild1", TitlePrefix: "MyServer", Color: 0x0000ff}
+	if err := SetGuildConfig(ctx, db, expCfg); err != nil {
+		t.Fatalf("failed to set guild config: %v", err)
+	}
+	cfg, err = GetGuildConfig(ctx, db, "guild1")
+	if err != nil {
+		t.Fatalf("failed to get guild config: %v", err)
+	}
+	assert.Equal(t, expCfg, cfg)
+}
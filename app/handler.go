@@ -8,6 +8,11 @@ import (
 	"image"
 	"log"
 	"log/slog"
+	"math/rand/v2"
+	"os"
+	"runtime/debug"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
@@ -17,14 +22,22 @@ import (
 type State struct {
 	Dg             *discordgo.Session
 	Db             *sqlx.DB
-	Sh             *NTestShell
+	Sh             EngineShell
 	Renderer       Renderer
+	Themes         ThemeRenderers
 	UserCache      UserCache
 	ChallengeCache ChallengeCache
+	AnalysisCache  AnalysisCache
+	DrawOffers     DrawOfferCache
+	GuildCache     GuildCache
+	Cooldowns      CooldownCache
 	SimCache       SimCache
+	Spectators     SpectatorCache
+	GameTtl        time.Duration
+	GameLimit      int // the number of games that can be in progress in a channel at once
 }
 
-func MakeState(db *sqlx.DB, dg *discordgo.Session, sh *NTestShell) State {
+func MakeState(db *sqlx.DB, dg *discordgo.Session, sh EngineShell) State {
 	if db == nil {
 		log.Fatalf("db must be non nil")
 	}
@@ -34,22 +47,95 @@ func MakeState(db *sqlx.DB, dg *discordgo.Session, sh *NTestShell) State {
 	if sh == nil {
 		log.Fatalf("ntest shell must be non nil")
 	}
+	themes := MakeThemeRenderers(parseRenderScale(os.Getenv("RENDER_SCALE")))
+	gameTtl := parseGameTtl(os.Getenv("GAME_TTL"))
 	return State{
 		Db:             db,
 		Dg:             dg,
 		Sh:             sh,
-		Renderer:       MakeRenderCache(),
+		Renderer:       themes.Get(ClassicTheme.Name),
+		Themes:         themes,
 		ChallengeCache: MakeChallengeCache(),
+		AnalysisCache:  MakeAnalysisCache(),
+		DrawOffers:     MakeDrawOfferCache(),
+		GuildCache:     MakeGuildCache(db),
+		Cooldowns:      MakeCooldownCache(),
 		UserCache:      MakeUserCache(dg),
 		SimCache:       MakeSimCache(),
+		Spectators:     MakeSpectatorCache(gameTtl),
+		GameTtl:        gameTtl,
+		GameLimit:      parseChannelGameLimit(os.Getenv("CHANNEL_GAME_LIMIT")),
 	}
 }
 
+// parseGameTtl parses the time a game can go without a move such as "12h", an empty or invalid ttl uses the default
+func parseGameTtl(str string) time.Duration {
+	if str == "" {
+		return GameStoreTtl
+	}
+	ttl, err := time.ParseDuration(str)
+	if err != nil || ttl <= 0 {
+		slog.Warn("invalid game ttl, using the default", "ttl", str, "default", GameStoreTtl)
+		return GameStoreTtl
+	}
+	return ttl
+}
+
+// parseChannelGameLimit parses the number of games that can be in progress in a channel at once, an empty or invalid
+// limit uses the default
+func parseChannelGameLimit(str string) int {
+	if str == "" {
+		return DefaultChannelGameLimit
+	}
+	limit, err := strconv.Atoi(str)
+	if err != nil || limit <= 0 {
+		slog.Warn("invalid channel game limit, using the default", "limit", str, "default", DefaultChannelGameLimit)
+		return DefaultChannelGameLimit
+	}
+	return limit
+}
+
+// parseRenderScale parses the multiple of the default size boards are drawn at such as "2", an empty or invalid scale
+// draws boards at the default size
+func parseRenderScale(str string) float64 {
+	if str == "" {
+		return 1
+	}
+	scale, err := strconv.ParseFloat(str, 64)
+	if err != nil || scale < MinRenderScale || scale > MaxRenderScale {
+		slog.Warn("invalid render scale, using the default size", "scale", str, "min", MinRenderScale, "max", MaxRenderScale)
+		return 1
+	}
+	return scale
+}
+
 var ErrUserNotProvided = errors.New("user not provided")
 
+// playerRenderer finds the renderer for the player's theme and orientation, boards seen by other players use the
+// default renderer
+func playerRenderer(ctx context.Context, state *State, playerID string) Renderer {
+	settings, err := GetUserSettings(ctx, state.Db, playerID)
+	if err != nil {
+		return state.Renderer
+	}
+	return settingsRenderer(state, settings)
+}
+
+func settingsRenderer(state *State, settings UserSettings) Renderer {
+	return state.Themes.Get(settings.Theme).WithFlip(settings.FlipBoard)
+}
+
 func (state *State) HandeInteractionCreate(_ *discordgo.Session, ic *discordgo.InteractionCreate) {
 	trace := uuid.NewString()
 	ctx := context.WithValue(context.Background(), TraceKey, trace)
+	ctx = context.WithValue(ctx, GuildConfigKey, state.GuildCache.GetConfig(ctx, ic.GuildID))
+	ctx = context.WithValue(ctx, ChannelKey, ic.ChannelID)
+	ctx = context.WithValue(ctx, GameTtlKey, state.GameTtl)
+	ctx = context.WithValue(ctx, ChannelGameLimitKey, state.GameLimit)
+
+	defer recoverPanic(ctx, func(err error) {
+		handleInteractionError(ctx, state.Dg, ic, err)
+	})
 
 	switch ic.Type {
 	case discordgo.InteractionApplicationCommandAutocomplete:
@@ -63,8 +149,30 @@ func (state *State) HandeInteractionCreate(_ *discordgo.Session, ic *discordgo.I
 			HandleChallenge(ctx, state, ic)
 		case "accept":
 			HandleAccept(ctx, state, ic)
+		case "match":
+			HandleMatch(ctx, state, ic)
 		case "forfeit":
 			HandleForfeit(ctx, state, ic)
+		case "draw":
+			HandleDraw(ctx, state, ic)
+		case "watch":
+			HandleWatch(ctx, state, ic)
+		case "unwatch":
+			HandleUnwatch(ctx, state, ic)
+		case "abort":
+			HandleAbort(ctx, state, ic)
+		case "takeback":
+			HandleTakeback(ctx, state, ic)
+		case "history":
+			HandleHistory(ctx, state, ic)
+		case "export":
+			HandleExport(ctx, state, ic)
+		case "replay":
+			HandleReplay(ctx, state, ic)
+		case "moves":
+			HandleMoves(ctx, state, ic)
+		case "import":
+			HandleImport(ctx, state, ic)
 		case "move":
 			if ic.Interaction.Type == discordgo.InteractionApplicationCommandAutocomplete {
 				HandleMoveAutocomplete(ctx, state, ic)
@@ -75,12 +183,38 @@ func (state *State) HandeInteractionCreate(_ *discordgo.Session, ic *discordgo.I
 			HandleView(ctx, state, ic)
 		case "analyze":
 			HandleAnalyze(ctx, state, ic)
+		case "hint":
+			HandleHint(ctx, state, ic)
+		case "help":
+			HandleHelp(ctx, state, ic)
 		case "simulate":
 			HandleSimulate(ctx, state, ic)
 		case "stats":
 			HandleStats(ctx, state, ic)
+		case "profile":
+			HandleProfile(ctx, state, ic)
+		case "record":
+			HandleRecord(ctx, state, ic)
 		case "leaderboard":
 			HandleLeaderboard(ctx, state, ic)
+		case "fastest":
+			HandleFastest(ctx, state, ic)
+		case "topbot":
+			HandleTopBot(ctx, state, ic)
+		case "branding":
+			HandleBranding(ctx, state, ic)
+		case "guildstats":
+			HandleGuildStats(ctx, state, ic)
+		case "seed":
+			HandleSeed(ctx, state, ic)
+		case "stats-admin":
+			HandleStatsAdmin(ctx, state, ic)
+		case "settings":
+			HandleSettings(ctx, state, ic)
+		case "theme":
+			HandleTheme(ctx, state, ic)
+		case "orientation":
+			HandleOrientation(ctx, state, ic)
 		}
 	case discordgo.InteractionMessageComponent:
 		msg := ic.MessageComponentData()
@@ -93,6 +227,16 @@ func (state *State) HandeInteractionCreate(_ *discordgo.Session, ic *discordgo.I
 			HandlePauseComponent(state, ic, key)
 		case SimStopKey:
 			HandleStopComponent(state, ic, key)
+		case ViewRefreshKey:
+			HandleRefreshComponent(ctx, state, ic, key)
+		case RematchKey:
+			HandleRematchComponent(ctx, state, ic, key)
+		case LeaderboardPageKey:
+			HandleLeaderboardPageComponent(ctx, state, ic, key)
+		case TakebackAcceptKey:
+			HandleTakebackComponent(ctx, state, ic, key, true)
+		case TakebackDeclineKey:
+			HandleTakebackComponent(ctx, state, ic, key, false)
 		default:
 			slog.Warn("unknown message component condition", "name", msg.CustomID, "cond", cond)
 		}
@@ -114,6 +258,8 @@ func HandleChallenge(ctx context.Context, state *State, ic *discordgo.Interactio
 	}
 }
 
+const ChannelFullMsg = "This channel has too many games in progress, wait for one to finish or play in another channel."
+
 func HandleBotChallengeCommand(ctx context.Context, state *State, ic *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
 	level, err := getLevelOpt(options, "level")
 	if err != nil {
@@ -129,20 +275,94 @@ func HandleBotChallengeCommand(ctx context.Context, state *State, ic *discordgo.
 		return
 	}
 
-	game, err := CreateBotGameTx(ctx, state.Db, player, level)
+	side, err := getSideOpt(options, "color")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	isBlack := side != WhiteSide
+	if side == RandomSide {
+		isBlack = rand.IntN(2) == 0
+	}
+
+	game, err := CreateBotGameTx(ctx, state.Db, player, level, isBlack)
 	if errors.Is(err, ErrAlreadyPlaying) {
 		interactionRespond(state.Dg, ic.Interaction, createStringResponse("You're already in a game."))
 		return
 	}
+	if errors.Is(err, ErrChannelFull) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(ChannelFullMsg))
+		return
+	}
 	if err != nil {
 		handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to create game with level=%d, player=%v: %w", level, player, err))
 		return
 	}
 
-	embed := createGameStartEmbed(game)
-	img := state.Renderer.DrawBoardMoves(game.Board, game.Board.FindCurrentMoves())
+	respondBotGameStart(ctx, state, ic, game)
+}
 
+// respondBotGameStart shows the start of a game against the bot, a bot playing black makes the opening move right away
+// and the game is stored once it has moved
+func respondBotGameStart(ctx context.Context, state *State, ic *discordgo.InteractionCreate, game OthelloGame) {
+	human := game.BlackPlayer
+	if human.IsBot() {
+		human = game.WhitePlayer
+	}
+
+	settings, err := GetUserSettings(ctx, state.Db, human.ID)
+	if err != nil {
+		settings = DefaultUserSettings(human.ID)
+	}
+	renderer := settingsRenderer(state, settings)
+
+	embed := brandEmbed(ctx, createGameStartEmbed(game))
+	var img image.Image
+	if game.CurrentPlayer().IsBot() {
+		img = renderer.DrawBoard(game.Board)
+	} else {
+		img = renderer.DrawBoardMoves(game.Board, settings.VisibleMoves(game.Board))
+	}
 	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
+
+	if game.CurrentPlayer().IsBot() {
+		playBotOpening(ctx, state, ic, game, settings, renderer)
+	}
+}
+
+const GameStartConflictMsg = "The game couldn't start, a player joined another game while the bot was moving."
+
+// playBotOpening makes the opening move of a bot playing black as a follow-up to the interaction, then stores the game
+func playBotOpening(ctx context.Context, state *State, ic *discordgo.InteractionCreate, game OthelloGame, settings UserSettings, renderer Renderer) {
+	trace := ctx.Value(TraceKey)
+
+	rankMove, err := findBotMove(ctx, state, ic, game, false, renderer)
+	if err != nil {
+		slog.Error("failed to handle bot opening", "trace", trace, "err", err)
+		followupSend(state.Dg, ic.Interaction, createStringSend(InternalServerErrorMsg))
+		return
+	}
+	moveKind := game.MakeMove(rankMove.Tile)
+
+	_, err = InsertGameTx(ctx, state.Db, game)
+	if errors.Is(err, ErrAlreadyPlaying) {
+		followupSend(state.Dg, ic.Interaction, createStringSend(GameStartConflictMsg))
+		return
+	}
+	if errors.Is(err, ErrChannelFull) {
+		followupSend(state.Dg, ic.Interaction, createStringSend(ChannelFullMsg))
+		return
+	}
+	if err != nil {
+		slog.Error("failed to store game after bot opening", "trace", trace, "err", err)
+		followupSend(state.Dg, ic.Interaction, createStringSend(InternalServerErrorMsg))
+		return
+	}
+
+	sendBotMove(ctx, state, ic, game, rankMove, moveKind, settings, renderer)
+	if ps, ok := state.Sh.(*PonderShell); ok {
+		ps.Ponder(game, game.OtherPlayer().LevelToDepth())
+	}
 }
 
 func HandleUserChallengeCommand(ctx context.Context, state *State, ic *discordgo.InteractionCreate, options []*discordgo.ApplicationCommandInteractionDataOption) {
@@ -151,6 +371,29 @@ func HandleUserChallengeCommand(ctx context.Context, state *State, ic *discordgo
 		handleInteractionError(ctx, state.Dg, ic, err)
 		return
 	}
+	createUserChallenge(ctx, state, ic, Challenge{Challenged: opponent})
+}
+
+func HandleMatch(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	options := ic.ApplicationCommandData().Options
+
+	opponent, err := getPlayerOpt(ctx, &state.UserCache, options, "opponent")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	bestOf, err := getBestOfOpt(options, "best-of")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	createUserChallenge(ctx, state, ic, Challenge{Challenged: opponent, BestOf: bestOf})
+}
+
+// createUserChallenge challenges the opponent on behalf of the user of the interaction, the challenge is to a match
+// when it has a number of games
+func createUserChallenge(ctx context.Context, state *State, ic *discordgo.InteractionCreate, challenge Challenge) {
+	opponent := challenge.Challenged
 
 	var player Player
 	if ic.Interaction.Member != nil {
@@ -160,13 +403,47 @@ func HandleUserChallengeCommand(ctx context.Context, state *State, ic *discordgo
 		return
 	}
 
+	// the cooldown only starts once the challenge is created, so a rejected challenge can be sent again straight away
+	cooldownKey := fmt.Sprintf("challenge,%s", player.ID)
+	if remaining, ok := state.Cooldowns.Check(cooldownKey); !ok {
+		msg := fmt.Sprintf("You're challenging players too quickly, wait %s before challenging again.", formatCooldown(remaining))
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse(msg))
+		return
+	}
+
+	// the players and the limit are checked again when the challenge is accepted, as games may have started since
+	err := CheckGameParticipation(ctx, state.Db, player.ID, &opponent.ID)
+	if errors.Is(err, ErrAlreadyPlaying) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse("Can't challenge while either player is in a game."))
+		return
+	}
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	err = CheckChannelGames(ctx, state.Db, ic.ChannelID)
+	if errors.Is(err, ErrChannelFull) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(ChannelFullMsg))
+		return
+	}
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
 	channelID := ic.ChannelID
 	handleExpire := func() {
 		channelMessageSend(state.Dg, channelID, fmt.Sprintf("<@%s> Challenge timed out!", player.ID))
 	}
-	state.ChallengeCache.CreateChallenge(ctx, Challenge{Challenger: player, Challenged: opponent}, handleExpire)
+	challenge.Challenger = player
+	state.ChallengeCache.CreateChallenge(ctx, challenge, handleExpire)
+	state.Cooldowns.Start(cooldownKey, ChallengeCooldown)
 
-	msg := fmt.Sprintf("<@%s>, %s has challenged you to a game of Othello. Type `/accept` <@%s>, or ignore to decline", opponent.ID, player.Name, player.ID)
+	gameDesc := "a game"
+	if challenge.BestOf > 0 {
+		gameDesc = fmt.Sprintf("a best of %d match", challenge.BestOf)
+	}
+	msg := fmt.Sprintf("<@%s>, %s has challenged you to %s of Othello. Type `/accept` <@%s>, or ignore to decline", opponent.ID, player.Name, gameDesc, player.ID)
 
 	interactionRespond(state.Dg, ic.Interaction, createStringResponse(msg))
 }
@@ -175,29 +452,129 @@ func HandleAccept(ctx context.Context, state *State, ic *discordgo.InteractionCr
 	cmd := ic.ApplicationCommandData()
 	player := MakeHumanPlayer(ic.Interaction.Member.User)
 
-	opponent, err := getPlayerOpt(ctx, &state.UserCache, cmd.Options, "challenger")
-	if err != nil {
-		handleInteractionError(ctx, state.Dg, ic, err)
-		return
+	var opponent Player
+	var err error
+	if cmd.GetOption("challenger") != nil {
+		if opponent, err = getPlayerOpt(ctx, &state.UserCache, cmd.Options, "challenger"); err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
+			return
+		}
+	} else {
+		// without a challenger the player's only pending challenge is accepted
+		challengers := state.ChallengeCache.PendingChallengers(player.ID)
+		switch len(challengers) {
+		case 0:
+			interactionRespond(state.Dg, ic.Interaction, createStringResponse("You don't have any pending challenges."))
+			return
+		case 1:
+			opponent = challengers[0]
+		default:
+			interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(createChallengersMessage(challengers)))
+			return
+		}
 	}
 
-	didAccept := state.ChallengeCache.AcceptChallenge(ctx, Challenge{Challenged: player, Challenger: opponent})
+	challenge, didAccept := state.ChallengeCache.AcceptChallenge(ctx, Challenge{Challenged: player, Challenger: opponent})
 	if !didAccept {
 		interactionRespond(state.Dg, ic.Interaction, createStringResponse("Cannot accept a challenge that does not exist."))
 		return
 	}
-	game, err := CreateGameTx(ctx, state.Db, opponent, player)
+
+	var game OthelloGame
+	var match Match
+	if challenge.BestOf > 0 {
+		match, game, err = CreateMatchTx(ctx, state.Db, opponent, player, challenge.BestOf)
+	} else {
+		game, err = CreateGameTx(ctx, state.Db, opponent, player)
+	}
+	if errors.Is(err, ErrChannelFull) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(ChannelFullMsg))
+		return
+	}
 	if err != nil {
 		handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to create game with opponent=%v cmd: %w", opponent, err))
 		return
 	}
 
 	embed := createGameStartEmbed(game)
+	if challenge.BestOf > 0 {
+		embed = createMatchStartEmbed(match, game)
+	}
+	embed = brandEmbed(ctx, embed)
 	img := state.Renderer.DrawBoard(game.Board)
 
 	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
 }
 
+// RematchTtl is how long the rematch button of a game works after the game finishes
+const RematchTtl = time.Minute * 15
+
+// HandleRematchComponent starts a game between the players of a finished game, either player can start the rematch as
+// long as neither is already playing another game
+func HandleRematchComponent(ctx context.Context, state *State, ic *discordgo.InteractionCreate, key string) {
+	blackID, whiteID, finishedTime := parseRematchKey(key)
+	if time.Since(finishedTime) > RematchTtl {
+		interactionRespond(state.Dg, ic.Interaction, createExpiredResponse())
+		return
+	}
+
+	var playerID string
+	if ic.Interaction.Member != nil {
+		playerID = ic.Interaction.Member.User.ID
+	}
+	if playerID == "" || (playerID != blackID && playerID != whiteID) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse("Only the players of this game can start a rematch."))
+		return
+	}
+
+	blackPlayer, err := getRematchPlayer(ctx, state, blackID)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	whitePlayer, err := getRematchPlayer(ctx, state, whiteID)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	var game OthelloGame
+	if blackPlayer.IsBot() {
+		game, err = PrepareBotOpening(ctx, state.Db, blackPlayer, whitePlayer)
+	} else {
+		game, err = CreateGameTx(ctx, state.Db, blackPlayer, whitePlayer)
+	}
+	if errors.Is(err, ErrAlreadyPlaying) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse("Can't start a rematch while either player is in a game."))
+		return
+	}
+	if errors.Is(err, ErrChannelFull) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(ChannelFullMsg))
+		return
+	}
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to create rematch with black=%v, white=%v: %w", blackPlayer, whitePlayer, err))
+		return
+	}
+
+	if blackPlayer.IsBot() || whitePlayer.IsBot() {
+		respondBotGameStart(ctx, state, ic, game)
+		return
+	}
+
+	embed := brandEmbed(ctx, createGameStartEmbed(game))
+	img := state.Renderer.DrawBoard(game.Board)
+	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
+}
+
+// getRematchPlayer finds the player for an ID stored in a rematch button, bots are recreated at the level of their ID
+func getRematchPlayer(ctx context.Context, state *State, playerID string) (Player, error) {
+	if player := MakePlayer(playerID, ""); !player.IsHuman() {
+		return player, nil
+	}
+	return state.UserCache.GetPlayer(ctx, playerID)
+}
+
 func handleGetGame(ctx context.Context, state *State, ic *discordgo.InteractionCreate) (OthelloGame, *discordgo.User, bool) {
 	var user *discordgo.User
 	if ic.Interaction.Member != nil {
@@ -220,128 +597,139 @@ func handleGetGame(ctx context.Context, state *State, ic *discordgo.InteractionC
 	return game, user, true
 }
 
+// handleGetComponentGame fetches the game a component was created for, components outlive their games so a game that
+// has ended or been replaced by another game is responded to as expired
+func handleGetComponentGame(ctx context.Context, state *State, ic *discordgo.InteractionCreate, gameID string, playerID string) (OthelloGame, bool) {
+	game, err := GetGame(ctx, state.Db, playerID)
+	if errors.Is(err, ErrGameNotFound) || (err == nil && game.ID != gameID) {
+		interactionRespond(state.Dg, ic.Interaction, createExpiredResponse())
+		return OthelloGame{}, false
+	}
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to get game for component player=%s: %w", playerID, err))
+		return OthelloGame{}, false
+	}
+	return game, true
+}
+
 func HandleView(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
-	game, _, ok := handleGetGame(ctx, state, ic)
+	game, user, ok := handleGetGame(ctx, state, ic)
 	if !ok {
 		return
 	}
 
-	embed := createGameEmbed(game)
-	img := state.Renderer.DrawBoardMoves(game.Board, game.Board.FindCurrentMoves())
+	var bestMoves []RankTile
+	if showAnalysis, _ := getBoolOpt(ic.ApplicationCommandData().Options, "analysis"); showAnalysis {
+		bestMoves = state.AnalysisCache.GetBest(game.Board)
+	}
 
-	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
+	embed := brandEmbed(ctx, createGameEmbed(game))
+	img := playerRenderer(ctx, state, user.ID).DrawBoardMovesAnalysis(game.Board, game.Board.FindCurrentMoves(), bestMoves)
+
+	interactionRespond(state.Dg, ic.Interaction, createComponentResponse(embed, img, createViewActionRow(game, user.ID), 0))
 }
 
-func HandleForfeit(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
-	game, user, ok := handleGetGame(ctx, state, ic)
+func HandleRefreshComponent(ctx context.Context, state *State, ic *discordgo.InteractionCreate, key string) {
+	gameID, playerID := parseGameKey(key)
+
+	game, ok := handleGetComponentGame(ctx, state, ic, gameID, playerID)
 	if !ok {
 		return
 	}
 
-	gr := game.CreateForfeitResult(user.ID)
-	sr, err := GameOverTx(ctx, state.Db, game, gr)
-	if err != nil {
-		handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to delete game in forfeit: %s", err))
-		return
-	}
+	embed := brandEmbed(ctx, createGameEmbed(game))
+	img := state.Renderer.DrawBoardMoves(game.Board, game.Board.FindCurrentMoves())
 
-	embed := createForfeitEmbed(gr, sr)
-	img := state.Renderer.DrawBoard(game.Board)
-	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
+	interactionRespond(state.Dg, ic.Interaction, createComponentUpdate(embed, img, createViewActionRow(game, playerID)))
 }
 
-func HandleMoveAutocomplete(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
-	var moves []Tile
-	if ic.Interaction.Member != nil {
-		if game, err := GetGame(ctx, state.Db, ic.Interaction.Member.User.ID); err == nil {
-			moves = game.Board.FindCurrentMoves()
-		}
-	}
-
-	var choices []*discordgo.ApplicationCommandOptionChoice
-	for _, move := range moves {
-		tileStr := move.String()
-		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: tileStr, Value: tileStr})
+func HandleHistory(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	game, _, ok := handleGetGame(ctx, state, ic)
+	if !ok {
+		return
 	}
 
-	interactionRespond(state.Dg, ic.Interaction, createAutocompleteResponse(choices))
+	embed := brandEmbed(ctx, createHistoryEmbed(game))
+	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, nil))
 }
 
-func respondMoveByHuman(state *State, ic *discordgo.InteractionCreate, game OthelloGame, sr StatsResult, move Tile) {
-	var embed *discordgo.MessageEmbed
-	var img image.Image
-
-	if game.IsOver() {
-		img = state.Renderer.DrawBoard(game.Board)
-		embed = createGameOverEmbed(game, game.CreateResult(), sr, move)
-	} else {
-		img = state.Renderer.DrawBoardMoves(game.Board, game.Board.FindCurrentMoves())
-		embed = createGameMoveEmbed(game, move)
+func HandleExport(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	game, _, ok := handleGetGame(ctx, state, ic)
+	if !ok {
+		return
 	}
 
-	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
+	b, err := MarshalJSONGame(game)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	interactionRespond(state.Dg, ic.Interaction, createFileResponse(fmt.Sprintf("game-%s.json", game.ID), "application/json", b))
 }
 
-func handleMoveAgainstBot(ctx context.Context, state *State, ic *discordgo.InteractionCreate, game OthelloGame, move Tile) {
+func HandleReplay(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
 	trace := ctx.Value(TraceKey)
 
-	handleBotErr := func(err error) {
-		slog.Error("failed to handle bot move", "trace", trace, "err", err)
-		channelMessageSendComplex(state.Dg, ic.ChannelID, createStringSend(InternalServerErrorMsg))
+	var player Player
+	if ic.Interaction.Member != nil {
+		player = MakeHumanPlayer(ic.Interaction.Member.User)
+	} else {
+		handleInteractionError(ctx, state.Dg, ic, ErrUserNotProvided)
+		return
 	}
 
-	embed := createGameEmbed(game)
-	img := state.Renderer.DrawBoardMoves(game.Board, game.Board.FindCurrentMoves())
-	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
-
-	botLevel := game.CurrentPlayer().LevelToDepth()
-
-	for game.HasMoves() {
-		respCh := state.Sh.FindBestMove(game, botLevel)
-		var resp MoveResp
+	row, err := GetLatestGameResult(ctx, state.Db, player.ID)
+	if errors.Is(err, ErrNoFinishedGame) {
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("You haven't finished a game yet."))
+		return
+	}
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	moveList, err := UnmarshalMoveList(row.Moves)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to unmarshal replay moves: %w", err))
+		return
+	}
+	if len(moveList) == 0 {
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("No moves were recorded for your last game."))
+		return
+	}
 
-		select {
-		case resp = <-respCh:
-		case <-ctx.Done():
-			handleBotErr(fmt.Errorf("timed out while waiting for engine: %w", ctx.Err()))
-			return
-		}
-		if resp.Err != nil {
-			handleBotErr(fmt.Errorf("failed to retrieve analyis data from engine: %w", resp.Err))
+	opponentID := row.LoserID
+	if opponentID == player.ID {
+		opponentID = row.WinnerID
+	}
+	opponent := MakePlayer(opponentID, "")
+	if opponent.IsHuman() {
+		if opponent, err = state.UserCache.GetPlayer(ctx, opponentID); err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
 			return
 		}
-
-		move = resp.assertValidMove(game).Tile
-		moveKind := game.MakeMove(move)
-
-		embed := createGameMoveEmbed(game, move)
-		img := state.Renderer.DrawBoardMoves(game.Board, game.Board.FindCurrentMoves())
-		channelMessageSendComplex(state.Dg, ic.ChannelID, createEmbedSend(embed, img))
-
-		if moveKind != Pass {
-			break
-		}
 	}
 
-	sr, err := UpdateGame(ctx, state.Db, game)
+	interactionRespond(state.Dg, ic.Interaction, createStringResponse("Rendering the replay... Wait a second..."))
+
+	buf, err := playerRenderer(ctx, state, player.ID).RenderReplayGIF(OthelloGame{MoveList: moveList})
 	if err != nil {
-		handleBotErr(fmt.Errorf("failed to update game: %w", err))
+		slog.Error("failed to render replay", "trace", trace, "gameID", row.GameID, "err", err)
+		interactionResponseEdit(state.Dg, ic.Interaction, createEmbedTextEdit("Failed to render the replay."))
 		return
 	}
-
-	if game.IsOver() {
-		embed := createGameOverEmbed(game, game.CreateResult(), sr, move)
-		img := state.Renderer.DrawBoard(game.Board)
-		channelMessageSendComplex(state.Dg, ic.ChannelID, createEmbedSend(embed, img))
-	}
+	embed := brandEmbed(ctx, createReplayEmbed(player, opponent, row))
+	interactionResponseEdit(state.Dg, ic.Interaction, createEmbedFileEdit(embed, "replay.gif", "image/gif", buf))
 }
 
-func HandleMove(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
-	move, moveStr, err := getTileOpt(ic.ApplicationCommandData().Options, "move")
-	if err != nil {
-		handleInteractionError(ctx, state.Dg, ic, err)
+func HandleMoves(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	game, _, ok := handleGetGame(ctx, state, ic)
+	if !ok {
 		return
 	}
+	interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(createMovesMessage(game)))
+}
+
+func HandleImport(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
 	var player Player
 	if ic.Interaction.Member != nil {
 		player = MakeHumanPlayer(ic.Interaction.Member.User)
@@ -350,166 +738,1216 @@ func HandleMove(ctx context.Context, state *State, ic *discordgo.InteractionCrea
 		return
 	}
 
-	game, sr, err := MakeMoveAgainstHuman(ctx, state.Db, player.ID, move)
+	ggf := ""
+	if opt := ic.ApplicationCommandData().GetOption("ggf"); opt != nil {
+		ggf = opt.StringValue()
+	}
+
+	imported, err := UnmarshalGGF(ggf)
+	if err != nil {
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse(fmt.Sprintf("Failed to import game: %s.", err)))
+		return
+	}
+
+	game, err := CreateGameFromPosition(ctx, state.Db, player, imported)
+	switch {
+	case errors.Is(err, ErrAlreadyPlaying):
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("You're already playing a game."))
+		return
+	case errors.Is(err, ErrUnsupportedPosition):
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("Only games played from the standard starting position can be imported."))
+		return
+	case errors.Is(err, ErrImportFinished):
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("The imported game is already finished."))
+		return
+	case errors.Is(err, ErrChannelFull):
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(ChannelFullMsg))
+		return
+	case err != nil:
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	embed := brandEmbed(ctx, createGameEmbed(game))
+	img := state.Renderer.DrawBoardMoves(game.Board, game.Board.FindCurrentMoves())
+	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
+}
+
+func HandleWatch(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	if ic.Interaction.Member == nil {
+		handleInteractionError(ctx, state.Dg, ic, ErrUserNotProvided)
+		return
+	}
+	user := ic.Interaction.Member.User
+
+	player, err := getPlayerOpt(ctx, &state.UserCache, ic.ApplicationCommandData().Options, "player")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	game, err := GetGame(ctx, state.Db, player.ID)
+	if errors.Is(err, ErrGameNotFound) {
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse(fmt.Sprintf("%s isn't playing a game.", player.Name)))
+		return
+	}
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to get game to watch for player=%s: %w", player.ID, err))
+		return
+	}
+
+	err = state.Spectators.Watch(ctx, game.ID, Spectator{ChannelID: ic.ChannelID, UserID: user.ID})
+	if errors.Is(err, ErrAlreadyWatching) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse("You're already watching this game in this channel."))
+		return
+	}
+	if errors.Is(err, ErrTooManySpectators) {
+		msg := fmt.Sprintf("This game already has the most spectators allowed, %d channels.", MaxSpectators)
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(msg))
+		return
+	}
+
+	embed := brandEmbed(ctx, createGameEmbed(game))
+	img := state.Renderer.DrawBoardMoves(game.Board, game.Board.FindCurrentMoves())
+	resp := createEmbedResponse(embed, img)
+	resp.Data.Content = fmt.Sprintf("Watching %s's game, each move will be posted in this channel until the game ends or you use `/unwatch`.", player.Name)
+	interactionRespond(state.Dg, ic.Interaction, resp)
+}
+
+func HandleUnwatch(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	if ic.Interaction.Member == nil {
+		handleInteractionError(ctx, state.Dg, ic, ErrUserNotProvided)
+		return
+	}
+	user := ic.Interaction.Member.User
+
+	player, err := getPlayerOpt(ctx, &state.UserCache, ic.ApplicationCommandData().Options, "player")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	game, err := GetGame(ctx, state.Db, player.ID)
+	if err != nil && !errors.Is(err, ErrGameNotFound) {
+		handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to get game to unwatch for player=%s: %w", player.ID, err))
+		return
+	}
+
+	if err != nil || !state.Spectators.Unwatch(game.ID, user.ID) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(fmt.Sprintf("You're not watching %s's game.", player.Name)))
+		return
+	}
+	interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(fmt.Sprintf("Stopped watching %s's game.", player.Name)))
+}
+
+func HandleAbort(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	game, user, ok := handleGetGame(ctx, state, ic)
+	if !ok {
+		return
+	}
+	if len(game.MoveList) > 0 {
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("A game can only be aborted before any moves are made, use /forfeit instead."))
+		return
+	}
+
+	game, err := AbortGameTx(ctx, state.Db, user.ID)
+	if errors.Is(err, ErrGameStarted) || errors.Is(err, ErrGameNotFound) {
+		// a move was made or the game ended since it was fetched
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("A game can only be aborted before any moves are made, use /forfeit instead."))
+		return
+	}
+	if errors.Is(err, ErrMatchStarted) {
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("A match can only be aborted before its first game finishes, use /forfeit to concede the series."))
+		return
+	}
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to abort game: %w", err))
+		return
+	}
+	state.DrawOffers.ClearOffer(game.ID)
+	state.Spectators.Clear(game.ID)
+
+	interactionRespond(state.Dg, ic.Interaction, createStringResponse(createAbortMessage(game)))
+}
+
+func HandleForfeit(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	game, user, ok := handleGetGame(ctx, state, ic)
+	if !ok {
+		return
+	}
+
+	gr := game.CreateForfeitResult(user.ID)
+	sr, err := GameOverTx(ctx, state.Db, game, gr)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to delete game in forfeit: %s", err))
+		return
+	}
+
+	embed := brandEmbed(ctx, createForfeitEmbed(gr, sr))
+	img := state.Renderer.DrawBoard(game.Board)
+	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
+
+	notifySpectators(ctx, state, game.ID, createForfeitEmbed(gr, sr), img, true)
+	announceMatch(ctx, state, ic, sr)
+}
+
+const BotDeclinedDrawMsg = "The bot declined your draw offer."
+
+func HandleDraw(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	game, user, ok := handleGetGame(ctx, state, ic)
+	if !ok {
+		return
+	}
+
+	if game.WhitePlayer.IsBot() || game.BlackPlayer.IsBot() {
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse(BotDeclinedDrawMsg))
+		return
+	}
+
+	if !state.DrawOffers.OfferDraw(ctx, game.ID, user.ID) {
+		opponent := game.WhitePlayer
+		if opponent.ID == user.ID {
+			opponent = game.BlackPlayer
+		}
+		msg := fmt.Sprintf("<@%s> offered a draw, <@%s> can accept with `/draw`.", user.ID, opponent.ID)
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse(msg))
+		return
+	}
+
+	sr, err := DrawGameTx(ctx, state.Db, game)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to delete game in draw: %s", err))
+		return
+	}
+
+	embed := brandEmbed(ctx, createDrawEmbed(game, sr))
+	img := state.Renderer.DrawBoard(game.Board)
+	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
+
+	notifySpectators(ctx, state, game.ID, createDrawEmbed(game, sr), img, true)
+	announceMatch(ctx, state, ic, sr)
+}
+
+func HandleTakeback(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	game, user, ok := handleGetGame(ctx, state, ic)
+	if !ok {
+		return
+	}
+
+	_, err := CheckTakeback(game, user.ID)
+	switch {
+	case errors.Is(err, ErrIsAgainstBot):
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("Takebacks are not allowed in bot games."))
+		return
+	case errors.Is(err, ErrNoMovesToUndo):
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("There are no moves to take back."))
+		return
+	case errors.Is(err, ErrTakebackTurn):
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("You can only take back your own last move."))
+		return
+	case err != nil:
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	opponent := game.WhitePlayer
+	if opponent.ID == user.ID {
+		opponent = game.BlackPlayer
+	}
+	interactionRespond(state.Dg, ic.Interaction, createTakebackResponse(game, user.ID, opponent.ID))
+}
+
+func HandleTakebackComponent(ctx context.Context, state *State, ic *discordgo.InteractionCreate, key string, accept bool) {
+	gameID, playerID, moveCount := parseTakebackKey(key)
+
+	game, ok := handleGetComponentGame(ctx, state, ic, gameID, playerID)
+	if !ok {
+		return
+	}
+
+	// only the opponent of the requesting player can respond to the takeback
+	var responderID string
+	if ic.Interaction.Member != nil {
+		responderID = ic.Interaction.Member.User.ID
+	}
+	if responderID == playerID || (responderID != game.WhitePlayer.ID && responderID != game.BlackPlayer.ID) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse("Only your opponent can respond to this takeback."))
+		return
+	}
+
+	if !accept {
+		interactionRespond(state.Dg, ic.Interaction, createTakebackDeclinedUpdate())
+		return
+	}
+
+	game, err := TakebackTx(ctx, state.Db, gameID, playerID, moveCount)
+	if errors.Is(err, ErrStaleTakeback) {
+		interactionRespond(state.Dg, ic.Interaction, createExpiredResponse())
+		return
+	}
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	state.DrawOffers.ClearOffer(game.ID)
+
+	embed := brandEmbed(ctx, createTakebackEmbed(game))
+	img := state.Renderer.DrawBoardMoves(game.Board, game.Board.FindCurrentMoves())
+	interactionRespond(state.Dg, ic.Interaction, createComponentUpdate(embed, img, []discordgo.MessageComponent{}))
+
+	notifySpectators(ctx, state, game.ID, createTakebackEmbed(game), img, false)
+}
+
+// autocompleteMoves are the moves suggested to the player, there are none while it's the opponent's turn as the legal
+// moves belong to the opponent
+func autocompleteMoves(game OthelloGame, playerID string, settings UserSettings, ranked []RankTile) []Tile {
+	if !game.IsTurn(playerID) {
+		return nil
+	}
+	return settings.OrderMoves(game.Board.FindCurrentMoves(), ranked)
+}
+
+func HandleMoveAutocomplete(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	var moves []Tile
+	if ic.Interaction.Member != nil {
+		playerID := ic.Interaction.Member.User.ID
+		if game, err := GetGame(ctx, state.Db, playerID); err == nil {
+			settings, err := GetUserSettings(ctx, state.Db, playerID)
+			if err != nil {
+				settings = DefaultUserSettings(playerID)
+			}
+			ranked, _ := state.AnalysisCache.Get(game.Board)
+			moves = autocompleteMoves(game, playerID, settings, ranked)
+		}
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, move := range moves {
+		tileStr := move.String()
+		choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: tileStr, Value: tileStr})
+	}
+
+	interactionRespond(state.Dg, ic.Interaction, createAutocompleteResponse(choices))
+}
+
+func respondMoveByHuman(ctx context.Context, state *State, ic *discordgo.InteractionCreate, game OthelloGame, sr StatsResult, move Tile, renderer Renderer) {
+	var embed *discordgo.MessageEmbed
+	var img image.Image
+	var components []discordgo.MessageComponent
+
+	if game.IsOver() {
+		img = renderer.DrawBoard(game.Board)
+		embed = brandEmbed(ctx, createGameOverEmbed(game, game.CreateResult(), sr, move))
+		if sr.Match == nil {
+			// the next game of a match starts on its own
+			components = createRematchActionRow(game, time.Now())
+		}
+	} else {
+		img = renderer.DrawBoardLastMove(game.Board, game.Board.FindCurrentMoves(), move)
+		embed = brandEmbed(ctx, createGameMoveEmbed(game, move, game.LastMoveKind()))
+	}
+
+	interactionRespond(state.Dg, ic.Interaction, createComponentResponse(embed, img, components, 0))
+
+	notifySpectatorsOfMove(ctx, state, game, sr, move)
+	announceMatch(ctx, state, ic, sr)
+}
+
+// announceMatch follows up a finished game of a match with the standing of the series, and the board of the next game
+// when the series isn't over
+func announceMatch(ctx context.Context, state *State, ic *discordgo.InteractionCreate, sr StatsResult) {
+	if sr.Match == nil {
+		return
+	}
+	embed := brandEmbed(ctx, createMatchEmbed(*sr.Match))
+
+	var img image.Image
+	if !sr.Match.IsOver {
+		img = state.Renderer.DrawBoard(sr.Match.NextGame.Board)
+	}
+	followupSend(state.Dg, ic.Interaction, createEmbedSend(embed, img))
+}
+
+// notifySpectatorsOfMove sends the board after a move to the game's spectators, spectators see the default theme and
+// the embeds of a player's game rather than their own
+func notifySpectatorsOfMove(ctx context.Context, state *State, game OthelloGame, sr StatsResult, move Tile) {
+	if game.IsOver() {
+		embed := createGameOverEmbed(game, game.CreateResult(), sr, move)
+		notifySpectators(ctx, state, game.ID, embed, state.Renderer.DrawBoard(game.Board), true)
+	} else {
+		embed := createGameEmbed(game)
+		img := state.Renderer.DrawBoardLastMove(game.Board, game.Board.FindCurrentMoves(), move)
+		notifySpectators(ctx, state, game.ID, embed, img, false)
+	}
+}
+
+// notifySpectators sends the embed and board to the game's spectators, the spectators are removed once the game ends
+func notifySpectators(ctx context.Context, state *State, gameID string, embed *discordgo.MessageEmbed, img image.Image, ended bool) {
+	state.Spectators.Notify(ctx, state.Dg, gameID, func() *discordgo.MessageSend {
+		e := *embed // each send attaches the image to its own copy of the embed
+		return createEmbedSend(&e, img)
+	}, ended)
+}
+
+// NotifyExpiredGame sends the result of a game that expired without a move to the game's spectators
+func (state *State) NotifyExpiredGame(ctx context.Context, game OthelloGame, result GameResult, sr StatsResult) {
+	notifySpectators(ctx, state, game.ID, createForfeitEmbed(result, sr), state.Renderer.DrawBoard(game.Board), true)
+}
+
+func handleMoveAgainstBot(ctx context.Context, state *State, ic *discordgo.InteractionCreate, game OthelloGame, move Tile, coach bool) {
+	settings, err := GetUserSettings(ctx, state.Db, game.OtherPlayer().ID)
+	if err != nil {
+		settings = DefaultUserSettings(game.OtherPlayer().ID)
+	}
+	renderer := settingsRenderer(state, settings)
+
+	embed := brandEmbed(ctx, createGameEmbed(game))
+	img := renderer.DrawBoardMoves(game.Board, settings.VisibleMoves(game.Board))
+	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
+	if !game.IsOver() {
+		notifySpectatorsOfMove(ctx, state, game, StatsResult{}, move)
+	}
+
+	playBotMoves(ctx, state, ic, game, move, coach, settings, renderer)
+}
+
+// playBotMoves makes the bot's moves as follow-ups to the interaction until it's the human's turn or the game is over,
+// move is the last move made before the bot's turn
+func playBotMoves(ctx context.Context, state *State, ic *discordgo.InteractionCreate, game OthelloGame, move Tile, coach bool, settings UserSettings, renderer Renderer) {
+	trace := ctx.Value(TraceKey)
+
+	handleBotErr := func(err error) {
+		slog.Error("failed to handle bot move", "trace", trace, "err", err)
+		followupSend(state.Dg, ic.Interaction, createStringSend(InternalServerErrorMsg))
+	}
+
+	botDepth := game.CurrentPlayer().LevelToDepth()
+
+	for game.HasMoves() {
+		rankMove, err := findBotMove(ctx, state, ic, game, coach, renderer)
+		if err != nil {
+			handleBotErr(err)
+			return
+		}
+		move = rankMove.Tile
+		moveKind := game.MakeMove(move)
+		sendBotMove(ctx, state, ic, game, rankMove, moveKind, settings, renderer)
+
+		if moveKind != Pass {
+			break
+		}
+	}
+
+	sr, err := UpdateGame(ctx, state.Db, game)
+	if err != nil {
+		handleBotErr(fmt.Errorf("failed to update game: %w", err))
+		return
+	}
+
+	if game.IsOver() {
+		embed := brandEmbed(ctx, createGameOverEmbed(game, game.CreateResult(), sr, move))
+		img := renderer.DrawBoard(game.Board)
+		send := createEmbedSend(embed, img)
+		send.Components = createRematchActionRow(game, time.Now())
+		followupSend(state.Dg, ic.Interaction, send)
+		notifySpectatorsOfMove(ctx, state, game, sr, move)
+	} else if ps, ok := state.Sh.(*PonderShell); ok {
+		ps.Ponder(game, botDepth)
+	}
+}
+
+// findBotMove asks the engine for the move of the bot to move, when coaching the bot's top candidates are sent first
+func findBotMove(ctx context.Context, state *State, ic *discordgo.InteractionCreate, game OthelloGame, coach bool, renderer Renderer) (RankTile, error) {
+	depth := AllocateDepth(game.Board, game.CurrentPlayer().LevelToDepth())
+
+	var respCh chan MoveResp
+	if coach {
+		// ranking every move is slower, but shows the replies the bot considered
+		respCh = state.Sh.FindRankedMoves(game, depth)
+	} else {
+		respCh = state.Sh.FindBestMove(game, depth)
+	}
+	var resp MoveResp
+
+	select {
+	case resp = <-respCh:
+	case <-ctx.Done():
+		return RankTile{}, fmt.Errorf("timed out while waiting for engine: %w", ctx.Err())
+	}
+	if resp.Err != nil {
+		return RankTile{}, fmt.Errorf("failed to retrieve analyis data from engine: %w", resp.Err)
+	}
+
+	if coach {
+		resp.Moves = topRankTiles(resp.Moves, CoachCandidates)
+		embed := brandEmbed(ctx, createCoachEmbed(game, resp.Moves))
+		img := renderer.DrawBoardAnalysis(game.Board, resp.Moves)
+		followupSend(state.Dg, ic.Interaction, createEmbedSend(embed, img))
+	}
+	return resp.validMove(game)
+}
+
+// sendBotMove shows the bot's move as a follow-up to the interaction and to the game's spectators
+func sendBotMove(ctx context.Context, state *State, ic *discordgo.InteractionCreate, game OthelloGame, rankMove RankTile, moveKind MoveKind, settings UserSettings, renderer Renderer) {
+	move := rankMove.Tile
+
+	embed := brandEmbed(ctx, createGameMoveEmbed(game, move, moveKind))
+	img := renderer.DrawBoardLastMove(game.Board, settings.VisibleMoves(game.Board), move)
+	if settings.EvalBar {
+		// the engine evaluates from the perspective of the bot, the bar shows black's advantage
+		eval := rankMove.H
+		if !game.BlackPlayer.IsBot() {
+			eval = -eval
+		}
+		img = renderer.DrawEvalBar(img, eval)
+	}
+	followupSend(state.Dg, ic.Interaction, createEmbedSend(embed, img))
+	if !game.IsOver() {
+		notifySpectatorsOfMove(ctx, state, game, StatsResult{}, move)
+	}
+}
+
+func HandleMove(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	move, moveStr, err := getTileOpt(ic.ApplicationCommandData().Options, "move")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	var player Player
+	if ic.Interaction.Member != nil {
+		player = MakeHumanPlayer(ic.Interaction.Member.User)
+	} else {
+		handleInteractionError(ctx, state.Dg, ic, ErrUserNotProvided)
+		return
+	}
+
+	coach, err := getBoolOpt(ic.ApplicationCommandData().Options, "coach")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	game, sr, err := MakeMoveAgainstHuman(ctx, state.Db, player.ID, move)
 
 	if errors.Is(err, ErrIsAgainstBot) {
-		handleMoveAgainstBot(ctx, state, ic, game, move)
+		handleMoveAgainstBot(ctx, state, ic, game, move, coach)
+		return
+	} else {
+		if resp := createMoveErrorResp(err, moveStr); resp != nil {
+			interactionRespond(state.Dg, ic.Interaction, resp)
+			return
+		}
+		if err != nil {
+			handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to make move against human: %w", err))
+			return
+		}
+	}
+	state.DrawOffers.ClearOffer(game.ID)
+	respondMoveByHuman(ctx, state, ic, game, sr, move, playerRenderer(ctx, state, player.ID))
+}
+
+// commandUserID is the user who sent the command, the member in a guild or the user in a DM
+func commandUserID(ic *discordgo.InteractionCreate) string {
+	if ic.Interaction.Member != nil {
+		return ic.Interaction.Member.User.ID
+	} else if ic.Interaction.User != nil {
+		return ic.Interaction.User.ID
+	}
+	return ""
+}
+
+// handleCommandCooldown rejects the command while the user's cooldown for it is running, the cooldown is started with
+// startCommandCooldown once the command is accepted
+func handleCommandCooldown(state *State, ic *discordgo.InteractionCreate) bool {
+	cmd := ic.ApplicationCommandData().Name
+	if remaining, ok := state.Cooldowns.CheckCommand(cmd, commandUserID(ic)); !ok {
+		msg := fmt.Sprintf("You're using /%s too quickly, wait %s before using it again.", cmd, formatCooldown(remaining))
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(msg))
+		return false
+	}
+	return true
+}
+
+func startCommandCooldown(state *State, ic *discordgo.InteractionCreate) {
+	state.Cooldowns.StartCommand(ic.ApplicationCommandData().Name, commandUserID(ic))
+}
+
+func HandleAnalyze(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	trace := ctx.Value(TraceKey)
+
+	if !handleCommandCooldown(state, ic) {
+		return
+	}
+
+	level, err := getAnalysisLevelOpt(ic.ApplicationCommandData().Options, "level")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	searchTime, err := getAnalysisTimeOpt(ic.ApplicationCommandData().Options, "time")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, searchTime)
+	defer cancel()
+	game, _, ok := handleGetGame(ctx, state, ic)
+	if !ok {
+		return
+	}
+	startCommandCooldown(state, ic)
+
+	interactionRespond(state.Dg, ic.Interaction, createStringResponse("Analyzing... Wait a second..."))
+
+	// the deepest levels can search far longer than the time limit, so the search is stopped once the handler gives up
+	respCh := findRankedMovesContext(ctx, state.Sh, game, AnalysisLevelToDepth(level))
+	select {
+	case resp := <-respCh:
+		if resp.Err != nil {
+			interactionResponseEdit(state.Dg, ic.Interaction, createEmbedTextEdit("Failed to retrieve analysis data from engine."))
+			return
+		}
+		state.AnalysisCache.Set(game.Board, resp.Moves)
+		moves, _ := state.AnalysisCache.Get(game.Board)
+
+		var pv []RankTile
+		if pvf, ok := state.Sh.(PrincipalVariationFinder); ok {
+			pv, _ = pvf.FindPrincipalVariation(game.Board, AnalysisLevelToDepth(level))
+		}
+
+		embed := brandEmbed(ctx, createAnalysisEmbed(game, level, searchTime, moves, pv))
+		img := state.Renderer.DrawBoardAnalysis(game.Board, moves)
+		interactionResponseEdit(state.Dg, ic.Interaction, createEmbedEdit(embed, img))
+	case <-ctx.Done():
+		slog.Warn("client timed out while waiting for an analysis response", "trace", trace, "err", ctx.Err())
+		interactionResponseEdit(state.Dg, ic.Interaction, createStringEdit(fmt.Sprintf("Timed out after %s while waiting for a response, try a lower level or a longer time.", searchTime)))
+	}
+	return
+}
+
+const HintLevel = 1
+const HintTimeout = time.Second * 30
+
+func HandleHint(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	trace := ctx.Value(TraceKey)
+
+	ctx, cancel := context.WithTimeout(ctx, HintTimeout)
+	defer cancel()
+	game, user, ok := handleGetGame(ctx, state, ic)
+	if !ok {
+		return
+	}
+	if game.CurrentPlayer().ID != user.ID {
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("It isn't your turn."))
+		return
+	}
+
+	interactionRespond(state.Dg, ic.Interaction, createStringResponse("Finding a hint... Wait a second..."))
+
+	respCh := state.Sh.FindBestMove(game, AllocateDepth(game.Board, LevelToDepth(HintLevel)))
+	select {
+	case resp := <-respCh:
+		if resp.Err != nil {
+			interactionResponseEdit(state.Dg, ic.Interaction, createEmbedTextEdit("Failed to retrieve a hint from engine."))
+			return
+		}
+		move, err := resp.validMove(game)
+		if err != nil {
+			slog.Error("engine produced an invalid hint", "trace", trace, "err", err)
+			interactionResponseEdit(state.Dg, ic.Interaction, createEmbedTextEdit("Failed to retrieve a hint from engine."))
+			return
+		}
+
+		embed := brandEmbed(ctx, createHintEmbed(game, move.Tile))
+		img := state.Renderer.DrawBoardMoves(game.Board, []Tile{move.Tile})
+		interactionResponseEdit(state.Dg, ic.Interaction, createEmbedEdit(embed, img))
+	case <-ctx.Done():
+		slog.Warn("client timed out while waiting for a hint response", "trace", trace, "err", ctx.Err())
+		interactionResponseEdit(state.Dg, ic.Interaction, createStringEdit("Timed out while waiting for a hint."))
+	}
+}
+
+func HandleHelp(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	board := MakeInitialBoard()
+	embed := brandEmbed(ctx, createHelpEmbed(Commands))
+	img := state.Renderer.DrawBoardMoves(board, board.FindCurrentMoves())
+	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, img))
+}
+
+func HandleSimulate(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	if !handleCommandCooldown(state, ic) {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Hour*1) // a simulation can stay paused for up to an hour
+	defer cancel()
+
+	// extract simulation inputs and send the initial simulation response
+	cmd := ic.ApplicationCommandData()
+
+	var whiteLevel uint64
+	var blackLevel uint64
+	var delay time.Duration
+	var err error
+
+	if whiteLevel, err = getLevelOpt(cmd.Options, "white-level"); err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	if blackLevel, err = getLevelOpt(cmd.Options, "black-level"); err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	if delay, err = getDelayOpt(cmd.Options, "delay"); err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	gameCount := 1
+	if loop, err := getBoolOpt(cmd.Options, "loop"); err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	} else if loop {
+		gameCount = MaxSimGames
+	}
+	keyFrames, err := getBoolOpt(cmd.Options, "keyframes")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	maxMoves, _, err := getIntOpt(cmd.Options, "max-moves", 1, MaxSimMoves)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	if isFloodingSimulation(whiteLevel, blackLevel, delay, keyFrames) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(FloodingSimulationMsg))
+		return
+	}
+	startCommandCooldown(state, ic)
+
+	initialGame := OthelloGame{
+		WhitePlayer: MakeBotPlayer(whiteLevel),
+		BlackPlayer: MakeBotPlayer(blackLevel),
+		Board:       MakeInitialBoard(),
+	}
+	embed := brandEmbed(ctx, createSimulationStartEmbed(initialGame, estimateSimulationTime(delay, gameCount, keyFrames, maxMoves)))
+	img := state.Renderer.DrawBoard(initialGame.Board)
+
+	simulationID := uuid.New().String()
+
+	response := createComponentResponse(embed, img, createSimulationActionRow(simulationID, false), 0)
+	interactionRespond(state.Dg, ic.Interaction, response)
+
+	// run the simulation against the engine and add it to the cache (so it can be paused/resumed)
+	simState := &SimState{Cancel: cancel}
+	simChan := make(chan SimStep, MaxSimCount) // give this a size so we don't block on send
+
+	state.SimCache.Set(simulationID, simState, SimulationTtl)
+
+	go GenerateSimulations(ctx, state.Sh, initialGame, gameCount, keyFrames, maxMoves, simChan)
+	RecvSimulation(ctx, state, &SimulationMessage{Interaction: ic.Interaction, SimulationID: simulationID}, delay, simState, simChan)
+}
+
+type SimulationEditor interface {
+	InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEditComplex(m *discordgo.MessageEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+// SimulationMessage is the message a simulation is shown in, a looping simulation can outlast the interaction token so
+// once it expires the simulation continues in a message sent to the channel
+type SimulationMessage struct {
+	Interaction  *discordgo.Interaction
+	SimulationID string
+	message      *discordgo.Message // the channel message the simulation continues in, nil until the token expires
+}
+
+func (sm *SimulationMessage) Edit(dg SimulationEditor, e *discordgo.WebhookEdit) {
+	if sm.message == nil && !interactionTokenExpired(sm.Interaction) {
+		interactionResponseEdit(dg, sm.Interaction, e)
+		return
+	}
+
+	if sm.message == nil {
+		// an edit without content or embeds only changes components, there is nothing to continue in the channel
+		if e.Content == nil && e.Embeds == nil {
+			return
+		}
+		send := &discordgo.MessageSend{Files: e.Files, Components: createSimulationActionRow(sm.SimulationID, false)}
+		if e.Content != nil {
+			send.Content = *e.Content
+		}
+		if e.Embeds != nil {
+			send.Embeds = *e.Embeds
+		}
+		if e.Components != nil {
+			send.Components = *e.Components
+		}
+		msg, err := dg.ChannelMessageSendComplex(sm.Interaction.ChannelID, send)
+		if err != nil {
+			slog.Error("failed to send message complex", "err", err)
+			return
+		}
+		sm.message = msg
+		return
+	}
+
+	edit := &discordgo.MessageEdit{
+		ID:          sm.message.ID,
+		Channel:     sm.Interaction.ChannelID,
+		Content:     e.Content,
+		Components:  e.Components,
+		Embeds:      e.Embeds,
+		Files:       e.Files,
+		Attachments: e.Attachments,
+	}
+	if _, err := dg.ChannelMessageEditComplex(edit); err != nil {
+		slog.Error("failed to send message edit", "err", err)
+	}
+}
+
+func RecvSimulation(ctx context.Context, state *State, sm *SimulationMessage, delay time.Duration, simState *SimState, simChan chan SimStep) {
+	trace := ctx.Value(TraceKey)
+
+	ticker := time.NewTicker(delay)
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("simulation receiver stopped", "trace", trace)
+			sm.Edit(state.Dg, &discordgo.WebhookEdit{Components: &[]discordgo.MessageComponent{}})
+			return
+		case <-ticker.C:
+			if simState.IsPaused.Load() { // paused? check again once the ticker executes
+				continue
+			}
+			step, ok := <-simChan
+			if !ok {
+				slog.Info("simulation receiver complete", "trace", trace)
+				return
+			}
+			sm.Edit(state.Dg, createStepEdit(ctx, state.Renderer, step))
+		}
+	}
+}
+
+func HandleStats(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	var user discordgo.User
+	var err error
+
+	private, err := getBoolOpt(ic.ApplicationCommandData().Options, "private")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	userOpt := ic.ApplicationCommandData().GetOption("player")
+	if userOpt != nil {
+		if user, err = state.UserCache.GetUser(ctx, userOpt.Value.(string)); err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
+			return
+		}
+	} else if ic.Interaction.Member != nil {
+		user = *ic.Interaction.Member.User
+	}
+
+	var stats Stats
+	if stats, err = ReadStats(ctx, state.Db, state.UserCache, statsGuildID(ctx), user.ID); err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	embed := brandEmbed(ctx, createStatsEmbed(user, stats))
+	interactionRespond(state.Dg, ic.Interaction, createComponentResponse(embed, nil, nil, privateFlags(private)))
+}
+
+func HandleProfile(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	var user discordgo.User
+	var err error
+
+	private, err := getBoolOpt(ic.ApplicationCommandData().Options, "private")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	userOpt := ic.ApplicationCommandData().GetOption("player")
+	if userOpt != nil {
+		if user, err = state.UserCache.GetUser(ctx, userOpt.Value.(string)); err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
+			return
+		}
+	} else if ic.Interaction.Member != nil {
+		user = *ic.Interaction.Member.User
+	}
+
+	var stats Stats
+	if stats, err = ReadStats(ctx, state.Db, state.UserCache, statsGuildID(ctx), user.ID); err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	var profile Profile
+	if profile, err = ReadProfile(ctx, state.Db, user.ID); err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
 		return
+	}
+
+	embed := brandEmbed(ctx, createProfileEmbed(user, stats, profile))
+	interactionRespond(state.Dg, ic.Interaction, createComponentResponse(embed, nil, nil, privateFlags(private)))
+}
+
+var RecordSubCmds = []string{"bot", "user"}
+
+func HandleRecord(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	var player Player
+	if ic.Interaction.Member != nil {
+		player = MakeHumanPlayer(ic.Interaction.Member.User)
 	} else {
-		if resp := createMoveErrorResp(err, moveStr); resp != nil {
-			interactionRespond(state.Dg, ic.Interaction, resp)
+		handleInteractionError(ctx, state.Dg, ic, ErrUserNotProvided)
+		return
+	}
+
+	subCmd, options := getSubcommand(ic)
+	var opponent Player
+
+	switch subCmd {
+	case "user":
+		var err error
+		if opponent, err = getPlayerOpt(ctx, &state.UserCache, options, "opponent"); err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
 			return
 		}
+	case "bot":
+		level, err := getLevelOpt(options, "level")
 		if err != nil {
-			handleInteractionError(ctx, state.Dg, ic, fmt.Errorf("failed to make move against human: %w", err))
+			handleInteractionError(ctx, state.Dg, ic, err)
 			return
 		}
+		opponent = MakeBotPlayer(level)
+	default:
+		handleInteractionError(ctx, state.Dg, ic, SubCmdError{Name: subCmd, ExpectedValues: RecordSubCmds})
+		return
+	}
+
+	wins, losses, draws, err := ReadHeadToHead(ctx, state.Db, player.ID, opponent.ID)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
 	}
-	respondMoveByHuman(state, ic, game, sr, move)
+
+	embed := brandEmbed(ctx, createRecordEmbed(player, opponent, wins, losses, draws))
+	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, nil))
 }
 
-func HandleAnalyze(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
-	trace := ctx.Value(TraceKey)
+const LeaderboardSize = 50
+
+const BlackSide = "black"
+const WhiteSide = "white"
+const RandomSide = "random"
+
+var BotChallengeSides = []string{BlackSide, WhiteSide, RandomSide}
+var BoardOrientations = []string{BlackSide, WhiteSide}
+
+const GlobalScope = "global"
+const ServerScope = "server"
 
-	ctx, cancel := context.WithTimeout(ctx, time.Minute*2)
-	defer cancel()
+var LeaderboardScopes = []string{GlobalScope, ServerScope}
+
+// LeaderboardPageTtl is how long the page buttons of a leaderboard work after it's posted
+const LeaderboardPageTtl = time.Minute * 15
 
-	level, err := getLevelOpt(ic.ApplicationCommandData().Options, "level")
+func HandleLeaderboard(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	scope, err := getScopeOpt(ic.ApplicationCommandData().Options, "scope")
 	if err != nil {
 		handleInteractionError(ctx, state.Dg, ic, err)
 		return
 	}
-	game, _, ok := handleGetGame(ctx, state, ic)
-	if !ok {
+	private, err := getBoolOpt(ic.ApplicationCommandData().Options, "private")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
 		return
 	}
 
-	interactionRespond(state.Dg, ic.Interaction, createStringResponse("Analyzing... Wait a second..."))
-
-	respCh := state.Sh.FindRankedMoves(game, LevelToDepth(level))
-	select {
-	case resp := <-respCh:
-		if resp.Err != nil {
-			interactionResponseEdit(state.Dg, ic.Interaction, createEmbedTextEdit("Failed to retrieve analysis data from engine."))
+	// without a scope the leaderboard shows the same ladder as /stats
+	guildID := statsGuildID(ctx)
+	switch scope {
+	case GlobalScope:
+		guildID = ""
+	case ServerScope:
+		if ic.GuildID == "" {
+			interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse("The server leaderboard can only be viewed in a server."))
 			return
 		}
-		embed := createAnalysisEmbed(game, level)
-		img := state.Renderer.DrawBoardAnalysis(game.Board, resp.Moves)
-		interactionResponseEdit(state.Dg, ic.Interaction, createEmbedEdit(embed, img))
-	case <-ctx.Done():
-		slog.Warn("client timed out while waiting for an analysis response", "trace", trace, "err", ctx.Err())
-		interactionResponseEdit(state.Dg, ic.Interaction, createStringEdit("Timed out while waiting for a response."))
+		guildID = ic.GuildID
 	}
-	return
+
+	embed, components, err := readLeaderboardPage(ctx, state, guildID, 0, time.Now())
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	interactionRespond(state.Dg, ic.Interaction, createComponentResponse(embed, nil, components, privateFlags(private)))
 }
 
-func HandleSimulate(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
-	ctx, cancel := context.WithTimeout(ctx, time.Hour*1) // a simulation can stay paused for up to an hour
-	defer cancel()
+func HandleLeaderboardPageComponent(ctx context.Context, state *State, ic *discordgo.InteractionCreate, key string) {
+	page, createdTime, guildID := parseLeaderboardKey(key)
+	if time.Since(createdTime) > LeaderboardPageTtl {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse("This leaderboard has expired, use /leaderboard to see the latest one."))
+		return
+	}
 
-	// extract simulation inputs and send the initial simulation response
-	cmd := ic.ApplicationCommandData()
+	embed, components, err := readLeaderboardPage(ctx, state, guildID, page, createdTime)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	interactionRespond(state.Dg, ic.Interaction, createComponentUpdate(embed, nil, components))
+}
 
-	var whiteLevel uint64
-	var blackLevel uint64
-	var delay time.Duration
+// readLeaderboardPage reads a page of the server leaderboard of the guild, or the global ladder if the guild ID is
+// empty. The page is clamped to the pages that exist now as players may have joined or left since it was posted
+func readLeaderboardPage(ctx context.Context, state *State, guildID string, page int, createdTime time.Time) (*discordgo.MessageEmbed, []discordgo.MessageComponent, error) {
+	// a guild rating games on its own ladder ranks that ladder
+	ladder := guildID != "" && statsGuildID(ctx) == guildID
+
+	var count int
 	var err error
+	if guildID == "" {
+		count, err = CountStatsRows(ctx, state.Db, "")
+	} else {
+		count, err = CountStatsRowsByGuild(ctx, state.Db, guildID, ladder)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	pageCount := max((count+LeaderboardSize-1)/LeaderboardSize, 1)
+	page = min(max(page, 0), pageCount-1)
 
-	if whiteLevel, err = getLevelOpt(cmd.Options, "white-level"); err != nil {
+	var stats []Stats
+	if guildID == "" {
+		stats, err = ReadTopStats(ctx, state.Db, state.UserCache, "", LeaderboardSize, page*LeaderboardSize)
+	} else {
+		stats, err = ReadTopStatsByGuild(ctx, state.Db, state.UserCache, guildID, ladder, LeaderboardSize, page*LeaderboardSize)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	embed := brandEmbed(ctx, createLeaderboardEmbed(stats, page, pageCount))
+	return embed, createLeaderboardActionRow(guildID, page, pageCount, createdTime), nil
+}
+
+func HandleFastest(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	wins, err := ReadFastestWins(ctx, state.Db, state.UserCache, LeaderboardSize)
+	if err != nil {
 		handleInteractionError(ctx, state.Dg, ic, err)
 		return
 	}
-	if blackLevel, err = getLevelOpt(cmd.Options, "black-level"); err != nil {
+
+	embed := brandEmbed(ctx, createFastestEmbed(wins))
+	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, nil))
+}
+
+func HandleTopBot(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	performances, err := ReadBotPerformance(ctx, state.Db)
+	if err != nil {
 		handleInteractionError(ctx, state.Dg, ic, err)
 		return
 	}
-	if delay, err = getDelayOpt(cmd.Options, "delay"); err != nil {
-		handleInteractionError(ctx, state.Dg, ic, err)
+
+	embed := brandEmbed(ctx, createBotPerformanceEmbed(performances))
+	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, nil))
+}
+
+// ClearPrefixValue is the prefix that removes the title prefix, discord doesn't allow an option to be an empty string
+const ClearPrefixValue = "none"
+
+// HandleBranding updates the options that were given, the rest of the branding is kept
+func HandleBranding(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	if ic.GuildID == "" {
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("Branding can only be set in a server."))
 		return
 	}
 
-	initialGame := OthelloGame{
-		WhitePlayer: MakeBotPlayer(whiteLevel),
-		BlackPlayer: MakeBotPlayer(blackLevel),
-		Board:       MakeInitialBoard(),
+	cmd := ic.ApplicationCommandData()
+	cfg := state.GuildCache.GetConfig(ctx, ic.GuildID)
+
+	if opt := cmd.GetOption("prefix"); opt != nil {
+		cfg.TitlePrefix = opt.StringValue()
+		if strings.EqualFold(cfg.TitlePrefix, ClearPrefixValue) {
+			cfg.TitlePrefix = ""
+		}
+	}
+	if opt := cmd.GetOption("color"); opt != nil {
+		color, err := ParseColor(opt.StringValue())
+		if err != nil {
+			handleInteractionError(ctx, state.Dg, ic, OptionError{Name: "color", InvalidValue: opt.StringValue(), ExpectedValue: ExpectedColorValue})
+			return
+		}
+		cfg.Color = color
 	}
-	embed := createSimulationStartEmbed(initialGame)
-	img := state.Renderer.DrawBoard(initialGame.Board)
 
-	simulationID := uuid.New().String()
+	if err := state.GuildCache.SetConfig(ctx, cfg); err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
 
-	response := createComponentResponse(embed, img, createSimulationActionRow(simulationID, false))
-	interactionRespond(state.Dg, ic.Interaction, response)
+	ctx = context.WithValue(ctx, GuildConfigKey, cfg)
+	embed := brandEmbed(ctx, createBrandingEmbed(cfg))
+	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, nil))
+}
 
-	// run the simulation against the engine and add it to the cache (so it can be paused/resumed)
-	simState := &SimState{Cancel: cancel}
-	simChan := make(chan SimStep, MaxSimCount) // give this a size so we don't block on send
+func HandleGuildStats(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	if ic.GuildID == "" {
+		interactionRespond(state.Dg, ic.Interaction, createStringResponse("Server stats can only be set in a server."))
+		return
+	}
 
-	state.SimCache.Set(simulationID, simState, SimulationTtl)
+	enabled, err := getBoolOpt(ic.ApplicationCommandData().Options, "enabled")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	cfg := state.GuildCache.GetConfig(ctx, ic.GuildID)
+	cfg.GuildStats = enabled
+	if err := state.GuildCache.SetConfig(ctx, cfg); err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
 
-	go GenerateSimulation(ctx, state.Sh, initialGame, simChan)
-	RecvSimulation(ctx, state, ic, delay, simState, simChan)
+	msg := "Games in this server are now rated on the global ladder."
+	if enabled {
+		msg = "Games in this server are now rated on a ladder for this server only."
+	}
+	interactionRespond(state.Dg, ic.Interaction, createStringResponse(msg))
 }
 
-func RecvSimulation(ctx context.Context, state *State, ic *discordgo.InteractionCreate, delay time.Duration, simState *SimState, simChan chan SimStep) {
-	trace := ctx.Value(TraceKey)
+var SettingsSubCmds = []string{"evalbar", "showmoves", "moveorder"}
 
-	ticker := time.NewTicker(delay)
-	for {
-		select {
-		case <-ctx.Done():
-			slog.Info("simulation receiver stopped", "trace", trace)
-			interactionResponseEdit(state.Dg, ic.Interaction, &discordgo.WebhookEdit{Components: &[]discordgo.MessageComponent{}})
-			return
-		case <-ticker.C:
-			if simState.IsPaused.Load() { // paused? check again once the ticker executes
-				continue
-			}
-			step, ok := <-simChan
-			if !ok {
-				slog.Info("simulation receiver complete", "trace", trace)
-				return
-			}
-			interactionResponseEdit(state.Dg, ic.Interaction, createStepEdit(state.Renderer, step))
-		}
+func HandleSettings(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	if ic.Interaction.Member == nil {
+		handleInteractionError(ctx, state.Dg, ic, ErrUserNotProvided)
+		return
 	}
-}
+	playerID := ic.Interaction.Member.User.ID
 
-func HandleStats(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
-	var user discordgo.User
-	var err error
+	settings, err := GetUserSettings(ctx, state.Db, playerID)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
 
-	userOpt := ic.ApplicationCommandData().GetOption("player")
-	if userOpt != nil {
-		if user, err = state.UserCache.GetUser(ctx, userOpt.Value.(string)); err != nil {
+	subCmd, options := getSubcommand(ic)
+	var msg string
+
+	switch subCmd {
+	case "evalbar":
+		if settings.EvalBar, err = getBoolOpt(options, "enabled"); err != nil {
 			handleInteractionError(ctx, state.Dg, ic, err)
 			return
 		}
-	} else if ic.Interaction.Member != nil {
-		user = *ic.Interaction.Member.User
+		msg = "Evaluation bars are now disabled for your bot games."
+		if settings.EvalBar {
+			msg = "Evaluation bars are now enabled for your bot games."
+		}
+	case "showmoves":
+		if settings.ShowMoves, err = getBoolOpt(options, "enabled"); err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
+			return
+		}
+		msg = "Legal moves are now hidden in your bot games."
+		if settings.ShowMoves {
+			msg = "Legal moves are now shown in your bot games."
+		}
+	case "moveorder":
+		if settings.MoveOrder, err = getMoveOrderOpt(options, "order"); err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
+			return
+		}
+		msg = fmt.Sprintf("Move suggestions are now in %s order.", settings.MoveOrder)
+	default:
+		handleInteractionError(ctx, state.Dg, ic, SubCmdError{Name: subCmd, ExpectedValues: SettingsSubCmds})
+		return
 	}
 
-	var stats Stats
-	if stats, err = ReadStats(ctx, state.Db, state.UserCache, user.ID); err != nil {
+	if err := SetUserSettings(ctx, state.Db, settings); err != nil {
 		handleInteractionError(ctx, state.Dg, ic, err)
 		return
 	}
+	interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(msg))
+}
 
-	embed := createStatsEmbed(user, stats)
-	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, nil))
+var SeedSubCmds = []string{"add", "purge"}
+
+const NotAdminMsg = "Only administrators can use this command."
+
+func isAdmin(ic *discordgo.InteractionCreate) bool {
+	return ic.Member != nil && ic.Member.Permissions&discordgo.PermissionAdministrator != 0
 }
 
-const LeaderboardSize = 50
+func HandleSeed(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(NotAdminMsg))
+		return
+	}
 
-func HandleLeaderboard(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
-	stats, err := ReadTopStats(ctx, state.Db, state.UserCache, LeaderboardSize)
-	if err != nil {
-		handleInteractionError(ctx, state.Dg, ic, err)
+	subCmd, options := getSubcommand(ic)
+	switch subCmd {
+	case "add":
+		count, err := getSeedCountOpt(options, "count")
+		if err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
+			return
+		}
+		if err := SeedRandomStats(ctx, state.Db, count); err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
+			return
+		}
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(fmt.Sprintf("Seeded %d synthetic stats rows.", count)))
+	case "purge":
+		count, err := PurgeSeededStats(ctx, state.Db)
+		if err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
+			return
+		}
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(fmt.Sprintf("Purged %d synthetic stats rows.", count)))
+	default:
+		handleInteractionError(ctx, state.Dg, ic, SubCmdError{Name: subCmd, ExpectedValues: SeedSubCmds})
+	}
+}
+
+var StatsAdminSubCmds = []string{"reset"}
+
+const NoGuildLadderMsg = "Stats can only be reset on this server's own ladder, enable it with /guildstats first."
+
+func HandleStatsAdmin(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	if !isAdmin(ic) {
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(NotAdminMsg))
 		return
 	}
 
-	embed := createLeaderboardEmbed(stats)
-	interactionRespond(state.Dg, ic.Interaction, createEmbedResponse(embed, nil))
+	subCmd, options := getSubcommand(ic)
+	switch subCmd {
+	case "reset":
+		// an administrator of one server can't reset a player's global stats
+		guildID := statsGuildID(ctx)
+		if guildID == "" {
+			interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(NoGuildLadderMsg))
+			return
+		}
+		player, err := getPlayerOpt(ctx, &state.UserCache, options, "player")
+		if err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
+			return
+		}
+		if err := ResetStats(ctx, state.Db, StatsKey(guildID, player.ID)); err != nil {
+			handleInteractionError(ctx, state.Dg, ic, err)
+			return
+		}
+		interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(fmt.Sprintf("Reset the stats of %s.", player.Name)))
+	default:
+		handleInteractionError(ctx, state.Dg, ic, SubCmdError{Name: subCmd, ExpectedValues: StatsAdminSubCmds})
+	}
 }
 
 func HandlePauseComponent(state *State, ic *discordgo.InteractionCreate, simulationID string) {
@@ -563,13 +2001,75 @@ func channelMessageSendComplex(dg *discordgo.Session, channelID string, data *di
 	}
 }
 
-func interactionRespond(dg *discordgo.Session, i *discordgo.Interaction, r *discordgo.InteractionResponse) {
-	if err := dg.InteractionRespond(i, r); err != nil {
+// InteractionTokenTtl is how long discord accepts follow-ups to an interaction, less a margin for slow requests
+const InteractionTokenTtl = time.Minute*15 - time.Second*30
+
+type FollowupSender interface {
+	FollowupMessageCreate(interaction *discordgo.Interaction, wait bool, data *discordgo.WebhookParams, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+func interactionTokenExpired(i *discordgo.Interaction) bool {
+	createdTime, err := discordgo.SnowflakeTimestamp(i.ID)
+	return err != nil || time.Since(createdTime) > InteractionTokenTtl
+}
+
+// followupSend sends a message as a follow-up to the interaction so it's threaded with the response, once the
+// interaction token has expired the message is sent to the channel instead
+func followupSend(dg FollowupSender, i *discordgo.Interaction, data *discordgo.MessageSend) {
+	if interactionTokenExpired(i) {
+		if _, err := dg.ChannelMessageSendComplex(i.ChannelID, data); err != nil {
+			slog.Error("failed to send message complex", "err", err)
+		}
+		return
+	}
+
+	params := &discordgo.WebhookParams{Content: data.Content, Embeds: data.Embeds, Files: data.Files, Components: data.Components}
+	if _, err := dg.FollowupMessageCreate(i, false, params); err != nil {
+		slog.Error("failed to send follow-up message", "err", err)
+	}
+}
+
+type InteractionResponder interface {
+	InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error
+}
+
+// interactionRespond sends the response, a response with files that fails to send is retried once without them so the
+// user still gets a response when an upload is rejected
+func interactionRespond(dg InteractionResponder, i *discordgo.Interaction, r *discordgo.InteractionResponse) {
+	err := dg.InteractionRespond(i, r)
+	if err == nil {
+		return
+	}
+	if r.Data == nil || len(r.Data.Files) == 0 {
 		slog.Error("failed to send interaction response", "err", err)
+		return
+	}
+
+	slog.Warn("failed to send interaction response with files, retrying as text only", "err", err)
+	if err := dg.InteractionRespond(i, withoutFiles(r)); err != nil {
+		slog.Error("failed to send text only interaction response", "err", err)
+	}
+}
+
+// withoutFiles copies a response without its files, embed images referencing the files are removed as well
+func withoutFiles(r *discordgo.InteractionResponse) *discordgo.InteractionResponse {
+	data := *r.Data
+	data.Files = nil
+	data.Embeds = nil
+	for _, embed := range r.Data.Embeds {
+		e := *embed
+		e.Image = nil
+		data.Embeds = append(data.Embeds, &e)
 	}
+	return &discordgo.InteractionResponse{Type: r.Type, Data: &data}
 }
 
-func interactionResponseEdit(dg *discordgo.Session, i *discordgo.Interaction, e *discordgo.WebhookEdit) {
+type ResponseEditor interface {
+	InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+func interactionResponseEdit(dg ResponseEditor, i *discordgo.Interaction, e *discordgo.WebhookEdit) {
 	if _, err := dg.InteractionResponseEdit(i, e); err != nil {
 		slog.Error("failed to send interaction response edit", "err", err)
 	}
@@ -577,6 +2077,16 @@ func interactionResponseEdit(dg *discordgo.Session, i *discordgo.Interaction, e
 
 const InternalServerErrorMsg = "An unexpected error occurred"
 
+// recoverPanic must be deferred directly, it converts a panic into an error so one handler cannot take down the bot
+func recoverPanic(ctx context.Context, onPanic func(err error)) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	slog.Error("recovered from panic", "trace", ctx.Value(TraceKey), "panic", r, "stack", string(debug.Stack()))
+	onPanic(fmt.Errorf("recovered from panic: %v", r))
+}
+
 func handleInteractionError(ctx context.Context, dg *discordgo.Session, ic *discordgo.InteractionCreate, err error) {
 	trace := ctx.Value(TraceKey)
 	slog.Error("error when handling command", "trace", trace, "err", err)
@@ -584,7 +2094,7 @@ func handleInteractionError(ctx context.Context, dg *discordgo.Session, ic *disc
 	content := InternalServerErrorMsg
 
 	switch err.(type) {
-	case *SubCmdError, *OptionError:
+	case SubCmdError, OptionError, *SubCmdError, *OptionError:
 		content = err.Error()
 	}
 
@@ -598,3 +2108,64 @@ func handleInteractionError(ctx context.Context, dg *discordgo.Session, ic *disc
 		slog.Error("failed to respond interaction error", "err", err)
 	}
 }
+
+func HandleOrientation(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	if ic.Interaction.Member == nil {
+		handleInteractionError(ctx, state.Dg, ic, ErrUserNotProvided)
+		return
+	}
+	playerID := ic.Interaction.Member.User.ID
+
+	flip, err := getOrientationOpt(ic.ApplicationCommandData().Options, "side")
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	settings, err := GetUserSettings(ctx, state.Db, playerID)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	settings.FlipBoard = flip
+	if err := SetUserSettings(ctx, state.Db, settings); err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+
+	side := BlackSide
+	if flip {
+		side = WhiteSide
+	}
+	interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(fmt.Sprintf("Your boards are now drawn from %s's side.", side)))
+}
+
+func HandleTheme(ctx context.Context, state *State, ic *discordgo.InteractionCreate) {
+	if ic.Interaction.Member == nil {
+		handleInteractionError(ctx, state.Dg, ic, ErrUserNotProvided)
+		return
+	}
+	playerID := ic.Interaction.Member.User.ID
+
+	var name string
+	if opt := ic.ApplicationCommandData().GetOption("name"); opt != nil {
+		name = opt.StringValue()
+	}
+	theme, ok := FindTheme(name)
+	if !ok {
+		handleInteractionError(ctx, state.Dg, ic, OptionError{Name: "name", InvalidValue: name, ExpectedValue: ExpectedThemeValue})
+		return
+	}
+
+	settings, err := GetUserSettings(ctx, state.Db, playerID)
+	if err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	settings.Theme = theme.Name
+	if err := SetUserSettings(ctx, state.Db, settings); err != nil {
+		handleInteractionError(ctx, state.Dg, ic, err)
+		return
+	}
+	interactionRespond(state.Dg, ic.Interaction, createEphemeralStringResponse(fmt.Sprintf("Your boards now use the %s theme.", theme.Name)))
+}
@@ -0,0 +1,688 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image/png"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverPanic(t *testing.T) {
+	ctx := context.WithValue(context.Background(), TraceKey, "test-recover-panic")
+
+	var recovered error
+	handle := func() {
+		defer recoverPanic(ctx, func(err error) {
+			recovered = err
+		})
+		// storing a game between two bots is one of the paths that panics inside a handler
+		gameParticipants(MakeBotPlayer(1), MakeBotPlayer(2))
+	}
+
+	assert.NotPanics(t, handle)
+	assert.ErrorContains(t, recovered, "recovered from panic")
+
+	// the handler should be callable again after recovering
+	recovered = nil
+	assert.NotPanics(t, handle)
+	assert.Error(t, recovered)
+}
+
+type MockResponder struct {
+	failures  int
+	responses []*discordgo.InteractionResponse
+}
+
+func (m *MockResponder) InteractionRespond(_ *discordgo.Interaction, resp *discordgo.InteractionResponse, _ ...discordgo.RequestOption) error {
+	m.responses = append(m.responses, resp)
+	if len(m.responses) <= m.failures {
+		return errors.New("request entity too large")
+	}
+	return nil
+}
+
+func TestParseGameTtl(t *testing.T) {
+	assert.Equal(t, GameStoreTtl, parseGameTtl(""))
+	assert.Equal(t, time.Hour*12, parseGameTtl("12h"))
+	assert.Equal(t, GameStoreTtl, parseGameTtl("tomorrow"))
+	assert.Equal(t, GameStoreTtl, parseGameTtl("-1h"))
+
+	ctx := context.WithValue(context.Background(), GameTtlKey, time.Hour*12)
+	assert.WithinDuration(t, time.Now().Add(time.Hour*12), gameExpireTime(ctx), time.Second)
+}
+
+func TestParseChannelGameLimit(t *testing.T) {
+	assert.Equal(t, DefaultChannelGameLimit, parseChannelGameLimit(""))
+	assert.Equal(t, 3, parseChannelGameLimit("3"))
+	assert.Equal(t, DefaultChannelGameLimit, parseChannelGameLimit("many"))
+	assert.Equal(t, DefaultChannelGameLimit, parseChannelGameLimit("0"))
+}
+
+func TestInteractionRespond_Fallback(t *testing.T) {
+	embed := &discordgo.MessageEmbed{Title: "Game Started!"}
+	resp := createEmbedResponse(embed, MakeRenderCache().DrawBoard(MakeInitialBoard()))
+
+	mock := &MockResponder{failures: 1}
+	interactionRespond(mock, &discordgo.Interaction{}, resp)
+
+	assert.Len(t, mock.responses, 2)
+	fallback := mock.responses[1]
+	assert.Empty(t, fallback.Data.Files)
+	assert.Equal(t, "Game Started!", fallback.Data.Embeds[0].Title)
+	assert.Nil(t, fallback.Data.Embeds[0].Image)
+
+	// a text only response isn't retried
+	mock = &MockResponder{failures: 1}
+	interactionRespond(mock, &discordgo.Interaction{}, createStringResponse("text"))
+	assert.Len(t, mock.responses, 1)
+}
+
+type MockFollowupSender struct {
+	followups []*discordgo.WebhookParams
+	sends     []*discordgo.MessageSend
+}
+
+func (m *MockFollowupSender) FollowupMessageCreate(_ *discordgo.Interaction, _ bool, data *discordgo.WebhookParams, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	m.followups = append(m.followups, data)
+	return &discordgo.Message{}, nil
+}
+
+func (m *MockFollowupSender) ChannelMessageSendComplex(_ string, data *discordgo.MessageSend, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	m.sends = append(m.sends, data)
+	return &discordgo.Message{}, nil
+}
+
+// MockRequest is a request the discord session made, the payload of a multipart request is split from its files
+type MockRequest struct {
+	Method  string
+	Path    string
+	Payload []byte
+	Files   [][]byte
+}
+
+// MockTransport records the requests of a discord session and replies to each with an empty object
+type MockTransport struct {
+	mu       sync.Mutex
+	requests []MockRequest
+}
+
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	mr := MockRequest{Method: req.Method, Path: req.URL.Path}
+
+	body, err := io.ReadAll(req.Body)
+	if err != nil {
+		return nil, err
+	}
+	mediaType, params, _ := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if strings.HasPrefix(mediaType, "multipart/") {
+		reader := multipart.NewReader(bytes.NewReader(body), params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return nil, err
+			}
+			if part.FormName() == "payload_json" {
+				mr.Payload = data
+			} else {
+				mr.Files = append(mr.Files, data)
+			}
+		}
+	} else {
+		mr.Payload = body
+	}
+
+	m.mu.Lock()
+	m.requests = append(m.requests, mr)
+	m.mu.Unlock()
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader("{}")),
+		Request:    req,
+	}, nil
+}
+
+// Requests returns the recorded requests with a path containing the segment
+func (m *MockTransport) Requests(segment string) []MockRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var requests []MockRequest
+	for _, mr := range m.requests {
+		if strings.Contains(mr.Path, segment) {
+			requests = append(requests, mr)
+		}
+	}
+	return requests
+}
+
+// MockMessage is the part of a message sent by the session that tests check, discordgo can't decode components
+type MockMessage struct {
+	Content string                    `json:"content"`
+	Flags   discordgo.MessageFlags    `json:"flags"`
+	Embeds  []*discordgo.MessageEmbed `json:"embeds"`
+}
+
+type MockResponse struct {
+	Type discordgo.InteractionResponseType `json:"type"`
+	Data MockMessage                       `json:"data"`
+}
+
+// Responses decodes the interaction responses sent by the session
+func (m *MockTransport) Responses(t *testing.T) []MockResponse {
+	var responses []MockResponse
+	for _, mr := range m.Requests("/callback") {
+		var resp MockResponse
+		if err := json.Unmarshal(mr.Payload, &resp); err != nil {
+			t.Fatalf("failed to decode interaction response: %v", err)
+		}
+		responses = append(responses, resp)
+	}
+	return responses
+}
+
+// Followups decodes the follow-up messages sent by the session
+func (m *MockTransport) Followups(t *testing.T) []MockMessage {
+	var followups []MockMessage
+	for _, mr := range m.Requests("/webhooks/") {
+		var msg MockMessage
+		if err := json.Unmarshal(mr.Payload, &msg); err != nil {
+			t.Fatalf("failed to decode followup: %v", err)
+		}
+		followups = append(followups, msg)
+	}
+	return followups
+}
+
+// createTestState creates the state for a handler backed by a test db and a discord session that never reaches discord
+func createTestState(sh EngineShell) (*State, *MockTransport, func()) {
+	db, cleanup := createTestDB()
+
+	transport := &MockTransport{}
+	dg, err := discordgo.New("Bot test")
+	if err != nil {
+		panic(err)
+	}
+	dg.Client = &http.Client{Transport: transport}
+
+	state := MakeState(db, dg, sh)
+	return &state, transport, cleanup
+}
+
+// interactionID creates a snowflake for an interaction created at the time
+func interactionID(createdTime time.Time) string {
+	return strconv.FormatInt((createdTime.UnixMilli()-1420070400000)<<22, 10)
+}
+
+func TestFollowupSend(t *testing.T) {
+	send := createStringSend("Bot moved")
+
+	mock := &MockFollowupSender{}
+	followupSend(mock, &discordgo.Interaction{ID: interactionID(time.Now())}, send)
+	assert.Len(t, mock.followups, 1)
+	assert.Equal(t, "Bot moved", mock.followups[0].Content)
+	assert.Empty(t, mock.sends)
+
+	// the interaction token has expired so the message falls back to the channel
+	mock = &MockFollowupSender{}
+	followupSend(mock, &discordgo.Interaction{ID: interactionID(time.Now().Add(-time.Minute * 20))}, send)
+	assert.Empty(t, mock.followups)
+	assert.Equal(t, []*discordgo.MessageSend{send}, mock.sends)
+}
+
+type MockSimulationEditor struct {
+	responseEdits []*discordgo.WebhookEdit
+	sends         []*discordgo.MessageSend
+	edits         []*discordgo.MessageEdit
+}
+
+func (m *MockSimulationEditor) InteractionResponseEdit(_ *discordgo.Interaction, e *discordgo.WebhookEdit, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	m.responseEdits = append(m.responseEdits, e)
+	return &discordgo.Message{}, nil
+}
+
+func (m *MockSimulationEditor) ChannelMessageSendComplex(_ string, data *discordgo.MessageSend, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	m.sends = append(m.sends, data)
+	return &discordgo.Message{ID: "message1"}, nil
+}
+
+func (m *MockSimulationEditor) ChannelMessageEditComplex(e *discordgo.MessageEdit, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	m.edits = append(m.edits, e)
+	return &discordgo.Message{}, nil
+}
+
+func TestSimulationMessage_Edit(t *testing.T) {
+	stepEdit := createEmbedTextEdit("Step")
+	stopEdit := &discordgo.WebhookEdit{Components: &[]discordgo.MessageComponent{}}
+
+	mock := &MockSimulationEditor{}
+	sm := &SimulationMessage{Interaction: &discordgo.Interaction{ID: interactionID(time.Now())}, SimulationID: "sim1"}
+	sm.Edit(mock, stepEdit)
+	assert.Equal(t, []*discordgo.WebhookEdit{stepEdit}, mock.responseEdits)
+	assert.Empty(t, mock.sends)
+
+	// the interaction token has expired so the simulation continues in a channel message with its buttons
+	mock = &MockSimulationEditor{}
+	sm = &SimulationMessage{Interaction: &discordgo.Interaction{ID: interactionID(time.Now().Add(-time.Minute * 20)), ChannelID: "channel1"}, SimulationID: "sim1"}
+	sm.Edit(mock, stopEdit)
+	assert.Empty(t, mock.sends)
+
+	sm.Edit(mock, stepEdit)
+	sm.Edit(mock, stepEdit)
+	sm.Edit(mock, stopEdit)
+	assert.Empty(t, mock.responseEdits)
+	if assert.Len(t, mock.sends, 1) {
+		assert.Equal(t, "Step", mock.sends[0].Content)
+		assert.Equal(t, createSimulationActionRow("sim1", false), mock.sends[0].Components)
+	}
+	if assert.Len(t, mock.edits, 2) {
+		assert.Equal(t, "message1", mock.edits[0].ID)
+		assert.Equal(t, "channel1", mock.edits[0].Channel)
+		assert.Equal(t, stepEdit.Content, mock.edits[0].Content)
+		assert.Equal(t, stopEdit.Components, mock.edits[1].Components)
+	}
+}
+
+func TestAutocompleteMoves(t *testing.T) {
+	player1 := Player{ID: "id1", Name: "Player1"}
+	player2 := Player{ID: "id2", Name: "Player2"}
+	settings := DefaultUserSettings("id1")
+
+	game := OthelloGame{BlackPlayer: player1, WhitePlayer: player2, Board: MakeInitialBoard()}
+	assert.Equal(t, game.Board.FindCurrentMoves(), autocompleteMoves(game, "id1", settings, nil))
+	// the legal moves belong to black, so white gets no suggestions
+	assert.Empty(t, autocompleteMoves(game, "id2", settings, nil))
+
+	game.MakeMove(ParseTile("d3"))
+	assert.Empty(t, autocompleteMoves(game, "id1", settings, nil))
+	assert.Equal(t, game.Board.FindCurrentMoves(), autocompleteMoves(game, "id2", settings, nil))
+
+	// a game against yourself always suggests the moves
+	selfGame := OthelloGame{BlackPlayer: player1, WhitePlayer: player1, Board: MakeInitialBoard()}
+	selfGame.MakeMove(ParseTile("d3"))
+	assert.Equal(t, selfGame.Board.FindCurrentMoves(), autocompleteMoves(selfGame, "id1", settings, nil))
+}
+
+// MockPassShell replies to every search with a pass
+type MockPassShell struct{}
+
+func (mock *MockPassShell) FindBestMove(_ OthelloGame, _ uint64) chan MoveResp {
+	ch := make(chan MoveResp, 1)
+	ch <- MoveResp{Err: ErrEnginePass}
+	return ch
+}
+
+func (mock *MockPassShell) FindRankedMoves(game OthelloGame, depth uint64) chan MoveResp {
+	return mock.FindBestMove(game, depth)
+}
+
+func TestPlayBotMoves_EnginePass(t *testing.T) {
+	state, transport, cleanup := createTestState(&MockPassShell{})
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-play-bot-moves-engine-pass")
+
+	player := Player{ID: "id1", Name: "Player1"}
+	game, err := CreateBotGameTx(ctx, state.Db, player, 3, true)
+	if err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+
+	// a pass from the engine while the bot has moves isn't played, and the player's move isn't stored
+	move := ParseTile("d3")
+	game.MakeMove(move)
+	ic := &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{ID: interactionID(time.Now()), Token: "token"}}
+	playBotMoves(ctx, state, ic, game, move, false, DefaultUserSettings(player.ID), state.Renderer)
+
+	stored, err := GetGame(ctx, state.Db, player.ID)
+	if err != nil {
+		t.Fatalf("failed to get game: %v", err)
+	}
+	assert.Empty(t, stored.MoveList)
+	assert.True(t, stored.Board.IsBlackMove)
+
+	followups := transport.Followups(t)
+	if assert.Len(t, followups, 1) {
+		assert.Equal(t, InternalServerErrorMsg, followups[0].Content)
+	}
+}
+
+func TestHandleBotChallenge_BotOpens(t *testing.T) {
+	board := MakeInitialBoard()
+	opening := board.FindCurrentMoves()[0]
+
+	type Test struct {
+		sh       EngineShell
+		expMoves []Move
+	}
+	tests := []Test{
+		{sh: &MockEngineShell{}, expMoves: []Move{{Tile: opening}}},
+		// the engine failed to open, so no game is left waiting on the bot
+		{sh: &MockPassShell{}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			state, transport, cleanup := createTestState(test.sh)
+			defer cleanup()
+
+			ctx := context.WithValue(context.Background(), TraceKey, "test-handle-bot-challenge-bot-opens")
+
+			state.HandeInteractionCreate(nil, commandInteraction("challenge", "", &discordgo.ApplicationCommandInteractionDataOption{
+				Name:    "bot",
+				Type:    discordgo.ApplicationCommandOptionSubCommand,
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{stringOption("color", WhiteSide)},
+			}))
+			assert.Len(t, transport.Responses(t), 1)
+			assert.Len(t, transport.Followups(t), 1)
+
+			game, err := GetGame(ctx, state.Db, "id1")
+			if test.expMoves == nil {
+				assert.ErrorIs(t, err, ErrGameNotFound)
+				return
+			}
+			if err != nil {
+				t.Fatalf("failed to get game: %v", err)
+			}
+			assert.Equal(t, test.expMoves, game.MoveList)
+			assert.False(t, game.CurrentPlayer().IsBot())
+		})
+	}
+}
+
+// componentInteraction creates the interaction for a player clicking the component
+func componentInteraction(customID string, playerID string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:   discordgo.InteractionMessageComponent,
+		Data:   discordgo.MessageComponentInteractionData{CustomID: customID},
+		Member: &discordgo.Member{User: &discordgo.User{ID: playerID}},
+	}}
+}
+
+func TestHandleRefreshComponent(t *testing.T) {
+	state, transport, cleanup := createTestState(&NTestShell{})
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-handle-refresh-component")
+
+	game, err := CreateGameTx(ctx, state.Db, Player{ID: "id1", Name: "Player1"}, Player{ID: "id2", Name: "Player2"})
+	if err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	row := createViewActionRow(game, "id1")[0].(discordgo.ActionsRow)
+	refreshID := row.Components[0].(discordgo.Button).CustomID
+
+	// the opponent moves after the board was viewed, so the refresh shows the board after the move
+	game, _, err = MakeMoveAgainstHuman(ctx, state.Db, "id1", ParseTile("d3"))
+	if err != nil {
+		t.Fatalf("failed to make move: %v", err)
+	}
+	state.HandeInteractionCreate(nil, componentInteraction(refreshID, "id1"))
+
+	var expected bytes.Buffer
+	if err := png.Encode(&expected, state.Renderer.DrawBoardMoves(game.Board, game.Board.FindCurrentMoves())); err != nil {
+		t.Fatalf("failed to encode image: %v", err)
+	}
+
+	requests := transport.Requests("/callback")
+	if assert.Len(t, requests, 1) && assert.Len(t, requests[0].Files, 1) {
+		assert.Equal(t, expected.Bytes(), requests[0].Files[0])
+	}
+	responses := transport.Responses(t)
+	if assert.Len(t, responses, 1) {
+		assert.Equal(t, discordgo.InteractionResponseUpdateMessage, responses[0].Type)
+		assert.Equal(t, createGameEmbed(game).Title, responses[0].Data.Embeds[0].Title)
+	}
+}
+
+func TestHandleComponent_Expired(t *testing.T) {
+	state, transport, cleanup := createTestState(&NTestShell{})
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-handle-component-expired")
+
+	game, err := CreateGameTx(ctx, state.Db, Player{ID: "id1", Name: "Player1"}, Player{ID: "id2", Name: "Player2"})
+	if err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	refreshRow := createViewActionRow(game, "id1")[0].(discordgo.ActionsRow)
+	rematchRow := createRematchActionRow(game, time.Now().Add(-RematchTtl-time.Minute))[0].(discordgo.ActionsRow)
+	takebackRow := createTakebackActionRow(game, "id1")[0].(discordgo.ActionsRow)
+
+	// the game the components were created for no longer exists
+	if _, err := GameOverTx(ctx, state.Db, game, game.CreateForfeitResult("id1")); err != nil {
+		t.Fatalf("failed to end game: %v", err)
+	}
+
+	type Test struct {
+		customID string
+		playerID string
+	}
+	tests := []Test{
+		{customID: refreshRow.Components[0].(discordgo.Button).CustomID, playerID: "id1"},
+		{customID: rematchRow.Components[0].(discordgo.Button).CustomID, playerID: "id1"},
+		{customID: takebackRow.Components[0].(discordgo.Button).CustomID, playerID: "id2"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			state.HandeInteractionCreate(nil, componentInteraction(test.customID, test.playerID))
+
+			responses := transport.Responses(t)
+			if assert.Len(t, responses, i+1) {
+				resp := responses[i]
+				assert.Equal(t, ExpiredGameMsg, resp.Data.Content)
+				assert.Equal(t, discordgo.MessageFlagsEphemeral, resp.Data.Flags)
+			}
+		})
+	}
+}
+
+// commandInteraction creates the interaction for a player sending the command in the guild
+func commandInteraction(name string, guildID string, options ...*discordgo.ApplicationCommandInteractionDataOption) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		ID:      interactionID(time.Now()),
+		Type:    discordgo.InteractionApplicationCommand,
+		GuildID: guildID,
+		Data:    discordgo.ApplicationCommandInteractionData{Name: name, Options: options},
+		Member:  &discordgo.Member{User: &discordgo.User{ID: "id1"}},
+	}}
+}
+
+func stringOption(name string, value string) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionString, Value: value}
+}
+
+func TestHandleBranding(t *testing.T) {
+	state, _, cleanup := createTestState(&NTestShell{})
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-handle-branding")
+
+	if err := state.GuildCache.SetConfig(ctx, GuildConfig{GuildID: "guild1", Color: GreenEmbed, GuildStats: true}); err != nil {
+		t.Fatalf("failed to set config: %v", err)
+	}
+
+	type Test struct {
+		options   []*discordgo.ApplicationCommandInteractionDataOption
+		expConfig GuildConfig
+	}
+	tests := []Test{
+		{
+			options:   []*discordgo.ApplicationCommandInteractionDataOption{stringOption("prefix", "OC"), stringOption("color", "#ff0000")},
+			expConfig: GuildConfig{GuildID: "guild1", TitlePrefix: "OC", Color: 0xff0000, GuildStats: true},
+		},
+		{
+			// the options that aren't given keep their value
+			options:   []*discordgo.ApplicationCommandInteractionDataOption{stringOption("color", "#00ff00")},
+			expConfig: GuildConfig{GuildID: "guild1", TitlePrefix: "OC", Color: 0x00ff00, GuildStats: true},
+		},
+		{
+			options:   []*discordgo.ApplicationCommandInteractionDataOption{stringOption("prefix", ClearPrefixValue)},
+			expConfig: GuildConfig{GuildID: "guild1", Color: 0x00ff00, GuildStats: true},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			state.HandeInteractionCreate(nil, commandInteraction("branding", "guild1", test.options...))
+			assert.Equal(t, test.expConfig, state.GuildCache.GetConfig(ctx, "guild1"))
+		})
+	}
+}
+
+func TestCreateUserChallenge_Cooldown(t *testing.T) {
+	state, transport, cleanup := createTestState(&NTestShell{})
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-create-user-challenge-cooldown")
+
+	state.UserCache.Cache.Set("id2", discordgo.User{ID: "id2", Username: "Player2"}, UserCacheTTl)
+	challenge := commandInteraction("challenge", "", &discordgo.ApplicationCommandInteractionDataOption{
+		Name:    "user",
+		Type:    discordgo.ApplicationCommandOptionSubCommand,
+		Options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "opponent", Type: discordgo.ApplicationCommandOptionUser, Value: "id2"}},
+	})
+
+	// the opponent is already in a game so the challenge is rejected without starting the cooldown
+	game, err := CreateGameTx(ctx, state.Db, Player{ID: "id2", Name: "Player2"}, Player{ID: "id3", Name: "Player3"})
+	if err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	state.HandeInteractionCreate(nil, challenge)
+
+	responses := transport.Responses(t)
+	if assert.Len(t, responses, 1) {
+		assert.Equal(t, discordgo.MessageFlagsEphemeral, responses[0].Data.Flags)
+	}
+	_, ok := state.Cooldowns.Check("challenge,id1")
+	assert.True(t, ok)
+
+	if _, err := GameOverTx(ctx, state.Db, game, game.CreateForfeitResult("id2")); err != nil {
+		t.Fatalf("failed to end game: %v", err)
+	}
+	state.HandeInteractionCreate(nil, challenge)
+
+	_, ok = state.Cooldowns.Check("challenge,id1")
+	assert.False(t, ok)
+}
+
+func intOption(name string, value int) *discordgo.ApplicationCommandInteractionDataOption {
+	return &discordgo.ApplicationCommandInteractionDataOption{Name: name, Type: discordgo.ApplicationCommandOptionInteger, Value: float64(value)}
+}
+
+func TestHandleCommandCooldown(t *testing.T) {
+	state, _, cleanup := createTestState(&MockEngineShell{})
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-handle-command-cooldown")
+
+	type Test struct {
+		ic         *discordgo.InteractionCreate
+		setup      func()
+		expStarted bool
+	}
+	tests := []Test{
+		{
+			// a rejected simulation doesn't start the cooldown
+			ic: commandInteraction("simulate", "",
+				intOption("white-level", MaxBotLevel), intOption("black-level", MaxBotLevel), intOption("delay", MinDelay)),
+		},
+		{
+			// neither does an analysis without a game
+			ic: commandInteraction("analyze", ""),
+		},
+		{
+			ic: commandInteraction("analyze", ""),
+			setup: func() {
+				if _, err := CreateGameTx(ctx, state.Db, Player{ID: "id1", Name: "Player1"}, Player{ID: "id2", Name: "Player2"}); err != nil {
+					t.Fatalf("failed to create game: %v", err)
+				}
+			},
+			expStarted: true,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if test.setup != nil {
+				test.setup()
+			}
+			state.HandeInteractionCreate(nil, test.ic)
+
+			_, ok := state.Cooldowns.CheckCommand(test.ic.ApplicationCommandData().Name, "id1")
+			assert.Equal(t, test.expStarted, !ok)
+		})
+	}
+}
+
+func TestHandleStatsAdmin(t *testing.T) {
+	state, transport, cleanup := createTestState(&MockEngineShell{})
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-handle-stats-admin")
+
+	player2 := Player{ID: "id2", Name: "Player2"}
+	player3 := Player{ID: "id3", Name: "Player3"}
+	state.UserCache.Cache.Set("id2", discordgo.User{ID: "id2", Username: "Player2"}, UserCacheTTl)
+	for _, guildID := range []string{"", "guild1"} {
+		if _, err := UpdateStats(ctx, state.Db, guildID, GameResult{Winner: player2, Loser: player3}); err != nil {
+			t.Fatalf("failed to update stats: %v", err)
+		}
+	}
+
+	reset := func(guildID string) {
+		ic := commandInteraction("stats-admin", guildID, &discordgo.ApplicationCommandInteractionDataOption{
+			Name:    "reset",
+			Type:    discordgo.ApplicationCommandOptionSubCommand,
+			Options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "player", Type: discordgo.ApplicationCommandOptionUser, Value: "id2"}},
+		})
+		ic.Member.Permissions = discordgo.PermissionAdministrator
+		state.HandeInteractionCreate(nil, ic)
+	}
+	getStats := func(guildID string) StatsRow {
+		stats, err := GetStats(ctx, state.Db, StatsKey(guildID, "id2"))
+		if err != nil {
+			t.Fatalf("failed to get stats: %v", err)
+		}
+		return stats
+	}
+
+	// the server has no ladder of its own, so the player's global stats are left alone
+	reset("guild1")
+	responses := transport.Responses(t)
+	if assert.Len(t, responses, 1) {
+		assert.Equal(t, NoGuildLadderMsg, responses[0].Data.Content)
+	}
+	assert.Equal(t, 1, getStats("").Won)
+
+	if err := state.GuildCache.SetConfig(ctx, GuildConfig{GuildID: "guild1", GuildStats: true}); err != nil {
+		t.Fatalf("failed to set config: %v", err)
+	}
+	reset("guild1")
+	assert.Equal(t, DefaultStats(StatsKey("guild1", "id2")), getStats("guild1"))
+	assert.Equal(t, 1, getStats("").Won)
+}
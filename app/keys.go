@@ -3,3 +3,19 @@ package app
 type TraceType string
 
 var TraceKey TraceType = "trace"
+
+type GuildConfigType string
+
+var GuildConfigKey GuildConfigType = "guild-config"
+
+type ChannelType string
+
+var ChannelKey ChannelType = "channel"
+
+type GameTtlType string
+
+var GameTtlKey GameTtlType = "game-ttl"
+
+type ChannelGameLimitType string
+
+var ChannelGameLimitKey ChannelGameLimitType = "channel-game-limit"
@@ -0,0 +1,337 @@
+package app
+
+import (
+	"math"
+	"math/bits"
+	"runtime"
+	"slices"
+
+	"go.uber.org/atomic"
+	"golang.org/x/sync/errgroup"
+)
+
+// LocalMaxDepth caps the built-in search, it is much weaker and slower than ntest so deep levels are not feasible
+const LocalMaxDepth = 6
+
+func tileBit(tile Tile) uint64 {
+	return 1 << (tile.Row*BoardSize + tile.Col)
+}
+
+// tilesBitboard sets the bit of each tile the predicate holds for
+func tilesBitboard(pred func(Tile) bool) uint64 {
+	var bitboard uint64
+	for row := range BoardSize {
+		for col := range BoardSize {
+			if tile := (Tile{Row: row, Col: col}); pred(tile) {
+				bitboard |= tileBit(tile)
+			}
+		}
+	}
+	return bitboard
+}
+
+var cornersBitboard = tilesBitboard(Tile.IsCorner)
+
+// cornerRegion is a corner and the squares next to it, a disc next to an empty corner can give the opponent the corner
+type cornerRegion struct {
+	corner   uint64
+	xSquare  uint64 // the square diagonal to the corner
+	cSquares uint64 // the edge squares beside the corner
+}
+
+var cornerRegions = makeCornerRegions()
+
+func makeCornerRegions() []cornerRegion {
+	var regions []cornerRegion
+	for row := range BoardSize {
+		for col := range BoardSize {
+			corner := Tile{Row: row, Col: col}
+			if !corner.IsCorner() {
+				continue
+			}
+			region := cornerRegion{corner: tileBit(corner)}
+			for _, neighbor := range corner.Neighbors() {
+				if neighbor.IsXSquare() {
+					region.xSquare |= tileBit(neighbor)
+				} else if neighbor.IsEdge() {
+					region.cSquares |= tileBit(neighbor)
+				}
+			}
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// XSquareWeight and CSquareWeight scale the penalty for discs next to an empty corner, an x-square gives the corner
+// away more often than a c-square
+const XSquareWeight = 2.0
+const CSquareWeight = 1.0
+
+// EndgameEmpties is the most empty squares the engine solves exactly instead of searching with the heuristic
+const EndgameEmpties = 12
+
+// FrontierWeight scales the frontier term of the evaluation, frontier discs give the opponent moves so fewer are better
+const FrontierWeight = 1.0
+
+// LocalEngineShell is a fallback engine used when ntest is not available, it runs an alpha-beta search in process
+type LocalEngineShell struct {
+	nodes   atomic.Int64
+	workers int // bounds the root moves searched at once
+}
+
+func MakeLocalEngineShell() *LocalEngineShell {
+	return &LocalEngineShell{workers: runtime.NumCPU()}
+}
+
+func (le *LocalEngineShell) FindBestMove(game OthelloGame, depth uint64) chan MoveResp {
+	ch := make(chan MoveResp, 1)
+	go func() {
+		if move, ok := le.SolveEndgame(game.Board); ok {
+			ch <- MoveResp{Moves: []RankTile{move}}
+			return
+		}
+		moves, err := le.findRankedMovesParallel(game.Board, depth)
+		if err != nil {
+			ch <- MoveResp{Err: err}
+			return
+		}
+		ch <- MoveResp{Moves: moves[:1]}
+	}()
+	return ch
+}
+
+func (le *LocalEngineShell) FindRankedMoves(game OthelloGame, depth uint64) chan MoveResp {
+	ch := make(chan MoveResp, 1)
+	go func() {
+		moves, err := le.findRankedMovesParallel(game.Board, depth)
+		ch <- MoveResp{Moves: moves, Err: err}
+	}()
+	return ch
+}
+
+func (le *LocalEngineShell) findRankedMoves(board OthelloBoard, depth uint64) ([]RankTile, error) {
+	tiles := board.FindCurrentMoves()
+	if len(tiles) == 0 {
+		return nil, ErrNoMoves
+	}
+	depth = max(min(depth, LocalMaxDepth), 1)
+
+	moves := make([]RankTile, 0, len(tiles))
+	for _, tile := range tiles {
+		child := board.MakeMoved(tile)
+		h := -le.negamax(child, depth-1, math.Inf(-1), math.Inf(1))
+		moves = append(moves, RankTile{Tile: tile, H: h})
+	}
+	return sortRankTiles(moves), nil
+}
+
+// findRankedMovesParallel searches each root move on its own goroutine, each search only shares the node counter so
+// the results match the sequential search
+func (le *LocalEngineShell) findRankedMovesParallel(board OthelloBoard, depth uint64) ([]RankTile, error) {
+	tiles := board.FindCurrentMoves()
+	if len(tiles) == 0 {
+		return nil, ErrNoMoves
+	}
+	depth = max(min(depth, LocalMaxDepth), 1)
+
+	var eg errgroup.Group
+	eg.SetLimit(max(le.workers, 1))
+
+	moves := make([]RankTile, len(tiles))
+	for i, tile := range tiles {
+		eg.Go(func() error {
+			child := board.MakeMoved(tile)
+			h := -le.negamax(child, depth-1, math.Inf(-1), math.Inf(1))
+			moves[i] = RankTile{Tile: tile, H: h}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	return sortRankTiles(moves), nil
+}
+
+// FindPrincipalVariation searches with iterative deepening and returns the expected line of play from the board,
+// the line stops early at passes and finished games. ok is false if the side to move has no moves
+func (le *LocalEngineShell) FindPrincipalVariation(board OthelloBoard, maxDepth uint64) (pv []RankTile, ok bool) {
+	if len(board.FindCurrentMoves()) == 0 {
+		return nil, false
+	}
+	maxDepth = max(min(maxDepth, LocalMaxDepth), 1)
+
+	bestMoves := make(map[OthelloBoard]Tile)
+	var h float64
+	for depth := uint64(1); depth <= maxDepth; depth++ {
+		h = le.negamaxPV(board, depth, math.Inf(-1), math.Inf(1), bestMoves)
+	}
+
+	for range maxDepth {
+		tile, found := bestMoves[board]
+		if !found || !board.IsLegalMove(tile) {
+			break
+		}
+		pv = append(pv, RankTile{Tile: tile, H: h})
+		board = board.MakeMoved(tile)
+		h = -h
+	}
+	return pv, true
+}
+
+// negamaxPV is negamax that records the best child of each searched position, the recorded moves are searched first
+// on the next iteration and are walked afterward to produce the principal variation
+func (le *LocalEngineShell) negamaxPV(board OthelloBoard, depth uint64, alpha float64, beta float64, bestMoves map[OthelloBoard]Tile) float64 {
+	le.nodes.Inc()
+	tiles := board.FindCurrentMoves()
+	if len(tiles) == 0 {
+		passed := board
+		passed.IsBlackMove = !passed.IsBlackMove
+		if len(passed.FindCurrentMoves()) == 0 {
+			return evaluateFinal(board)
+		}
+		return -le.negamaxPV(passed, depth, -beta, -alpha, bestMoves)
+	}
+	if depth == 0 {
+		return evaluate(board)
+	}
+
+	if prev, found := bestMoves[board]; found {
+		if i := slices.Index(tiles, prev); i > 0 {
+			tiles[0], tiles[i] = tiles[i], tiles[0]
+		}
+	}
+
+	best := math.Inf(-1)
+	for _, tile := range tiles {
+		h := -le.negamaxPV(board.MakeMoved(tile), depth-1, -beta, -alpha, bestMoves)
+		if h > best {
+			best = h
+			bestMoves[board] = tile
+		}
+		alpha = max(alpha, h)
+		if alpha >= beta {
+			break
+		}
+	}
+	return best
+}
+
+// SolveEndgame searches to the end of the game and returns the move with the best final disc differential for the side
+// to move, ok is false if the side to move has no moves or the board has more than EndgameEmpties empty squares
+func (le *LocalEngineShell) SolveEndgame(board OthelloBoard) (move RankTile, ok bool) {
+	tiles := board.FindCurrentMoves()
+	if len(tiles) == 0 || board.CountEmpty() > EndgameEmpties {
+		return RankTile{}, false
+	}
+
+	// the window is bounded by the disc differential, so no move can score outside it
+	alpha := -float64(BoardSize*BoardSize) - 1
+	beta := float64(BoardSize*BoardSize) + 1
+	for i, tile := range tiles {
+		h := -le.solve(board.MakeMoved(tile), -beta, -alpha)
+		if i == 0 || h > alpha {
+			alpha = h
+			move = RankTile{Tile: tile, H: h}
+		}
+	}
+	return move, true
+}
+
+// solve is negamax without a depth limit, every leaf is a finished game scored by the exact disc differential
+func (le *LocalEngineShell) solve(board OthelloBoard, alpha float64, beta float64) float64 {
+	le.nodes.Inc()
+	tiles := board.FindCurrentMoves()
+	if len(tiles) == 0 {
+		passed := board
+		passed.IsBlackMove = !passed.IsBlackMove
+		if len(passed.FindCurrentMoves()) == 0 {
+			return evaluateFinal(board)
+		}
+		return -le.solve(passed, -beta, -alpha)
+	}
+
+	best := math.Inf(-1)
+	for _, tile := range tiles {
+		h := -le.solve(board.MakeMoved(tile), -beta, -alpha)
+		best = max(best, h)
+		alpha = max(alpha, h)
+		if alpha >= beta {
+			break
+		}
+	}
+	return best
+}
+
+// Nodes is the total number of positions searched by the engine
+func (le *LocalEngineShell) Nodes() int64 {
+	return le.nodes.Load()
+}
+
+func (le *LocalEngineShell) negamax(board OthelloBoard, depth uint64, alpha float64, beta float64) float64 {
+	le.nodes.Inc()
+	tiles := board.FindCurrentMoves()
+	if len(tiles) == 0 {
+		passed := board
+		passed.IsBlackMove = !passed.IsBlackMove
+		if len(passed.FindCurrentMoves()) == 0 {
+			return evaluateFinal(board)
+		}
+		return -le.negamax(passed, depth, -beta, -alpha)
+	}
+	if depth == 0 {
+		return evaluate(board)
+	}
+
+	best := math.Inf(-1)
+	for _, tile := range tiles {
+		h := -le.negamax(board.MakeMoved(tile), depth-1, -beta, -alpha)
+		best = max(best, h)
+		alpha = max(alpha, h)
+		if alpha >= beta {
+			break
+		}
+	}
+	return best
+}
+
+// evaluateFinal scores a finished game by the disc differential for the side to move
+func evaluateFinal(board OthelloBoard) float64 {
+	diff := float64(board.BlackScore() - board.WhiteScore())
+	if !board.IsBlackMove {
+		diff = -diff
+	}
+	return diff
+}
+
+// evaluate estimates the disc differential for the side to move using mobility, corner ownership and frontier discs
+func evaluate(board OthelloBoard) float64 {
+	own, opp := board.Bitboards()
+	if !board.IsBlackMove {
+		own, opp = opp, own
+	}
+	mobility := bits.OnesCount64(MovesBitboard(own, opp)) - bits.OnesCount64(MovesBitboard(opp, own))
+	corners := bits.OnesCount64(own&cornersBitboard) - bits.OnesCount64(opp&cornersBitboard)
+	return float64(mobility) + float64(corners)*4 + findFrontierHeuristic(own, opp) + findCornerRegionHeuristic(own, opp)
+}
+
+// findCornerRegionHeuristic is negative when the own discs have more x-squares and c-squares next to empty corners
+// than the opp discs
+func findCornerRegionHeuristic(own uint64, opp uint64) float64 {
+	var h float64
+	for _, region := range cornerRegions {
+		if (own|opp)&region.corner != 0 {
+			continue
+		}
+		xSquares := bits.OnesCount64(own&region.xSquare) - bits.OnesCount64(opp&region.xSquare)
+		cSquares := bits.OnesCount64(own&region.cSquares) - bits.OnesCount64(opp&region.cSquares)
+		h -= float64(xSquares)*XSquareWeight + float64(cSquares)*CSquareWeight
+	}
+	return h
+}
+
+// findFrontierHeuristic is positive when the own discs have fewer frontier discs than the opp discs
+func findFrontierHeuristic(own uint64, opp uint64) float64 {
+	empty := ^(own | opp)
+	frontier := bits.OnesCount64(FrontierBitboard(own, empty)) - bits.OnesCount64(FrontierBitboard(opp, empty))
+	return -float64(frontier) * FrontierWeight
+}
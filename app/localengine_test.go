@@ -0,0 +1,273 @@
+package app
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocalEngineShell_FindBestMove(t *testing.T) {
+	le := MakeLocalEngineShell()
+
+	board, moveList := RandomBoard(30)
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: board, MoveList: moveList}
+
+	resp := <-le.FindBestMove(game, 4)
+	if resp.Err != nil {
+		t.Fatalf("failed to find best move: %v", resp.Err)
+	}
+	assert.Len(t, resp.Moves, 1)
+	assert.True(t, slices.Contains(board.FindCurrentMoves(), resp.Moves[0].Tile))
+}
+
+func TestLocalEngineShell_FindRankedMoves(t *testing.T) {
+	le := MakeLocalEngineShell()
+
+	board, moveList := RandomBoard(30)
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: board, MoveList: moveList}
+
+	resp := <-le.FindRankedMoves(game, 20)
+	if resp.Err != nil {
+		t.Fatalf("failed to find ranked moves: %v", resp.Err)
+	}
+	assert.Len(t, resp.Moves, len(board.FindCurrentMoves()))
+	assert.True(t, slices.IsSortedFunc(resp.Moves, func(a, b RankTile) int {
+		return int(b.H - a.H)
+	}))
+}
+
+func TestLocalEngineShell_FindRankedMoves_FlankedTwice(t *testing.T) {
+	le := MakeLocalEngineShell()
+
+	// after c4 c5 black can play c6 through d5 and through c5, the tile is found from both flanking discs
+	board := MakeInitialBoard()
+	board.MakeMove(ParseTile("c4"))
+	board.MakeMove(ParseTile("c5"))
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: board}
+
+	resp := <-le.FindRankedMoves(game, 3)
+	if resp.Err != nil {
+		t.Fatalf("failed to find ranked moves: %v", resp.Err)
+	}
+
+	seen := make(map[Tile]bool)
+	for _, move := range resp.Moves {
+		assert.False(t, seen[move.Tile], "tile %s was ranked twice", move.Tile)
+		seen[move.Tile] = true
+	}
+	assert.True(t, seen[ParseTile("c6")])
+	assert.Len(t, resp.Moves, len(board.FindCurrentMoves()))
+}
+
+func TestLocalEngineShell_Endgame(t *testing.T) {
+	le := MakeLocalEngineShell()
+
+	// with few empty squares the search reaches the end of the game, so the score is an exact disc differential
+	board, moveList := RandomBoard(57)
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: board, MoveList: moveList}
+	if !game.HasMoves() {
+		t.Skip("random board has no moves")
+	}
+
+	resp := <-le.FindRankedMoves(game, LocalMaxDepth)
+	if resp.Err != nil {
+		t.Fatalf("failed to find ranked moves: %v", resp.Err)
+	}
+	for _, move := range resp.Moves {
+		assert.Equal(t, float64(int(move.H)), move.H)
+	}
+}
+
+// solveExhaustive finds the exact disc differential for the side to move by searching every line without pruning
+func solveExhaustive(board OthelloBoard) float64 {
+	tiles := board.FindCurrentMoves()
+	if len(tiles) == 0 {
+		passed := board
+		passed.IsBlackMove = !passed.IsBlackMove
+		if len(passed.FindCurrentMoves()) == 0 {
+			return evaluateFinal(board)
+		}
+		return -solveExhaustive(passed)
+	}
+	best := math.Inf(-1)
+	for _, tile := range tiles {
+		best = max(best, -solveExhaustive(board.MakeMoved(tile)))
+	}
+	return best
+}
+
+func TestLocalEngineShell_SolveEndgame(t *testing.T) {
+	le := MakeLocalEngineShell()
+
+	for _, count := range []int{52, 54, 56, 58} {
+		t.Run(fmt.Sprintf("%d", count), func(t *testing.T) {
+			board, _ := RandomBoard(count)
+			if len(board.FindCurrentMoves()) == 0 {
+				t.Skip("random board has no moves")
+			}
+
+			move, ok := le.SolveEndgame(board)
+			assert.True(t, ok)
+			assert.True(t, slices.Contains(board.FindCurrentMoves(), move.Tile))
+
+			// the chosen move must reach the exact best result, and score what it reaches
+			exp := solveExhaustive(board)
+			assert.Equal(t, exp, move.H)
+			assert.Equal(t, exp, -solveExhaustive(board.MakeMoved(move.Tile)))
+		})
+	}
+}
+
+func TestLocalEngineShell_SolveEndgame_KnownWin(t *testing.T) {
+	le := MakeLocalEngineShell()
+
+	// white's only discs are on the bottom row between a8 and the empty g8, so g8 flanks them all and wipes white out
+	board := OthelloBoard{IsBlackMove: true}
+	for _, tile := range AllTiles {
+		board.SetSquareByTile(tile, Black)
+	}
+	for col := 1; col < 6; col++ {
+		board.SetSquare(7, col, White)
+	}
+	board.SetSquare(7, 6, Empty)
+	board.SetSquare(7, 7, Empty)
+
+	move, ok := le.SolveEndgame(board)
+	assert.True(t, ok)
+	assert.Equal(t, ParseTile("g8"), move.Tile)
+	assert.Equal(t, 63.0, move.H)
+}
+
+func TestLocalEngineShell_SolveEndgame_TooManyEmpties(t *testing.T) {
+	le := MakeLocalEngineShell()
+
+	board, _ := RandomBoard(30)
+	_, ok := le.SolveEndgame(board)
+	assert.False(t, ok)
+}
+
+func TestLocalEngineShell_FindPrincipalVariation(t *testing.T) {
+	le := MakeLocalEngineShell()
+
+	// RandomBoard uses a fixed seed, so each count is a different position
+	for i := range 10 {
+		board, _ := RandomBoard(14 + i)
+		pv, ok := le.FindPrincipalVariation(board, 4)
+		if !ok {
+			assert.Empty(t, board.FindCurrentMoves())
+			continue
+		}
+		assert.NotEmpty(t, pv)
+		assert.LessOrEqual(t, len(pv), 4)
+
+		// each move in the line must be legal from the position reached by the moves before it
+		for _, move := range pv {
+			assert.True(t, slices.Contains(board.FindCurrentMoves(), move.Tile))
+			board = board.MakeMoved(move.Tile)
+		}
+	}
+}
+
+func TestLocalEngineShell_FindPrincipalVariation_MatchesBestMove(t *testing.T) {
+	le := MakeLocalEngineShell()
+
+	board, moveList := RandomBoard(30)
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: board, MoveList: moveList}
+	if !game.HasMoves() {
+		t.Skip("random board has no moves")
+	}
+
+	resp := <-le.FindRankedMoves(game, 4)
+	if resp.Err != nil {
+		t.Fatalf("failed to find ranked moves: %v", resp.Err)
+	}
+	pv, ok := le.FindPrincipalVariation(board, 4)
+	assert.True(t, ok)
+	assert.Equal(t, resp.Moves[0].H, pv[0].H)
+}
+
+func TestLocalEngineShell_FindRankedMovesParallel(t *testing.T) {
+	le := MakeLocalEngineShell()
+
+	// RandomBoard uses a fixed seed, so each count is a different position
+	for i := range 5 {
+		board, _ := RandomBoard(16 + i)
+		if len(board.FindCurrentMoves()) == 0 {
+			continue
+		}
+		expMoves, err := le.findRankedMoves(board, 4)
+		if err != nil {
+			t.Fatalf("failed to find ranked moves: %v", err)
+		}
+		moves, err := le.findRankedMovesParallel(board, 4)
+		if err != nil {
+			t.Fatalf("failed to find ranked moves in parallel: %v", err)
+		}
+		assert.Equal(t, expMoves, moves)
+	}
+}
+
+func benchmarkRankedMoves(b *testing.B, find func(le *LocalEngineShell, board OthelloBoard) ([]RankTile, error)) {
+	le := MakeLocalEngineShell()
+	board, _ := RandomBoard(20)
+	if len(board.FindCurrentMoves()) == 0 {
+		b.Skip("random board has no moves")
+	}
+
+	b.ResetTimer()
+	for range b.N {
+		if _, err := find(le, board); err != nil {
+			b.Fatalf("failed to find ranked moves: %v", err)
+		}
+	}
+}
+
+func BenchmarkLocalEngineShell_FindRankedMoves(b *testing.B) {
+	benchmarkRankedMoves(b, func(le *LocalEngineShell, board OthelloBoard) ([]RankTile, error) {
+		return le.findRankedMoves(board, 5)
+	})
+}
+
+func BenchmarkLocalEngineShell_FindRankedMovesParallel(b *testing.B) {
+	benchmarkRankedMoves(b, func(le *LocalEngineShell, board OthelloBoard) ([]RankTile, error) {
+		return le.findRankedMovesParallel(board, 5)
+	})
+}
+
+func TestFindFrontierHeuristic(t *testing.T) {
+	// black's discs form a solid block along the top edge, white's rows have empty squares on both sides
+	board := OthelloBoard{IsBlackMove: true}
+	for col := 0; col < BoardSize; col++ {
+		board.SetSquare(0, col, Black)
+		board.SetSquare(1, col, Black)
+	}
+	for col := 0; col < BoardSize; col++ {
+		board.SetSquare(4, col, White)
+		board.SetSquare(6, col, White)
+	}
+	assert.Less(t, board.CountFrontierDiscs(Black), board.CountFrontierDiscs(White))
+
+	black, white := board.Bitboards()
+	assert.Greater(t, findFrontierHeuristic(black, white), 0.0)
+	assert.Less(t, findFrontierHeuristic(white, black), 0.0)
+	assert.Equal(t, 0.0, findFrontierHeuristic(black, black))
+}
+
+func TestFindCornerRegionHeuristic(t *testing.T) {
+	assert.Equal(t, uint64(1|1<<7|1<<56|1<<63), cornersBitboard)
+	assert.Len(t, cornerRegions, 4)
+
+	// black holds the x-square and a c-square of the empty a1 corner, white holds the c-square of the taken h8 corner
+	board := OthelloBoard{IsBlackMove: true}
+	board.SetSquareByTile(ParseTile("b2"), Black)
+	board.SetSquareByTile(ParseTile("b1"), Black)
+	board.SetSquareByTile(ParseTile("h8"), Black)
+	board.SetSquareByTile(ParseTile("g8"), White)
+
+	black, white := board.Bitboards()
+	assert.Equal(t, -(XSquareWeight + CSquareWeight), findCornerRegionHeuristic(black, white))
+	assert.Equal(t, XSquareWeight+CSquareWeight, findCornerRegionHeuristic(white, black))
+}
@@ -0,0 +1,54 @@
+package app
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+type PlayerJSON struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Level uint64 `json:"level,omitempty"` // only set for bots
+}
+
+// GameJSON is the exported form of a game, moves use the same notation as Move.String so passes are "PA"
+type GameJSON struct {
+	ID          string     `json:"id"`
+	Black       PlayerJSON `json:"black"`
+	White       PlayerJSON `json:"white"`
+	Moves       []string   `json:"moves"`
+	Board       string     `json:"board"`
+	BlackToMove bool       `json:"blackToMove"`
+	BlackScore  int        `json:"blackScore"`
+	WhiteScore  int        `json:"whiteScore"`
+	CreatedTime time.Time  `json:"createdTime"`
+}
+
+func mapPlayerJSON(player Player) PlayerJSON {
+	return PlayerJSON{ID: player.ID, Name: player.Name, Level: player.Level}
+}
+
+func MarshalJSONGame(game OthelloGame) ([]byte, error) {
+	moves := make([]string, 0, len(game.MoveList))
+	for _, move := range game.MoveList {
+		moves = append(moves, move.String())
+	}
+
+	gameJSON := GameJSON{
+		ID:          game.ID,
+		Black:       mapPlayerJSON(game.BlackPlayer),
+		White:       mapPlayerJSON(game.WhitePlayer),
+		Moves:       moves,
+		Board:       game.Board.MarshalString(),
+		BlackToMove: game.Board.IsBlackMove,
+		BlackScore:  game.Board.BlackScore(),
+		WhiteScore:  game.Board.WhiteScore(),
+		CreatedTime: game.CreatedTime,
+	}
+	b, err := json.MarshalIndent(gameJSON, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal game json: %w", err)
+	}
+	return b, nil
+}
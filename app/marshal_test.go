@@ -0,0 +1,54 @@
+package app
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMarshalJSONGame(t *testing.T) {
+	board := MakeInitialBoard()
+	board.MakeMove(ParseTile("d3"))
+
+	game := OthelloGame{
+		ID:          "game1",
+		Board:       board,
+		WhitePlayer: MakeBotPlayer(3),
+		BlackPlayer: Player{ID: "id1", Name: "Player1"},
+		MoveList:    []Move{{Tile: ParseTile("d3")}, {Pass: true}},
+		CreatedTime: time.UnixMilli(1000).UTC(),
+	}
+
+	b, err := MarshalJSONGame(game)
+	if err != nil {
+		t.Fatalf("failed to marshal game: %v", err)
+	}
+
+	var gameJSON GameJSON
+	if err := json.Unmarshal(b, &gameJSON); err != nil {
+		t.Fatalf("failed to unmarshal game: %v", err)
+	}
+	expGameJSON := GameJSON{
+		ID:          "game1",
+		Black:       PlayerJSON{ID: "id1", Name: "Player1"},
+		White:       PlayerJSON{ID: "3", Name: "NTest level 3", Level: 3},
+		Moves:       []string{"D3", "PA"},
+		Board:       board.MarshalString(),
+		BlackToMove: false,
+		BlackScore:  4,
+		WhiteScore:  1,
+		CreatedTime: time.UnixMilli(1000).UTC(),
+	}
+	assert.Equal(t, expGameJSON, gameJSON)
+	assert.NotContains(t, string(b), `"level": 0`)
+}
+
+func TestMarshalJSONGame_NoMoves(t *testing.T) {
+	b, err := MarshalJSONGame(OthelloGame{Board: MakeInitialBoard()})
+	if err != nil {
+		t.Fatalf("failed to marshal game: %v", err)
+	}
+	assert.Contains(t, string(b), `"moves": []`)
+}
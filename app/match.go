@@ -0,0 +1,209 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"log/slog"
+)
+
+// MatchBestOfs are the number of games a match can be played over
+var MatchBestOfs = []int{3, 5, 7}
+
+// MaxMatchDraws is the number of drawn games a series replays, the next draw decides the series on the games won so far
+const MaxMatchDraws = 3
+
+// Match is a best of n series between two players, the players swap colors each game and drawn games are replayed
+// without counting toward either player
+type Match struct {
+	ID          string `db:"id"`
+	GameID      string `db:"game_id"` // the series game currently being played
+	Player1ID   string `db:"player1_id"`
+	Player2ID   string `db:"player2_id"`
+	Player1Name string `db:"player1_name"`
+	Player2Name string `db:"player2_name"`
+	BestOf      int    `db:"best_of"`
+	Player1Wins int    `db:"player1_wins"`
+	Player2Wins int    `db:"player2_wins"`
+	Draws       int    `db:"draws"`
+}
+
+func (m Match) Player1() Player {
+	return MakePlayer(m.Player1ID, m.Player1Name)
+}
+
+func (m Match) Player2() Player {
+	return MakePlayer(m.Player2ID, m.Player2Name)
+}
+
+// WinsNeeded is the majority of the series a player has to win to take it
+func (m Match) WinsNeeded() int {
+	return m.BestOf/2 + 1
+}
+
+// MatchResult is the standing of a match after one of its games finished
+type MatchResult struct {
+	Match    Match
+	IsOver   bool
+	IsDrawn  bool        // the series ran out of draws with the players level, so it has no winner
+	Winner   Player      // the winner of the series once it's over
+	NextGame OthelloGame // the game started for the series when it isn't over
+}
+
+// RecordResult scores a finished game of the series, a forfeit or timeout loses the whole series, not just the game
+func (m Match) RecordResult(gr GameResult) MatchResult {
+	if gr.IsDraw {
+		m.Draws++
+		if m.Draws <= MaxMatchDraws {
+			return MatchResult{Match: m}
+		}
+		switch {
+		case m.Player1Wins > m.Player2Wins:
+			return MatchResult{Match: m, IsOver: true, Winner: m.Player1()}
+		case m.Player2Wins > m.Player1Wins:
+			return MatchResult{Match: m, IsOver: true, Winner: m.Player2()}
+		default:
+			return MatchResult{Match: m, IsOver: true, IsDrawn: true}
+		}
+	}
+
+	wins := &m.Player2Wins
+	if gr.Winner.ID == m.Player1ID {
+		wins = &m.Player1Wins
+	}
+	*wins++
+
+	if gr.Kind == Forfeit || gr.Kind == Timeout || *wins >= m.WinsNeeded() {
+		return MatchResult{Match: m, IsOver: true, Winner: gr.Winner}
+	}
+	return MatchResult{Match: m}
+}
+
+// CreateMatchTx starts a match with its first game, the challenger plays black first
+func CreateMatchTx(ctx context.Context, db *sqlx.DB, challenger Player, challenged Player, bestOf int) (Match, OthelloGame, error) {
+	trace := ctx.Value(TraceKey)
+
+	fail := func(err error) (Match, OthelloGame, error) {
+		slog.Error("failed to create match", "trace", trace, "challenger", challenger, "challenged", challenged, "err", err)
+		return Match{}, OthelloGame{}, err
+	}
+
+	game := makeGame(ctx, challenger, challenged)
+	match := Match{
+		ID:          uuid.NewString(),
+		GameID:      game.ID,
+		Player1ID:   challenger.ID,
+		Player2ID:   challenged.ID,
+		Player1Name: challenger.Name,
+		Player2Name: challenged.Name,
+		BestOf:      bestOf,
+	}
+	player1Id, player2Id := gameParticipants(challenger, challenged)
+
+	tx, err := db.BeginTxx(ctx, &sql.TxOptions{Isolation: sql.LevelSerializable})
+	if err != nil {
+		return fail(err)
+	}
+	defer tx.Rollback()
+
+	if err := CheckGameParticipation(ctx, tx, player1Id, player2Id); err != nil {
+		return Match{}, OthelloGame{}, err
+	}
+	if err := CheckChannelGames(ctx, tx, game.ChannelID); err != nil {
+		return Match{}, OthelloGame{}, err
+	}
+	if err := SetGame(ctx, tx, game); err != nil {
+		return fail(err)
+	}
+	_, err = tx.ExecContext(ctx,
+		`INSERT INTO matches (id, game_id, player1_id, player2_id, player1_name, player2_name, best_of, player1_wins, player2_wins, draws)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10);`,
+		match.ID, match.GameID, match.Player1ID, match.Player2ID, match.Player1Name, match.Player2Name, match.BestOf,
+		match.Player1Wins, match.Player2Wins, match.Draws,
+	)
+	if err != nil {
+		return fail(fmt.Errorf("failed to insert match: %w", err))
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+
+	slog.Info("created match", "trace", trace, "match", match, "game", game.MarshalGGF())
+	return match, game, nil
+}
+
+// advanceMatch records the result of a finished game in its match and starts the next game of the series, the result
+// is nil if the game wasn't part of a match
+func advanceMatch(ctx context.Context, tx *sqlx.Tx, game OthelloGame, gr GameResult) (*MatchResult, error) {
+	trace := ctx.Value(TraceKey)
+
+	var match Match
+	err := tx.GetContext(ctx, &match,
+		`SELECT id, game_id, player1_id, player2_id, player1_name, player2_name, best_of, player1_wins, player2_wins, draws
+		FROM matches WHERE game_id = $1;`,
+		game.ID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to select match: %w", err)
+	}
+
+	mr := match.RecordResult(gr)
+	if mr.IsOver {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM matches WHERE id = $1;", match.ID); err != nil {
+			return nil, fmt.Errorf("failed to delete match: %w", err)
+		}
+		slog.Info("finished match", "trace", trace, "match", mr.Match, "winner", mr.Winner, "drawn", mr.IsDrawn)
+		return &mr, nil
+	}
+
+	blackPlayer, whitePlayer := game.RematchPlayers()
+	next := makeGame(ctx, blackPlayer, whitePlayer)
+	next.GuildID = game.GuildID
+	next.ChannelID = game.ChannelID
+	next.ServerID = game.ServerID
+	if err := SetGame(ctx, tx, next); err != nil {
+		return nil, err
+	}
+
+	mr.Match.GameID = next.ID
+	_, err = tx.ExecContext(ctx,
+		"UPDATE matches SET game_id = $1, player1_wins = $2, player2_wins = $3, draws = $4 WHERE id = $5;",
+		mr.Match.GameID, mr.Match.Player1Wins, mr.Match.Player2Wins, mr.Match.Draws, mr.Match.ID,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update match: %w", err)
+	}
+	mr.NextGame = next
+
+	slog.Info("advanced match", "trace", trace, "match", mr.Match, "game", next.MarshalGGF())
+	return &mr, nil
+}
+
+var ErrMatchStarted = errors.New("match already has finished games")
+
+// cancelMatch deletes the match the game belongs to, if any. Only a match without finished games can be cancelled, a
+// player behind in the series must forfeit it instead
+func cancelMatch(ctx context.Context, tx *sqlx.Tx, gameID string) error {
+	var played int
+	err := tx.GetContext(ctx, &played, "SELECT player1_wins + player2_wins + draws FROM matches WHERE game_id = $1;", gameID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to select match: %w", err)
+	}
+	if played > 0 {
+		return ErrMatchStarted
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM matches WHERE game_id = $1;", gameID); err != nil {
+		return fmt.Errorf("failed to delete match: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,177 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMatch_RecordResult(t *testing.T) {
+	player1 := Player{ID: "id1", Name: "Player1"}
+	player2 := Player{ID: "id2", Name: "Player2"}
+	match := Match{Player1ID: player1.ID, Player2ID: player2.ID, BestOf: 3, Player1Wins: 1}
+
+	type Test struct {
+		match     Match
+		gr        GameResult
+		expP1Wins int
+		expP2Wins int
+		expDraws  int
+		expOver   bool
+		expDrawn  bool
+		expWinner Player
+	}
+	tests := []Test{
+		{gr: GameResult{Winner: player2, Loser: player1}, expP1Wins: 1, expP2Wins: 1},
+		{gr: GameResult{Winner: player1, Loser: player2}, expP1Wins: 2, expOver: true, expWinner: player1},
+		{gr: GameResult{Winner: player1, Loser: player2, IsDraw: true}, expP1Wins: 1, expDraws: 1},
+		{gr: GameResult{Winner: player2, Loser: player1, Kind: Forfeit}, expP1Wins: 1, expP2Wins: 1, expOver: true, expWinner: player2},
+		{gr: GameResult{Winner: player2, Loser: player1, Kind: Timeout}, expP1Wins: 1, expP2Wins: 1, expOver: true, expWinner: player2},
+		// the draws are capped, so another draw decides the series on the games won so far
+		{
+			match:     Match{Player1ID: player1.ID, Player2ID: player2.ID, Player1Name: player1.Name, Player2Name: player2.Name, BestOf: 3, Player1Wins: 1, Draws: MaxMatchDraws},
+			gr:        GameResult{Winner: player1, Loser: player2, IsDraw: true},
+			expP1Wins: 1, expDraws: MaxMatchDraws + 1, expOver: true, expWinner: player1,
+		},
+		{
+			match:    Match{Player1ID: player1.ID, Player2ID: player2.ID, BestOf: 3, Draws: MaxMatchDraws},
+			gr:       GameResult{Winner: player1, Loser: player2, IsDraw: true},
+			expDraws: MaxMatchDraws + 1, expOver: true, expDrawn: true,
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			if test.match.BestOf == 0 {
+				test.match = match
+			}
+			mr := test.match.RecordResult(test.gr)
+			assert.Equal(t, test.expP1Wins, mr.Match.Player1Wins)
+			assert.Equal(t, test.expP2Wins, mr.Match.Player2Wins)
+			assert.Equal(t, test.expDraws, mr.Match.Draws)
+			assert.Equal(t, test.expOver, mr.IsOver)
+			assert.Equal(t, test.expDrawn, mr.IsDrawn)
+			assert.Equal(t, test.expWinner, mr.Winner)
+		})
+	}
+}
+
+func TestMatch_GameOver(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-match-game-over")
+
+	player1 := Player{ID: "id1", Name: "Player1"}
+	player2 := Player{ID: "id2", Name: "Player2"}
+
+	// the match is created in a server, the later games are started by finishing the previous game
+	serverCtx := context.WithValue(ctx, GuildConfigKey, GuildConfig{GuildID: "guild1"})
+	match, game, err := CreateMatchTx(serverCtx, db, player1, player2, 3)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+	assert.Equal(t, game.ID, match.GameID)
+	assert.Equal(t, player1, game.BlackPlayer)
+	assert.Equal(t, "guild1", game.ServerID)
+
+	_, _, err = CreateMatchTx(ctx, db, player2, Player{ID: "id3", Name: "Player3"}, 3)
+	assert.ErrorIs(t, err, ErrAlreadyPlaying)
+
+	// the first game is won by player1, so the next game starts with the colors swapped
+	sr, err := GameOverTx(ctx, db, game, GameResult{Winner: player1, Loser: player2})
+	if err != nil {
+		t.Fatalf("failed to finish game: %v", err)
+	}
+	if assert.NotNil(t, sr.Match) {
+		assert.False(t, sr.Match.IsOver)
+		assert.Equal(t, 1, sr.Match.Match.Player1Wins)
+		assert.Equal(t, player2, sr.Match.NextGame.BlackPlayer)
+		assert.Equal(t, player1, sr.Match.NextGame.WhitePlayer)
+	}
+
+	next, err := GetGame(ctx, db, player1.ID)
+	if err != nil {
+		t.Fatalf("failed to get next game: %v", err)
+	}
+	assert.Equal(t, sr.Match.NextGame.ID, next.ID)
+	assert.Equal(t, "guild1", next.ServerID)
+
+	sr, err = GameOverTx(ctx, db, next, GameResult{Winner: player1, Loser: player2})
+	if err != nil {
+		t.Fatalf("failed to finish game: %v", err)
+	}
+	if assert.NotNil(t, sr.Match) {
+		assert.True(t, sr.Match.IsOver)
+		assert.Equal(t, player1, sr.Match.Winner)
+	}
+
+	// once the match is over no more games are started
+	_, err = GetGame(ctx, db, player1.ID)
+	assert.ErrorIs(t, err, ErrGameNotFound)
+
+	var count int
+	if err := db.Get(&count, "SELECT COUNT(*) FROM matches;"); err != nil {
+		t.Fatalf("failed to count matches: %v", err)
+	}
+	assert.Equal(t, 0, count)
+
+	// a game that isn't part of a match has no match result
+	game, err = CreateGameTx(ctx, db, player1, player2)
+	if err != nil {
+		t.Fatalf("failed to create game: %v", err)
+	}
+	sr, err = GameOverTx(ctx, db, game, GameResult{Winner: player1, Loser: player2})
+	if err != nil {
+		t.Fatalf("failed to finish game: %v", err)
+	}
+	assert.Nil(t, sr.Match)
+}
+
+func TestMatch_Abort(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-match-abort")
+
+	player1 := Player{ID: "id1", Name: "Player1"}
+	player2 := Player{ID: "id2", Name: "Player2"}
+
+	_, game, err := CreateMatchTx(ctx, db, player1, player2, 5)
+	if err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+
+	// once a game of the series has finished the player behind can't abort the next one to cancel the series
+	sr, err := GameOverTx(ctx, db, game, GameResult{Winner: player1, Loser: player2})
+	if err != nil {
+		t.Fatalf("failed to finish game: %v", err)
+	}
+	_, err = AbortGameTx(ctx, db, player2.ID)
+	assert.ErrorIs(t, err, ErrMatchStarted)
+
+	next, err := GetGame(ctx, db, player2.ID)
+	if err != nil {
+		t.Fatalf("failed to get next game: %v", err)
+	}
+	assert.Equal(t, sr.Match.NextGame.ID, next.ID)
+
+	// the first game of a series can be aborted, which cancels the series
+	if _, err := GameOverTx(ctx, db, next, GameResult{Winner: player2, Loser: player1, Kind: Forfeit}); err != nil {
+		t.Fatalf("failed to forfeit game: %v", err)
+	}
+	if _, _, err := CreateMatchTx(ctx, db, player1, player2, 5); err != nil {
+		t.Fatalf("failed to create match: %v", err)
+	}
+	if _, err := AbortGameTx(ctx, db, player2.ID); err != nil {
+		t.Fatalf("failed to abort game: %v", err)
+	}
+
+	var count int
+	if err := db.Get(&count, "SELECT COUNT(*) FROM matches;"); err != nil {
+		t.Fatalf("failed to count matches: %v", err)
+	}
+	assert.Equal(t, 0, count)
+}
@@ -0,0 +1,69 @@
+package app
+
+import (
+	"slices"
+	"strings"
+)
+
+const UnknownOpening = "Unknown opening"
+
+type Opening struct {
+	Name  string
+	Moves []Tile // played from the standard start with black opening at f5
+}
+
+func makeOpening(name string, moves string) Opening {
+	var tiles []Tile
+	for _, move := range strings.Fields(moves) {
+		tiles = append(tiles, ParseTile(move))
+	}
+	return Opening{Name: name, Moves: tiles}
+}
+
+// Openings are a few well known openings, a game is named by the longest opening it starts with
+var Openings = []Opening{
+	makeOpening("Perpendicular", "f5 d6"),
+	makeOpening("Diagonal", "f5 f6"),
+	makeOpening("Parallel", "f5 f4"),
+	makeOpening("Tiger", "f5 d6 c3 d3 c4"),
+	makeOpening("Cow", "f5 d6 c5"),
+	makeOpening("Buffalo", "f5 f6 e6 f4 c3"),
+	makeOpening("Heath", "f5 f6 e6 f4 g5"),
+	makeOpening("Chimney", "f5 f6 e6 f4 e3"),
+}
+
+// normalizeOpening transforms a tile by the symmetry of the starting position that maps the first move to f5, each of
+// black's four first moves is the same opening rotated or reflected
+func normalizeOpening(first Tile, tile Tile) Tile {
+	switch first {
+	case ParseTile("e6"):
+		return Tile{Row: tile.Col, Col: tile.Row}
+	case ParseTile("c4"):
+		return Tile{Row: BoardSize - 1 - tile.Row, Col: BoardSize - 1 - tile.Col}
+	case ParseTile("d3"):
+		return Tile{Row: BoardSize - 1 - tile.Col, Col: BoardSize - 1 - tile.Row}
+	default:
+		return tile
+	}
+}
+
+// IdentifyOpening names the opening of a game played from the standard start, the line ends at the first pass
+func IdentifyOpening(moves []Move) string {
+	var line []Tile
+	for _, move := range moves {
+		if move.Pass {
+			break
+		}
+		line = append(line, normalizeOpening(moves[0].Tile, move.Tile))
+	}
+
+	name := UnknownOpening
+	longest := 0
+	for _, opening := range Openings {
+		if len(opening.Moves) > longest && len(opening.Moves) <= len(line) && slices.Equal(opening.Moves, line[:len(opening.Moves)]) {
+			name = opening.Name
+			longest = len(opening.Moves)
+		}
+	}
+	return name
+}
@@ -0,0 +1,53 @@
+package app
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenings_Legal(t *testing.T) {
+	for _, opening := range Openings {
+		game := OthelloGame{Board: MakeInitialBoard()}
+		for _, move := range opening.Moves {
+			if !slices.Contains(game.Board.FindCurrentMoves(), move) {
+				t.Fatalf("opening %s has an illegal move %s", opening.Name, move)
+			}
+			game.MakeMove(move)
+		}
+	}
+}
+
+func TestIdentifyOpening(t *testing.T) {
+	tiger := makeOpening("Tiger", "f5 d6 c3 d3 c4 f4")
+
+	type Test struct {
+		first   string
+		moves   []Tile
+		expName string
+	}
+	tests := []Test{
+		{first: "f5", moves: tiger.Moves, expName: "Tiger"},
+		{first: "f5", moves: tiger.Moves[:3], expName: "Perpendicular"},
+		{first: "e6", moves: tiger.Moves, expName: "Tiger"},
+		{first: "c4", moves: tiger.Moves, expName: "Tiger"},
+		{first: "d3", moves: tiger.Moves, expName: "Tiger"},
+		{first: "f5", moves: tiger.Moves[:1], expName: UnknownOpening},
+		{first: "f5", moves: nil, expName: UnknownOpening},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			// each symmetry is its own inverse, so it also maps the f5 line to the line starting with the first move
+			game := OthelloGame{Board: MakeInitialBoard()}
+			for _, move := range test.moves {
+				tile := normalizeOpening(ParseTile(test.first), move)
+				assert.Contains(t, game.Board.FindCurrentMoves(), tile)
+				game.MakeMove(tile)
+			}
+			assert.Equal(t, test.expName, IdentifyOpening(game.MoveList))
+		})
+	}
+}
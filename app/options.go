@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"github.com/bwmarrin/discordgo"
+	"math"
+	"slices"
 	"strings"
 	"time"
 )
@@ -38,9 +40,8 @@ func getPlayerOpt(ctx context.Context, uc *UserCache, options []*discordgo.Appli
 	return opponent, nil
 }
 
-const DefaultLevel = 3
-
-func getLevelOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (uint64, error) {
+// getIntOpt reads an integer option within [lo, hi], ok is false when the option isn't provided
+func getIntOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string, lo int, hi int) (value int, ok bool, err error) {
 	var option *discordgo.ApplicationCommandInteractionDataOption
 	for _, opt := range options {
 		if opt.Name == name {
@@ -49,23 +50,74 @@ func getLevelOpt(options []*discordgo.ApplicationCommandInteractionDataOption, n
 		}
 	}
 	if option == nil {
-		return DefaultLevel, nil
+		return 0, false, nil
 	}
 
-	value, ok := option.Value.(float64)
+	expected := fmt.Sprintf("be an integer between %d and %d", lo, hi)
+	f, isFloat := option.Value.(float64)
+	if !isFloat || f != math.Trunc(f) {
+		return 0, true, OptionError{Name: name, InvalidValue: option.Value, ExpectedValue: expected}
+	}
+	value = int(f)
+	if value < lo || value > hi {
+		return 0, true, OptionError{Name: name, InvalidValue: value, ExpectedValue: expected}
+	}
+	return value, true, nil
+}
+
+const DefaultLevel = 3
+
+func getLevelOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (uint64, error) {
+	level, ok, err := getIntOpt(options, name, MinBotLevel, MaxBotLevel)
+	if err != nil {
+		return 0, err
+	}
 	if !ok {
-		return 0, OptionError{Name: name, InvalidValue: option.Value}
+		return DefaultLevel, nil
 	}
-	level := uint64(value)
-	if IsInvalidBotLevel(level) {
-		return 0, OptionError{Name: name, InvalidValue: level}
+	return uint64(level), nil
+}
+
+// getAnalysisLevelOpt reads a level like getLevelOpt but also accepts the analysis only levels up to MaxAnalysisLevel
+func getAnalysisLevelOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (uint64, error) {
+	level, ok, err := getIntOpt(options, name, MinBotLevel, MaxAnalysisLevel)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return DefaultLevel, nil
 	}
-	return level, nil
+	return uint64(level), nil
 }
 
 const DefaultDelay = time.Second * 2
 
 func getDelayOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (time.Duration, error) {
+	delay, ok, err := getIntOpt(options, name, MinDelay, MaxDelay)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return DefaultDelay, nil
+	}
+	return time.Second * time.Duration(delay), nil
+}
+
+func getSeedCountOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (int, error) {
+	count, ok, err := getIntOpt(options, name, 1, MaxSeedCount)
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, OptionError{Name: name, ExpectedValue: fmt.Sprintf("be an integer between %d and %d", 1, MaxSeedCount)}
+	}
+	return count, nil
+}
+
+const DefaultAnalysisTime = time.Minute * 2
+
+// getAnalysisTimeOpt reads a time limit in seconds, values out of range are clamped rather than rejected
+func getAnalysisTimeOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (time.Duration, error) {
 	var option *discordgo.ApplicationCommandInteractionDataOption
 	for _, opt := range options {
 		if opt.Name == name {
@@ -74,18 +126,92 @@ func getDelayOpt(options []*discordgo.ApplicationCommandInteractionDataOption, n
 		}
 	}
 	if option == nil {
-		return DefaultDelay, nil
+		return DefaultAnalysisTime, nil
 	}
 
 	value, ok := option.Value.(float64)
 	if !ok {
 		return 0, OptionError{Name: name, InvalidValue: option.Value}
 	}
-	delay := int(value)
-	if delay < MinDelay || delay > MaxDelay {
-		return 0, OptionError{Name: name, InvalidValue: delay}
+	secs := min(max(int(value), MinAnalysisTime), MaxAnalysisTime)
+	return time.Second * time.Duration(secs), nil
+}
+
+func getBoolOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (bool, error) {
+	var option *discordgo.ApplicationCommandInteractionDataOption
+	for _, opt := range options {
+		if opt.Name == name {
+			option = opt
+			break
+		}
+	}
+	if option == nil {
+		return false, nil
 	}
-	return time.Second * time.Duration(delay), nil
+
+	value, ok := option.Value.(bool)
+	if !ok {
+		return false, OptionError{Name: name, InvalidValue: option.Value}
+	}
+	return value, nil
+}
+
+func getMoveOrderOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (string, error) {
+	for _, opt := range options {
+		if opt.Name != name {
+			continue
+		}
+		value, ok := opt.Value.(string)
+		if !ok || !slices.Contains(MoveOrders, value) {
+			return "", OptionError{Name: name, InvalidValue: opt.Value, ExpectedValue: ExpectedMoveOrderValue}
+		}
+		return value, nil
+	}
+	return "", OptionError{Name: name, ExpectedValue: ExpectedMoveOrderValue}
+}
+
+func getScopeOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (string, error) {
+	for _, opt := range options {
+		if opt.Name != name {
+			continue
+		}
+		value, ok := opt.Value.(string)
+		if !ok || !slices.Contains(LeaderboardScopes, value) {
+			return "", OptionError{Name: name, InvalidValue: opt.Value, ExpectedValue: ExpectedScopeValue}
+		}
+		return value, nil
+	}
+	return "", nil
+}
+
+// getSideOpt reads the color a player picked to play as, defaulting to black
+func getSideOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (string, error) {
+	for _, opt := range options {
+		if opt.Name != name {
+			continue
+		}
+		value, ok := opt.Value.(string)
+		if !ok || !slices.Contains(BotChallengeSides, value) {
+			return "", OptionError{Name: name, InvalidValue: opt.Value, ExpectedValue: ExpectedSideValue}
+		}
+		return value, nil
+	}
+	return BlackSide, nil
+}
+
+// getOrientationOpt parses the side a board is drawn from, a board drawn from white's side is flipped
+func getOrientationOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (bool, error) {
+	for _, opt := range options {
+		if opt.Name != name {
+			continue
+		}
+		value, ok := opt.Value.(string)
+		if !ok || !slices.Contains(BoardOrientations, value) {
+			return false, OptionError{Name: name, InvalidValue: opt.Value, ExpectedValue: ExpectedOrientationValue}
+		}
+		return value == WhiteSide, nil
+	}
+	return false, nil
 }
 
 func getTileOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (Tile, string, error) {
@@ -128,3 +254,18 @@ func formatOptions(options []*discordgo.ApplicationCommandInteractionDataOption)
 	sb.WriteRune(']')
 	return sb.String()
 }
+
+// getBestOfOpt reads the number of games of a match, which must be one of the series lengths a match can be played over
+func getBestOfOpt(options []*discordgo.ApplicationCommandInteractionDataOption, name string) (int, error) {
+	value, ok, err := getIntOpt(options, name, slices.Min(MatchBestOfs), slices.Max(MatchBestOfs))
+	if err == nil && ok && !slices.Contains(MatchBestOfs, value) {
+		err = OptionError{Name: name, InvalidValue: value, ExpectedValue: ExpectedBestOfValue}
+	}
+	if err != nil {
+		return 0, err
+	}
+	if !ok {
+		return 0, OptionError{Name: name, ExpectedValue: ExpectedBestOfValue}
+	}
+	return value, nil
+}
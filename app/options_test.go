@@ -0,0 +1,213 @@
+package app
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetAnalysisTimeOpt(t *testing.T) {
+	type Test struct {
+		options []*discordgo.ApplicationCommandInteractionDataOption
+		expTime time.Duration
+	}
+	tests := []Test{
+		{options: nil, expTime: DefaultAnalysisTime},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "time", Value: float64(30)}}, expTime: time.Second * 30},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "time", Value: float64(1)}}, expTime: time.Second * MinAnalysisTime},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "time", Value: float64(10000)}}, expTime: time.Second * MaxAnalysisTime},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			searchTime, err := getAnalysisTimeOpt(test.options, "time")
+			assert.NoError(t, err)
+			assert.Equal(t, test.expTime, searchTime)
+		})
+	}
+}
+
+func TestGetScopeOpt(t *testing.T) {
+	type Test struct {
+		options  []*discordgo.ApplicationCommandInteractionDataOption
+		expScope string
+		expErr   error
+	}
+	tests := []Test{
+		{options: nil, expScope: ""},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "scope", Value: GlobalScope}}, expScope: GlobalScope},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "scope", Value: ServerScope}}, expScope: ServerScope},
+		{
+			options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "scope", Value: "planet"}},
+			expErr:  OptionError{Name: "scope", InvalidValue: "planet", ExpectedValue: ExpectedScopeValue},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			scope, err := getScopeOpt(test.options, "scope")
+			assert.Equal(t, test.expErr, err)
+			assert.Equal(t, test.expScope, scope)
+		})
+	}
+}
+
+func TestGetSideOpt(t *testing.T) {
+	type Test struct {
+		options []*discordgo.ApplicationCommandInteractionDataOption
+		expSide string
+		expErr  error
+	}
+	tests := []Test{
+		{options: nil, expSide: BlackSide},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "color", Value: WhiteSide}}, expSide: WhiteSide},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "color", Value: RandomSide}}, expSide: RandomSide},
+		{
+			options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "color", Value: "green"}},
+			expErr:  OptionError{Name: "color", InvalidValue: "green", ExpectedValue: ExpectedSideValue},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			side, err := getSideOpt(test.options, "color")
+			assert.Equal(t, test.expErr, err)
+			assert.Equal(t, test.expSide, side)
+		})
+	}
+}
+
+func TestGetOrientationOpt(t *testing.T) {
+	type Test struct {
+		options []*discordgo.ApplicationCommandInteractionDataOption
+		expFlip bool
+		expErr  error
+	}
+	tests := []Test{
+		{options: nil, expFlip: false},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "side", Value: BlackSide}}, expFlip: false},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "side", Value: WhiteSide}}, expFlip: true},
+		{
+			options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "side", Value: RandomSide}},
+			expErr:  OptionError{Name: "side", InvalidValue: RandomSide, ExpectedValue: ExpectedOrientationValue},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			flip, err := getOrientationOpt(test.options, "side")
+			assert.Equal(t, test.expErr, err)
+			assert.Equal(t, test.expFlip, flip)
+		})
+	}
+}
+
+func TestGetBestOfOpt(t *testing.T) {
+	type Test struct {
+		options   []*discordgo.ApplicationCommandInteractionDataOption
+		expBestOf int
+		expErr    bool
+	}
+	tests := []Test{
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "best-of", Value: 3.0}}, expBestOf: 3},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "best-of", Value: 7.0}}, expBestOf: 7},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "best-of", Value: 4.0}}, expErr: true},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "best-of", Value: 9.0}}, expErr: true},
+		{options: nil, expErr: true},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			bestOf, err := getBestOfOpt(test.options, "best-of")
+			if test.expErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expBestOf, bestOf)
+		})
+	}
+}
+
+func TestGetLevelOpt(t *testing.T) {
+	type Test struct {
+		options  []*discordgo.ApplicationCommandInteractionDataOption
+		expLevel uint64
+		expErr   error
+	}
+	expected := fmt.Sprintf("be an integer between %d and %d", MinBotLevel, MaxBotLevel)
+	tests := []Test{
+		{options: nil, expLevel: DefaultLevel},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "level", Value: float64(1)}}, expLevel: 1},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "level", Value: float64(5)}}, expLevel: 5},
+		{
+			options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "level", Value: float64(0)}},
+			expErr:  OptionError{Name: "level", InvalidValue: 0, ExpectedValue: expected},
+		},
+		{
+			options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "level", Value: float64(6)}},
+			expErr:  OptionError{Name: "level", InvalidValue: 6, ExpectedValue: expected},
+		},
+		{
+			options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "level", Value: float64(-1)}},
+			expErr:  OptionError{Name: "level", InvalidValue: -1, ExpectedValue: expected},
+		},
+		{
+			options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "level", Value: "3"}},
+			expErr:  OptionError{Name: "level", InvalidValue: "3", ExpectedValue: expected},
+		},
+		{
+			options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "level", Value: 2.5}},
+			expErr:  OptionError{Name: "level", InvalidValue: 2.5, ExpectedValue: expected},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			level, err := getLevelOpt(test.options, "level")
+			if test.expErr != nil {
+				assert.Equal(t, test.expErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expLevel, level)
+		})
+	}
+}
+
+func TestGetAnalysisLevelOpt(t *testing.T) {
+	type Test struct {
+		options  []*discordgo.ApplicationCommandInteractionDataOption
+		expLevel uint64
+		expErr   error
+	}
+	expected := fmt.Sprintf("be an integer between %d and %d", MinBotLevel, MaxAnalysisLevel)
+	tests := []Test{
+		{options: nil, expLevel: DefaultLevel},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "level", Value: float64(5)}}, expLevel: 5},
+		{options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "level", Value: float64(8)}}, expLevel: 8},
+		{
+			options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "level", Value: float64(9)}},
+			expErr:  OptionError{Name: "level", InvalidValue: 9, ExpectedValue: expected},
+		},
+		{
+			options: []*discordgo.ApplicationCommandInteractionDataOption{{Name: "level", Value: float64(0)}},
+			expErr:  OptionError{Name: "level", InvalidValue: 0, ExpectedValue: expected},
+		},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			level, err := getAnalysisLevelOpt(test.options, "level")
+			if test.expErr != nil {
+				assert.Equal(t, test.expErr, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expLevel, level)
+		})
+	}
+}
@@ -14,6 +14,9 @@ import (
 const MinBotLevel = 1
 const MaxBotLevel = 5
 
+// MaxAnalysisLevel is above MaxBotLevel, the levels in between can only be used to analyze and not to play against
+const MaxAnalysisLevel = 8
+
 type Player struct {
 	ID    string
 	Name  string
@@ -56,6 +59,19 @@ func LevelToDepth(level uint64) uint64 {
 	return 0
 }
 
+// AnalysisLevelToDepth extends the playable levels with deeper levels that are only used for analysis
+func AnalysisLevelToDepth(level uint64) uint64 {
+	switch level {
+	case 6:
+		return 24
+	case 7:
+		return 28
+	case 8:
+		return 32
+	}
+	return LevelToDepth(level)
+}
+
 func (player Player) LevelToDepth() uint64 {
 	return LevelToDepth(player.Level)
 }
@@ -68,6 +84,11 @@ func (player Player) IsBot() bool {
 	return player.Level != 0
 }
 
+// Equal compares players by id, names are display only and may be stale or missing
+func (player Player) Equal(other Player) bool {
+	return player.ID == other.ID
+}
+
 func IsInvalidBotLevel(level uint64) bool {
 	return level < MinBotLevel || level > MaxBotLevel
 }
@@ -45,3 +45,10 @@ func TestUserCache_GetUsername(t *testing.T) {
 	assert.NotNil(t, user)
 	assert.Equal(t, discordgo.User{ID: "id1", Username: "Player1"}, user.Value())
 }
+
+func TestPlayer_Equal(t *testing.T) {
+	assert.True(t, Player{ID: "id1", Name: "Player1"}.Equal(Player{ID: "id1", Name: "Renamed"}))
+	assert.True(t, Player{ID: "id1"}.Equal(Player{ID: "id1", Name: "Player1"}))
+	assert.False(t, Player{ID: "id1", Name: "Player1"}.Equal(Player{ID: "id2", Name: "Player1"}))
+	assert.True(t, MakePlayer("3", "").Equal(MakeBotPlayer(3)))
+}
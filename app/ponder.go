@@ -0,0 +1,128 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"github.com/jellydator/ttlcache/v3"
+)
+
+const PonderTtl = time.Minute * 5
+const MaxPonders = 1 // pondering shares the engine with real requests, so only a few searches may run in the background
+
+type ponderResult struct {
+	done chan struct{}
+	resp MoveResp
+}
+
+// IdleReporter is an engine that can tell whether a search would start right away or wait behind other searches
+type IdleReporter interface {
+	HasIdleWorker() bool
+}
+
+// PonderShell wraps an engine to search the position after the opponent's expected reply while they are thinking,
+// the result is used for the next bot move if the opponent plays the expected reply
+type PonderShell struct {
+	EngineShell
+	results *ttlcache.Cache[string, *ponderResult]
+	cancels *ttlcache.Cache[string, context.CancelFunc]
+	sem     chan struct{}
+}
+
+func MakePonderShell(sh EngineShell) *PonderShell {
+	return &PonderShell{
+		EngineShell: sh,
+		results:     ttlcache.New[string, *ponderResult](ttlcache.WithTTL[string, *ponderResult](PonderTtl)),
+		cancels:     ttlcache.New[string, context.CancelFunc](ttlcache.WithTTL[string, context.CancelFunc](PonderTtl)),
+		sem:         make(chan struct{}, MaxPonders),
+	}
+}
+
+func ponderKey(board OthelloBoard, depth uint64) string {
+	return fmt.Sprintf("%s,%d", board.MarshalString(), depth)
+}
+
+func (ps *PonderShell) FindBestMove(game OthelloGame, depth uint64) chan MoveResp {
+	item := ps.results.Get(ponderKey(game.Board, depth))
+	if item == nil {
+		// the opponent didn't play the expected reply, so the ponder for this game is no longer useful
+		ps.CancelPonder(game.ID)
+		return ps.EngineShell.FindBestMove(game, depth)
+	}
+	ps.results.Delete(item.Key())
+	slog.Info("ponder hit", "gameID", game.ID, "key", item.Key())
+
+	ch := make(chan MoveResp, 1)
+	go func() {
+		result := item.Value()
+		<-result.done
+		ch <- result.resp
+	}()
+	return ch
+}
+
+// FindRankedMovesContext ranks the moves with the wrapped engine, which is only cancelled if the wrapped engine can be
+func (ps *PonderShell) FindRankedMovesContext(ctx context.Context, game OthelloGame, depth uint64) chan MoveResp {
+	return findRankedMovesContext(ctx, ps.EngineShell, game, depth)
+}
+
+func (ps *PonderShell) CancelPonder(gameID string) {
+	if item := ps.cancels.Get(gameID); item != nil {
+		item.Value()()
+		ps.cancels.Delete(gameID)
+	}
+}
+
+// hasIdleWorker checks if the engine can search without holding up a real request, cancelling a ponder doesn't stop a
+// search the engine already started so a ponder only starts its searches on an idle worker
+func (ps *PonderShell) hasIdleWorker() bool {
+	ir, ok := ps.EngineShell.(IdleReporter)
+	return !ok || ir.HasIdleWorker()
+}
+
+// Ponder searches the bot's reply to the opponent's expected move in the background, the search is skipped if too many
+// ponders are already running or the engine has no idle worker
+func (ps *PonderShell) Ponder(game OthelloGame, depth uint64) {
+	if !ps.hasIdleWorker() {
+		slog.Info("skipped ponder, no idle engine worker", "gameID", game.ID)
+		return
+	}
+	select {
+	case ps.sem <- struct{}{}:
+	default:
+		slog.Info("skipped ponder, too many ponders running", "gameID", game.ID)
+		return
+	}
+
+	game.MoveList = slices.Clone(game.MoveList) // the game is played forward in the background
+	ctx, cancel := context.WithTimeout(context.Background(), PonderTtl)
+	ps.cancels.Set(game.ID, cancel, ttlcache.DefaultTTL)
+
+	go func() {
+		defer func() { <-ps.sem }()
+		defer cancel()
+
+		if !game.HasMoves() {
+			return
+		}
+		reply := <-ps.EngineShell.FindBestMove(game, AllocateDepth(game.Board, depth))
+		if reply.Err != nil || len(reply.Moves) == 0 || ctx.Err() != nil {
+			return
+		}
+		game.MakeMove(reply.Moves[0].Tile)
+		if !game.HasMoves() || !ps.hasIdleWorker() {
+			return
+		}
+
+		botDepth := AllocateDepth(game.Board, depth)
+		result := &ponderResult{done: make(chan struct{})}
+		ps.results.Set(ponderKey(game.Board, botDepth), result, ttlcache.DefaultTTL)
+
+		result.resp = <-ps.EngineShell.FindBestMove(game, botDepth)
+		close(result.done)
+		slog.Info("pondered expected reply", "gameID", game.ID, "reply", reply.Moves[0], "resp", result.resp.Moves)
+	}()
+}
@@ -0,0 +1,68 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPonderShell_Hit(t *testing.T) {
+	le := MakeLocalEngineShell()
+	ps := MakePonderShell(le)
+
+	board, moveList := RandomBoard(20)
+	game := OthelloGame{ID: "1", WhitePlayer: MakeBotPlayer(1), BlackPlayer: Player{ID: "id1"}, Board: board, MoveList: moveList}
+	const depth = 4
+
+	ps.Ponder(game, depth)
+
+	// wait for the ponder to release its slot
+	select {
+	case ps.sem <- struct{}{}:
+		<-ps.sem
+	case <-time.After(time.Second * 10):
+		t.Fatal("ponder did not finish before timeout")
+	}
+
+	// play the expected reply, the search for the bot's next move should be served from the ponder
+	reply := <-le.FindBestMove(game, AllocateDepth(game.Board, depth))
+	game.MakeMove(reply.Moves[0].Tile)
+
+	nodesBefore := le.Nodes()
+	resp := <-ps.FindBestMove(game, AllocateDepth(game.Board, depth))
+	assert.NoError(t, resp.Err)
+	assert.Equal(t, nodesBefore, le.Nodes())
+
+	// a miss searches the position with the engine
+	resp = <-ps.FindBestMove(game, AllocateDepth(game.Board, depth))
+	assert.NoError(t, resp.Err)
+	assert.Greater(t, le.Nodes(), nodesBefore)
+}
+
+// MockBusyShell is an engine whose workers are all busy, it counts the searches it's asked for
+type MockBusyShell struct {
+	MockEngineShell
+	searches int
+}
+
+func (mock *MockBusyShell) HasIdleWorker() bool {
+	return false
+}
+
+func (mock *MockBusyShell) FindBestMove(game OthelloGame, depth uint64) chan MoveResp {
+	mock.searches++
+	return mock.MockEngineShell.FindBestMove(game, depth)
+}
+
+func TestPonderShell_NoIdleWorker(t *testing.T) {
+	sh := &MockBusyShell{}
+	ps := MakePonderShell(sh)
+
+	game := OthelloGame{ID: "1", WhitePlayer: MakeBotPlayer(1), BlackPlayer: Player{ID: "id1"}, Board: MakeInitialBoard()}
+	ps.Ponder(game, 4)
+
+	// the ponder is skipped before it takes a slot or asks the engine for a search
+	assert.Empty(t, ps.sem)
+	assert.Equal(t, 0, sh.searches)
+}
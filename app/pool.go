@@ -0,0 +1,96 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"sync"
+
+	"go.uber.org/atomic"
+)
+
+const DefaultEngineWorkers = 1
+const MaxEngineWorkers = 8
+
+// EnginePool runs several ntest processes that take requests from a shared channel, so a request goes to the first
+// free process and a long analysis only holds up the process running it
+type EnginePool struct {
+	shells    []*NTestShell
+	moveReqCh chan MoveReq
+	busy      atomic.Int32
+}
+
+func StartEnginePool(path string, size int) (*EnginePool, error) {
+	pool := &EnginePool{moveReqCh: make(chan MoveReq)}
+	for i := 0; i < size; i++ {
+		sh, err := StartNTestShell(path)
+		if err != nil {
+			_ = pool.Close()
+			return nil, fmt.Errorf("failed to start ntest worker %d: %w", i, err)
+		}
+		sh.moveReqCh = pool.moveReqCh
+		sh.busy = &pool.busy
+		pool.shells = append(pool.shells, sh)
+	}
+	slog.Info("started engine pool", "path", path, "size", size)
+	return pool, nil
+}
+
+// ListenRequests handles requests on every process, each process takes the next request once it is free
+func (pool *EnginePool) ListenRequests() {
+	var wg sync.WaitGroup
+	for _, sh := range pool.shells {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sh.ListenRequests()
+		}()
+	}
+	wg.Wait()
+}
+
+// HasIdleWorker checks if a process is free to start a search right away
+func (pool *EnginePool) HasIdleWorker() bool {
+	return int(pool.busy.Load()) < len(pool.shells)
+}
+
+func (pool *EnginePool) FindBestMove(game OthelloGame, depth uint64) chan MoveResp {
+	ch := make(chan MoveResp, 1)
+	pool.moveReqCh <- MoveReq{Kind: BestMoveKind, Game: game, Depth: depth, RespCh: ch}
+	return ch
+}
+
+func (pool *EnginePool) FindRankedMoves(game OthelloGame, depth uint64) chan MoveResp {
+	ch := make(chan MoveResp, 1)
+	pool.moveReqCh <- MoveReq{Kind: RankMovesKind, Game: game, Depth: depth, RespCh: ch}
+	return ch
+}
+
+func (pool *EnginePool) FindRankedMovesContext(ctx context.Context, game OthelloGame, depth uint64) chan MoveResp {
+	return sendMoveReq(ctx, pool.moveReqCh, MoveReq{Kind: RankMovesKind, Game: game, Depth: depth, Ctx: ctx})
+}
+
+// Close stops every process, the request channel stays open so requests made while shutting down fail instead of
+// panicking on a closed channel
+func (pool *EnginePool) Close() error {
+	var errs []error
+	for _, sh := range pool.shells {
+		errs = append(errs, sh.Close())
+	}
+	return errors.Join(errs...)
+}
+
+// ParseEngineWorkers parses the number of ntest processes to run, an empty or invalid size uses the default
+func ParseEngineWorkers(str string) int {
+	if str == "" {
+		return DefaultEngineWorkers
+	}
+	size, err := strconv.Atoi(str)
+	if err != nil || size < 1 || size > MaxEngineWorkers {
+		slog.Warn("invalid engine workers, using the default", "workers", str, "default", DefaultEngineWorkers, "max", MaxEngineWorkers)
+		return DefaultEngineWorkers
+	}
+	return size
+}
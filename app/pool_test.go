@@ -0,0 +1,60 @@
+package app
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEnginePool_ConcurrentRequests(t *testing.T) {
+	game := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(2), Board: MakeInitialBoard()}
+
+	pool := &EnginePool{moveReqCh: make(chan MoveReq)}
+	for i := 0; i < 2; i++ {
+		sh, reader := stuckShell(EngineTimeout)
+		defer reader.Close()
+		sh.moveReqCh = pool.moveReqCh
+		pool.shells = append(pool.shells, sh)
+	}
+	go pool.ListenRequests()
+
+	// the first request holds up one process, the second is taken by the other process instead of waiting
+	respCh1 := pool.FindRankedMoves(game, 5)
+	sent := make(chan chan MoveResp)
+	go func() {
+		sent <- pool.FindBestMove(game, 5)
+	}()
+
+	var respCh2 chan MoveResp
+	select {
+	case respCh2 = <-sent:
+	case <-time.After(time.Second):
+		t.Fatal("second request waited for the first process")
+	}
+
+	// closing the pool ends both requests with an error, and neither process is restarted
+	assert.NoError(t, pool.Close())
+	for _, sh := range pool.shells {
+		_ = sh.stdoutPipe.Close()
+	}
+	for _, respCh := range []chan MoveResp{respCh1, respCh2} {
+		select {
+		case resp := <-respCh:
+			assert.ErrorIs(t, resp.Err, ErrEngineDied)
+		case <-time.After(time.Second):
+			t.Fatal("request hung after the pool was closed")
+		}
+	}
+	for _, sh := range pool.shells {
+		assert.False(t, sh.isRestarting())
+	}
+}
+
+func TestParseEngineWorkers(t *testing.T) {
+	assert.Equal(t, DefaultEngineWorkers, ParseEngineWorkers(""))
+	assert.Equal(t, 4, ParseEngineWorkers("4"))
+	assert.Equal(t, DefaultEngineWorkers, ParseEngineWorkers("four"))
+	assert.Equal(t, DefaultEngineWorkers, ParseEngineWorkers("0"))
+	assert.Equal(t, DefaultEngineWorkers, ParseEngineWorkers("100"))
+}
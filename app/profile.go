@@ -0,0 +1,82 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// FormGames is how many of the latest games are shown in a player's form
+const FormGames = 10
+
+type Profile struct {
+	Form   string // results of the latest games from oldest to newest as W, L or D
+	Streak int    // the latest consecutive games with the same result as the newest game
+}
+
+// StreakResult is the result the player's current streak is made of, or 0 if the player hasn't finished a game
+func (p Profile) StreakResult() byte {
+	if p.Form == "" {
+		return 0
+	}
+	return p.Form[len(p.Form)-1]
+}
+
+func (p Profile) FormatStreak() string {
+	if p.Streak == 0 {
+		return "No games yet"
+	}
+	return fmt.Sprintf("%c%d", p.StreakResult(), p.Streak)
+}
+
+func (p Profile) FormatForm() string {
+	if p.Form == "" {
+		return "No games yet"
+	}
+	return p.Form
+}
+
+// profileResultExpr is a game result as W, L or D from the perspective of the player bound to $1
+const profileResultExpr = "CASE WHEN is_draw THEN 'D' WHEN winner_id = $1 THEN 'W' ELSE 'L' END"
+
+// ReadProfile reads the player's form and streak from their game results, results are stored by player and not by
+// ladder so they cover games on every ladder
+func ReadProfile(ctx context.Context, db *sqlx.DB, playerID string) (Profile, error) {
+	trace := ctx.Value(TraceKey)
+
+	var results []string
+	err := db.SelectContext(ctx, &results,
+		"SELECT "+profileResultExpr+" FROM game_results WHERE winner_id = $1 OR loser_id = $1 ORDER BY finished_time DESC LIMIT $2;",
+		playerID, FormGames,
+	)
+	if err != nil {
+		slog.Error("failed to read profile", "trace", trace, "playerID", playerID, "err", err)
+		return Profile{}, fmt.Errorf("failed to select game results: %w", err)
+	}
+
+	var profile Profile
+	if len(results) == 0 {
+		slog.Info("selected profile", "trace", trace, "playerID", playerID, "profile", profile)
+		return profile, nil
+	}
+	for _, result := range results {
+		profile.Form = result + profile.Form
+	}
+
+	// a streak can be longer than the form, so it counts the games since the latest game with another result
+	err = db.GetContext(ctx, &profile.Streak,
+		`SELECT COUNT(*) FROM game_results WHERE (winner_id = $1 OR loser_id = $1) AND finished_time > COALESCE((
+			SELECT MAX(finished_time) FROM game_results WHERE (winner_id = $1 OR loser_id = $1) AND `+profileResultExpr+` != $2
+		), -1);`,
+		playerID, results[0],
+	)
+	if err != nil {
+		slog.Error("failed to read profile streak", "trace", trace, "playerID", playerID, "err", err)
+		return Profile{}, fmt.Errorf("failed to count streak: %w", err)
+	}
+
+	slog.Info("selected profile", "trace", trace, "playerID", playerID, "profile", profile)
+	return profile, nil
+}
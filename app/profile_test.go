@@ -0,0 +1,83 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadProfile(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-read-profile")
+
+	rows := []GameResultRow{
+		{WinnerID: "id1", LoserID: "id2"},
+		{WinnerID: "id2", LoserID: "id1"},
+		{WinnerID: "id1", LoserID: "id2", IsDraw: true},
+		{WinnerID: "id3", LoserID: "id4"},
+		{WinnerID: "id3", LoserID: "id4", IsDraw: true},
+		{WinnerID: "id4", LoserID: "id3"},
+		{WinnerID: "id4", LoserID: "id3"},
+	}
+	for range 11 {
+		rows = append(rows, GameResultRow{WinnerID: "id1", LoserID: "id2"})
+	}
+	for i, row := range rows {
+		row.GameID = fmt.Sprintf("game%d", i)
+		row.FinishedTime = int64(i)
+		if err := InsertGameResult(ctx, db, row); err != nil {
+			t.Fatalf("failed to insert game result: %v", err)
+		}
+	}
+
+	type Test struct {
+		playerID   string
+		expProfile Profile
+		expStreak  string
+	}
+	tests := []Test{
+		{playerID: "id1", expProfile: Profile{Form: "WWWWWWWWWW", Streak: 11}, expStreak: "W11"},
+		{playerID: "id2", expProfile: Profile{Form: "LLLLLLLLLL", Streak: 11}, expStreak: "L11"},
+		{playerID: "id3", expProfile: Profile{Form: "WDLL", Streak: 2}, expStreak: "L2"},
+		{playerID: "id4", expProfile: Profile{Form: "LDWW", Streak: 2}, expStreak: "W2"},
+		{playerID: "id5", expProfile: Profile{}, expStreak: "No games yet"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			profile, err := ReadProfile(ctx, db, test.playerID)
+			if err != nil {
+				t.Fatalf("failed to read profile: %v", err)
+			}
+			assert.Equal(t, test.expProfile, profile)
+			assert.Equal(t, test.expStreak, profile.FormatStreak())
+		})
+	}
+}
+
+func TestUpdateStats_PeakElo(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-peak-elo")
+
+	player1 := Player{ID: "id1"}
+	player2 := Player{ID: "id2"}
+	for _, gr := range []GameResult{{Winner: player1, Loser: player2}, {Winner: player2, Loser: player1}} {
+		if _, err := UpdateStats(ctx, db, "", gr); err != nil {
+			t.Fatalf("failed to update stats: %v", err)
+		}
+	}
+
+	stats, err := GetStats(ctx, db, "id1")
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+	// the peak is kept after the rating drops from a loss
+	assert.Less(t, stats.Elo, stats.PeakElo)
+	assert.Equal(t, 1520.0, stats.PeakElo)
+}
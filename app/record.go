@@ -0,0 +1,173 @@
+package app
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+type GameResultRow struct {
+	GameID       string `db:"game_id"`
+	WinnerID     string `db:"winner_id"`
+	LoserID      string `db:"loser_id"`
+	IsDraw       bool   `db:"is_draw"`
+	FinishedTime int64  `db:"finished_time"`
+	Moves        string `db:"moves"`     // the move list of the finished game, empty for games finished before it was stored
+	ServerID     string `db:"server_id"` // the guild the game was played in, empty for games finished before it was stored
+}
+
+func InsertGameResult(ctx context.Context, q CtxQuerier, row GameResultRow) error {
+	_, err := q.ExecContext(ctx,
+		"INSERT INTO game_results (game_id, winner_id, loser_id, is_draw, finished_time, moves, server_id) VALUES ($1, $2, $3, $4, $5, $6, $7);",
+		row.GameID, row.WinnerID, row.LoserID, row.IsDraw, row.FinishedTime, row.Moves, row.ServerID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to insert game result: %w", err)
+	}
+	return nil
+}
+
+// recordGameResult stores the result for head-to-head records, games against yourself and forfeits by a player who
+// wasn't in the game have no opponent and are not stored. Aborted games weren't decided by the players, so they aren't
+// stored as draws either
+func recordGameResult(ctx context.Context, q CtxQuerier, game OthelloGame, gr GameResult, finishedTime time.Time) error {
+	if gr.Winner.ID == "" || gr.Loser.ID == "" || gr.Winner.ID == gr.Loser.ID || gr.Kind == Aborted {
+		return nil
+	}
+	row := GameResultRow{
+		GameID:       game.ID,
+		WinnerID:     gr.Winner.ID,
+		LoserID:      gr.Loser.ID,
+		IsDraw:       gr.IsDraw,
+		FinishedTime: finishedTime.UnixMilli(),
+		Moves:        MarshalMoveList(game.MoveList),
+		ServerID:     game.ServerID,
+	}
+	return InsertGameResult(ctx, q, row)
+}
+
+var ErrNoFinishedGame = errors.New("player has no finished games")
+
+// GetLatestGameResult finds the result of the player's most recently finished game
+func GetLatestGameResult(ctx context.Context, db *sqlx.DB, playerID string) (GameResultRow, error) {
+	trace := ctx.Value(TraceKey)
+
+	var row GameResultRow
+	err := db.GetContext(ctx, &row,
+		`SELECT game_id, winner_id, loser_id, is_draw, finished_time, moves, server_id FROM game_results
+		WHERE winner_id = $1 OR loser_id = $1 ORDER BY finished_time DESC, rowid DESC LIMIT 1;`,
+		playerID,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return GameResultRow{}, ErrNoFinishedGame
+	}
+	if err != nil {
+		slog.Error("failed to get latest game result", "trace", trace, "playerID", playerID, "err", err)
+		return GameResultRow{}, fmt.Errorf("failed to select latest game result: %w", err)
+	}
+
+	slog.Info("selected latest game result", "trace", trace, "playerID", playerID, "gameID", row.GameID)
+	return row, nil
+}
+
+// ReadHeadToHead counts the results of every game between two players from the perspective of playerA, bots are
+// keyed by their level so a record against a bot covers every game against that level
+func ReadHeadToHead(ctx context.Context, db *sqlx.DB, playerA, playerB string) (wins, losses, draws int, err error) {
+	trace := ctx.Value(TraceKey)
+
+	var record struct {
+		Wins   int `db:"wins"`
+		Losses int `db:"losses"`
+		Draws  int `db:"draws"`
+	}
+	err = db.GetContext(ctx, &record,
+		`SELECT
+			COALESCE(SUM(CASE WHEN NOT is_draw AND winner_id = $1 THEN 1 ELSE 0 END), 0) AS wins,
+			COALESCE(SUM(CASE WHEN NOT is_draw AND winner_id = $2 THEN 1 ELSE 0 END), 0) AS losses,
+			COALESCE(SUM(CASE WHEN is_draw THEN 1 ELSE 0 END), 0) AS draws
+		FROM game_results WHERE (winner_id = $1 AND loser_id = $2) OR (winner_id = $2 AND loser_id = $1);`,
+		playerA, playerB,
+	)
+	if err != nil {
+		slog.Error("failed to read head to head", "trace", trace, "playerA", playerA, "playerB", playerB, "err", err)
+		return 0, 0, 0, fmt.Errorf("failed to select head to head: %w", err)
+	}
+
+	slog.Info("selected head to head", "trace", trace, "playerA", playerA, "playerB", playerB, "record", record)
+	return record.Wins, record.Losses, record.Draws, nil
+}
+
+// BotPerformance is a bot level's record against humans, counted from the bot's perspective
+type BotPerformance struct {
+	Level  uint64
+	Wins   int
+	Losses int
+	Draws  int
+}
+
+func (p BotPerformance) Games() int {
+	return p.Wins + p.Losses + p.Draws
+}
+
+func (p BotPerformance) WinRate() string {
+	wr := 0.0
+	if p.Games() > 0 {
+		wr = float64(p.Wins) / float64(p.Games())
+	}
+	return fmt.Sprintf("%0.2f%%", wr*100)
+}
+
+// ReadBotPerformance counts the results of every game between a human and a bot for each bot level, levels without
+// games are included so the levels can be compared side by side
+func ReadBotPerformance(ctx context.Context, db *sqlx.DB) ([]BotPerformance, error) {
+	trace := ctx.Value(TraceKey)
+
+	botIDs := make([]string, 0, MaxBotLevel-MinBotLevel+1)
+	for level := uint64(MinBotLevel); level <= MaxBotLevel; level++ {
+		botIDs = append(botIDs, MakeBotPlayer(level).ID)
+	}
+
+	query, args, err := sqlx.In(
+		"SELECT game_id, winner_id, loser_id, is_draw, finished_time FROM game_results WHERE winner_id IN (?) OR loser_id IN (?);",
+		botIDs, botIDs,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build bot performance query: %w", err)
+	}
+
+	var rows []GameResultRow
+	if err := db.SelectContext(ctx, &rows, db.Rebind(query), args...); err != nil {
+		slog.Error("failed to read bot performance", "trace", trace, "err", err)
+		return nil, fmt.Errorf("failed to select bot game results: %w", err)
+	}
+
+	performances := make([]BotPerformance, len(botIDs))
+	for i := range performances {
+		performances[i].Level = uint64(MinBotLevel + i)
+	}
+	for _, row := range rows {
+		winner := MakePlayer(row.WinnerID, "")
+		loser := MakePlayer(row.LoserID, "")
+		if winner.IsBot() == loser.IsBot() {
+			continue
+		}
+		switch {
+		case row.IsDraw && winner.IsBot():
+			performances[winner.Level-MinBotLevel].Draws++
+		case row.IsDraw:
+			performances[loser.Level-MinBotLevel].Draws++
+		case winner.IsBot():
+			performances[winner.Level-MinBotLevel].Wins++
+		default:
+			performances[loser.Level-MinBotLevel].Losses++
+		}
+	}
+
+	slog.Info("selected bot performance", "trace", trace, "performances", performances)
+	return performances, nil
+}
@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadHeadToHead(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-read-head-to-head")
+
+	player1 := Player{ID: "id1", Name: "Player1"}
+	player2 := Player{ID: "id2", Name: "Player2"}
+	bot := MakeBotPlayer(3)
+
+	results := []GameResult{
+		{Winner: player1, Loser: player2},
+		{Winner: player2, Loser: player1},
+		{Winner: player1, Loser: player2, Kind: Forfeit},
+		{Winner: player2, Loser: player1, IsDraw: true},
+		{Winner: bot, Loser: player1, Kind: Timeout},
+		{Winner: player1, Loser: player1},
+		{Winner: player1, Loser: player2, IsDraw: true, Kind: Aborted},
+	}
+	for _, gr := range results {
+		game := OthelloGame{ID: "1", Board: MakeInitialBoard(), BlackPlayer: gr.Winner, WhitePlayer: gr.Loser}
+		if _, err := GameOverTx(ctx, db, game, gr); err != nil {
+			t.Fatalf("failed to finish game: %v", err)
+		}
+	}
+
+	type Test struct {
+		playerA   string
+		playerB   string
+		expWins   int
+		expLosses int
+		expDraws  int
+	}
+	tests := []Test{
+		{playerA: "id1", playerB: "id2", expWins: 2, expLosses: 1, expDraws: 1},
+		{playerA: "id2", playerB: "id1", expWins: 1, expLosses: 2, expDraws: 1},
+		{playerA: "id1", playerB: bot.ID, expWins: 0, expLosses: 1, expDraws: 0},
+		{playerA: "id1", playerB: "id1"},
+		{playerA: "id1", playerB: "id5"},
+	}
+
+	for _, test := range tests {
+		wins, losses, draws, err := ReadHeadToHead(ctx, db, test.playerA, test.playerB)
+		if err != nil {
+			t.Fatalf("failed to read head to head: %v", err)
+		}
+		assert.Equal(t, test.expWins, wins)
+		assert.Equal(t, test.expLosses, losses)
+		assert.Equal(t, test.expDraws, draws)
+	}
+}
+
+func TestReadBotPerformance(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-read-bot-performance")
+
+	player1 := Player{ID: "id1", Name: "Player1"}
+	player2 := Player{ID: "id2", Name: "Player2"}
+	bot1 := MakeBotPlayer(1)
+	bot3 := MakeBotPlayer(3)
+
+	results := []GameResult{
+		{Winner: player1, Loser: bot1},
+		{Winner: bot1, Loser: player2},
+		{Winner: bot3, Loser: player1, Kind: Timeout},
+		{Winner: player2, Loser: bot3, IsDraw: true},
+		{Winner: bot3, Loser: player2},
+		{Winner: player1, Loser: player2},
+	}
+	for _, gr := range results {
+		game := OthelloGame{ID: "1", Board: MakeInitialBoard(), BlackPlayer: gr.Winner, WhitePlayer: gr.Loser}
+		if _, err := GameOverTx(ctx, db, game, gr); err != nil {
+			t.Fatalf("failed to finish game: %v", err)
+		}
+	}
+
+	performances, err := ReadBotPerformance(ctx, db)
+	if err != nil {
+		t.Fatalf("failed to read bot performance: %v", err)
+	}
+
+	expPerformances := []BotPerformance{
+		{Level: 1, Wins: 1, Losses: 1},
+		{Level: 2},
+		{Level: 3, Wins: 2, Draws: 1},
+		{Level: 4},
+		{Level: 5},
+	}
+	assert.Equal(t, expPerformances, performances)
+	assert.Equal(t, "50.00%", performances[0].WinRate())
+	assert.Equal(t, "0.00%", performances[1].WinRate())
+}
+
+func TestGetLatestGameResult(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-get-latest-game-result")
+
+	player1 := Player{ID: "id1", Name: "Player1"}
+	player2 := Player{ID: "id2", Name: "Player2"}
+
+	_, err := GetLatestGameResult(ctx, db, player1.ID)
+	assert.ErrorIs(t, err, ErrNoFinishedGame)
+
+	moves, err := UnmarshalMoveList("f5,f6,e6,f4")
+	if err != nil {
+		t.Fatalf("failed to unmarshal moves: %v", err)
+	}
+	games := []OthelloGame{
+		{ID: "1", Board: MakeInitialBoard(), BlackPlayer: player1, WhitePlayer: player2},
+		{ID: "2", Board: MakeInitialBoard(), BlackPlayer: player2, WhitePlayer: player1, MoveList: moves, ServerID: "guild1"},
+	}
+	for _, game := range games {
+		if _, err := GameOverTx(ctx, db, game, GameResult{Winner: game.BlackPlayer, Loser: game.WhitePlayer}); err != nil {
+			t.Fatalf("failed to finish game: %v", err)
+		}
+	}
+
+	row, err := GetLatestGameResult(ctx, db, player1.ID)
+	if err != nil {
+		t.Fatalf("failed to get latest game result: %v", err)
+	}
+	assert.Equal(t, "2", row.GameID)
+	assert.Equal(t, player2.ID, row.WinnerID)
+	assert.Equal(t, MarshalMoveList(moves), row.Moves)
+	assert.Equal(t, "guild1", row.ServerID)
+
+	_, err = GetLatestGameResult(ctx, db, "id3")
+	assert.ErrorIs(t, err, ErrNoFinishedGame)
+}
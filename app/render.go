@@ -1,31 +1,44 @@
 package app
 
 import (
+	"bytes"
 	_ "embed"
 	"fmt"
 	"github.com/golang/freetype/truetype"
+	"github.com/jellydator/ttlcache/v3"
 	"github.com/llgcode/draw2d"
 	"github.com/llgcode/draw2d/draw2dimg"
 	"github.com/llgcode/draw2d/draw2dkit"
 	"image"
 	"image/color"
+	"image/color/palette"
 	"image/draw"
+	"image/gif"
 	"log"
 	"math"
 	"strconv"
+	"strings"
 )
 
 //go:embed Courier.ttf
 var TtfFont []byte
 
 const (
-	DiscSize      = 100
-	LineThickness = 4
-	SideOffset    = 40
-	TileSize      = DiscSize + LineThickness
-	DotSize       = 8
-	SideFont      = 25.0
-	AnalysisFont  = 23.0
+	DiscSize          = 100
+	LineThickness     = 4
+	SideOffset        = 40
+	TileSize          = DiscSize + LineThickness
+	DotSize           = 8
+	SideFont          = 25.0
+	AnalysisFont      = 23.0
+	EvalBarWidth      = 30
+	MaxEval           = 64.0
+	LastMoveThickness = 5.0
+	RenderCacheSize   = 32 // each cached board image is a few megabytes
+	MinRenderScale    = 0.5
+	MaxRenderScale    = 3.0
+	ReplayFrameDelay  = 80  // hundredths of a second each move of a replay is shown
+	ReplayEndDelay    = 400 // the final position is shown longer before the replay loops
 )
 
 var (
@@ -41,6 +54,46 @@ var (
 	DotLocations = [][]int{{2, 2}, {6, 6}, {2, 6}, {6, 2}}
 )
 
+// Theme is the set of colors a board is drawn with
+type Theme struct {
+	Name      string
+	Board     color.RGBA
+	Line      color.RGBA
+	BlackDisc color.RGBA
+	WhiteDisc color.RGBA
+	Text      color.RGBA
+}
+
+var (
+	ClassicTheme = Theme{Name: "classic", Board: GreenBg, Line: BlackBg, BlackDisc: BlackFill, WhiteDisc: WhiteFill, Text: WhiteFill}
+	DarkTheme    = Theme{
+		Name:      "dark",
+		Board:     color.RGBA{R: 58, G: 62, B: 66, A: 255},
+		Line:      color.RGBA{R: 18, G: 18, B: 20, A: 255},
+		BlackDisc: BlackFill,
+		WhiteDisc: color.RGBA{R: 215, G: 215, B: 215, A: 255},
+		Text:      color.RGBA{R: 190, G: 190, B: 190, A: 255},
+	}
+	BlueTheme = Theme{
+		Name:      "blue",
+		Board:     color.RGBA{R: 52, G: 101, B: 164, A: 255},
+		Line:      color.RGBA{R: 12, G: 30, B: 58, A: 255},
+		BlackDisc: BlackFill,
+		WhiteDisc: WhiteFill,
+		Text:      WhiteFill,
+	}
+	Themes = []Theme{ClassicTheme, DarkTheme, BlueTheme}
+)
+
+func FindTheme(name string) (Theme, bool) {
+	for _, theme := range Themes {
+		if theme.Name == name {
+			return theme, true
+		}
+	}
+	return Theme{}, false
+}
+
 func init() {
 	font, err := truetype.Parse(TtfFont)
 	if err != nil {
@@ -50,46 +103,184 @@ func init() {
 }
 
 type Renderer struct {
-	whiteDisc  image.Image
-	blackDisc  image.Image
-	noDisc     image.Image
-	background image.Image
+	discSize      int
+	tileSize      int
+	sideOffset    int
+	lineThickness int
+	whiteDisc     image.Image
+	blackDisc     image.Image
+	noDisc        image.Image
+	lastRing      image.Image
+	background    image.Image
+	flippedBg     image.Image // the background with labels reversed, for boards drawn from white's side
+	theme         Theme
+	flip          bool                                 // draws the board from white's side, with h8 in the top left
+	images        *ttlcache.Cache[string, image.Image] // recently drawn boards, the least recently used is evicted first
 }
 
 func MakeRenderCache() Renderer {
-	return Renderer{
-		whiteDisc:  DrawDisc(WhiteFill, 2.0),
-		blackDisc:  DrawDisc(BlackFill, 2.0),
-		noDisc:     DrawDisc(NoFill, 3.0),
-		background: drawBackground(BoardSize),
+	return MakeRenderCacheWithSize(DiscSize, SideOffset)
+}
+
+// MakeRenderCacheWithSize creates a renderer drawing discs and the sidebars with the given pixel sizes, the cached
+// images are drawn at the chosen size so smaller renderers produce smaller images
+func MakeRenderCacheWithSize(discSize int, sideOffset int) Renderer {
+	return makeRenderer(discSize, sideOffset, ClassicTheme)
+}
+
+// MakeRenderCacheWithTheme creates a renderer drawing boards of the default size with the theme's colors
+func MakeRenderCacheWithTheme(theme Theme) Renderer {
+	return MakeRenderCacheWithScale(1, theme)
+}
+
+// MakeRenderCacheWithScale creates a renderer drawing every part of the board at a multiple of the default size, such
+// as 2 for sharper images or 0.5 to save bandwidth
+func MakeRenderCacheWithScale(scale float64, theme Theme) Renderer {
+	discSize := int(math.Round(DiscSize * scale))
+	sideOffset := int(math.Round(SideOffset * scale))
+	return makeRenderer(discSize, sideOffset, theme)
+}
+
+func makeRenderer(discSize int, sideOffset int, theme Theme) Renderer {
+	lineThickness := max(1, int(math.Round(LineThickness*float64(discSize)/DiscSize)))
+	r := Renderer{
+		discSize:      discSize,
+		tileSize:      discSize + lineThickness,
+		sideOffset:    sideOffset,
+		lineThickness: lineThickness,
+		theme:         theme,
 	}
+	r.whiteDisc = drawDisc(r.tileSize, lineThickness, theme.WhiteDisc, 2.0*r.scale())
+	r.blackDisc = drawDisc(r.tileSize, lineThickness, theme.BlackDisc, 2.0*r.scale())
+	r.noDisc = drawDisc(r.tileSize, lineThickness, NoFill, 3.0*r.scale())
+	r.lastRing = drawRing(r.tileSize, lineThickness, CyanBg, LastMoveThickness*r.scale())
+	r.background = r.drawBackground(BoardSize, false)
+	r.flippedBg = r.drawBackground(BoardSize, true)
+	r.images = ttlcache.New[string, image.Image](ttlcache.WithCapacity[string, image.Image](RenderCacheSize))
+	return r
 }
 
-func (r Renderer) DrawBoard(board OthelloBoard) image.Image {
-	return r.DrawBoardMoves(board, nil)
+// WithFlip returns a renderer drawing boards from white's side when flip is set, sharing the same cache of drawn boards
+func (r Renderer) WithFlip(flip bool) Renderer {
+	r.flip = flip
+	return r
 }
 
-func (r Renderer) DrawBoardMoves(board OthelloBoard, moves []Tile) image.Image {
-	img := image.NewRGBA(image.Rect(0, 0, r.background.Bounds().Dx(), r.background.Bounds().Dy()))
+// DrawBoardOriented draws the potential moves on a board that is turned around for white's side when flip is set
+func (r Renderer) DrawBoardOriented(board OthelloBoard, moves []Tile, flip bool) image.Image {
+	return r.WithFlip(flip).DrawBoardMoves(board, moves)
+}
 
-	r.DrawBoardDiscs(board, img)
+// orient finds the row and column a tile is drawn at, a flipped board turns every tile around the center
+func (r Renderer) orient(tile Tile) (int, int) {
+	if r.flip {
+		return BoardSize - 1 - tile.Row, BoardSize - 1 - tile.Col
+	}
+	return tile.Row, tile.Col
+}
 
-	// draw each move image onto the preMoves
+// renderKey identifies a drawn board by the position, the orientation, and every overlay drawn onto it
+func (r Renderer) renderKey(board OthelloBoard, moves []Tile, bestMoves []RankTile, last *Tile) string {
+	var sb strings.Builder
+	if r.flip {
+		sb.WriteString("flip/")
+	}
+	sb.WriteString(board.MarshalString())
+	sb.WriteString("/")
 	for _, move := range moves {
-		x := SideOffset + move.Col*TileSize - (LineThickness / 2)
-		y := SideOffset + move.Row*TileSize - (LineThickness / 2)
-		rect := image.Rect(x, y, x+r.noDisc.Bounds().Dx(), y+r.noDisc.Bounds().Dy())
-		draw.Draw(img, rect, r.noDisc, image.Point{X: 0, Y: 0}, draw.Over)
+		sb.WriteString(move.String())
 	}
+	sb.WriteString("/")
+	for _, move := range bestMoves {
+		sb.WriteString(fmt.Sprintf("%s%.1f", move.Tile.String(), move.H))
+	}
+	sb.WriteString("/")
+	if last != nil {
+		sb.WriteString(last.String())
+	}
+	return sb.String()
+}
 
+// cachedDraw returns the image drawn for the key by a recent call, or draws and caches it. Cached images are shared so
+// they must not be drawn onto
+func (r Renderer) cachedDraw(key string, drawFunc func() image.Image) image.Image {
+	if r.images == nil {
+		return drawFunc()
+	}
+	if item := r.images.Get(key); item != nil {
+		return item.Value()
+	}
+	img := drawFunc()
+	r.images.Set(key, img, ttlcache.NoTTL)
 	return img
 }
 
+// scale is the size of the renderer relative to the default disc size, used to size fonts and decorations
+func (r Renderer) scale() float64 {
+	return float64(r.discSize) / DiscSize
+}
+
+func (r Renderer) DrawBoard(board OthelloBoard) image.Image {
+	return r.DrawBoardMoves(board, nil)
+}
+
+func (r Renderer) DrawBoardMoves(board OthelloBoard, moves []Tile) image.Image {
+	return r.DrawBoardMovesAnalysis(board, moves, nil)
+}
+
 func (r Renderer) DrawBoardAnalysis(board OthelloBoard, bestMoves []RankTile) image.Image {
+	return r.DrawBoardMovesAnalysis(board, nil, bestMoves)
+}
+
+// DrawBoardLastMove draws the potential moves with a ring outlining the disc placed by the last move
+func (r Renderer) DrawBoardLastMove(board OthelloBoard, moves []Tile, last Tile) image.Image {
+	return r.cachedDraw(r.renderKey(board, moves, nil, &last), func() image.Image {
+		return r.drawBoardLastMove(board, moves, last)
+	})
+}
+
+func (r Renderer) drawBoardLastMove(board OthelloBoard, moves []Tile, last Tile) *image.RGBA {
+	img := r.drawBoardMovesAnalysis(board, moves, nil)
+
+	row, col := r.orient(last)
+	x := r.sideOffset + col*r.tileSize - (r.lineThickness / 2)
+	y := r.sideOffset + row*r.tileSize - (r.lineThickness / 2)
+	rect := image.Rect(x, y, x+r.lastRing.Bounds().Dx(), y+r.lastRing.Bounds().Dy())
+	draw.Draw(img, rect, r.lastRing, image.Point{X: 0, Y: 0}, draw.Over)
+
+	return img
+}
+
+// DrawBoardMovesAnalysis draws the potential moves along with the heuristics for analyzed moves, the first analyzed
+// move is highlighted as the best move
+func (r Renderer) DrawBoardMovesAnalysis(board OthelloBoard, moves []Tile, bestMoves []RankTile) image.Image {
+	return r.cachedDraw(r.renderKey(board, moves, bestMoves, nil), func() image.Image {
+		return r.drawBoardMovesAnalysis(board, moves, bestMoves)
+	})
+}
+
+func (r Renderer) drawBoardMovesAnalysis(board OthelloBoard, moves []Tile, bestMoves []RankTile) *image.RGBA {
 	img := image.NewRGBA(image.Rect(0, 0, r.background.Bounds().Dx(), r.background.Bounds().Dy()))
 
 	r.DrawBoardDiscs(board, img)
 
+	var analyzed [BoardSize][BoardSize]bool
+	for _, move := range bestMoves {
+		analyzed[move.Row][move.Col] = true
+	}
+
+	// draw each move image onto the preMoves
+	for _, move := range moves {
+		if analyzed[move.Row][move.Col] {
+			continue
+		}
+		row, col := r.orient(move)
+		x := r.sideOffset + col*r.tileSize - (r.lineThickness / 2)
+		y := r.sideOffset + row*r.tileSize - (r.lineThickness / 2)
+		rect := image.Rect(x, y, x+r.noDisc.Bounds().Dx(), y+r.noDisc.Bounds().Dy())
+		draw.Draw(img, rect, r.noDisc, image.Point{X: 0, Y: 0}, draw.Over)
+	}
+
 	g := draw2dimg.NewGraphicContext(img)
 
 	// draw each heuristic eval onto the preMoves
@@ -108,21 +299,27 @@ func (r Renderer) DrawBoardAnalysis(board OthelloBoard, bestMoves []RankTile) im
 			g.SetFillColor(YellowBg)
 		}
 
-		x := SideOffset + move.Col*TileSize
-		y := SideOffset + move.Row*TileSize
-		drawCenterString(g, AnalysisFont, hText, x, y, TileSize, TileSize)
+		row, col := r.orient(move.Tile)
+		x := r.sideOffset + col*r.tileSize
+		y := r.sideOffset + row*r.tileSize
+		drawCenterString(g, AnalysisFont*r.scale(), hText, x, y, r.tileSize, r.tileSize)
 	}
 
 	return img
 }
 
 func (r Renderer) DrawBoardDiscs(board OthelloBoard, img draw.Image) {
-	draw.Draw(img, r.background.Bounds(), r.background, image.Point{X: 0, Y: 0}, draw.Over)
+	background := r.background
+	if r.flip {
+		background = r.flippedBg
+	}
+	draw.Draw(img, background.Bounds(), background, image.Point{X: 0, Y: 0}, draw.Over)
 
 	// draw discs onto preMoves, either empty, black, or white
 	for _, tile := range AllTiles {
-		x := SideOffset + tile.Col*TileSize - (LineThickness / 2)
-		y := SideOffset + tile.Row*TileSize - (LineThickness / 2)
+		row, col := r.orient(tile)
+		x := r.sideOffset + col*r.tileSize - (r.lineThickness / 2)
+		y := r.sideOffset + row*r.tileSize - (r.lineThickness / 2)
 		// determine which bitmap belongs in the tile slot
 		disc := board.GetSquareByTile(tile)
 
@@ -140,28 +337,32 @@ func (r Renderer) DrawBoardDiscs(board OthelloBoard, img draw.Image) {
 	}
 }
 
-func drawBackground(boardSize int) image.Image {
-	width := TileSize*boardSize + LineThickness + SideOffset
-	height := TileSize*boardSize + LineThickness + SideOffset
+// drawBackground draws the lines and labels of an empty board, a flipped board labels the columns from h to a and the
+// rows from 8 to 1 so each label stays next to its tiles
+func (r Renderer) drawBackground(boardSize int, flip bool) image.Image {
+	sideOffset := float64(r.sideOffset)
+	width := r.tileSize*boardSize + r.lineThickness + r.sideOffset
+	height := r.tileSize*boardSize + r.lineThickness + r.sideOffset
 
 	img := image.NewRGBA(image.Rect(0, 0, width, height))
 	g := draw2dimg.NewGraphicContext(img)
+	g.SetStrokeColor(r.theme.Line)
 
-	g.SetFillColor(BlackBg)
+	g.SetFillColor(r.theme.Line)
 	draw2dkit.Rectangle(g, 0, 0, float64(width), float64(height))
 	g.FillStroke()
 
-	g.SetFillColor(GreenBg)
-	draw2dkit.Rectangle(g, SideOffset, SideOffset, float64(width-LineThickness), float64(height-LineThickness))
+	g.SetFillColor(r.theme.Board)
+	draw2dkit.Rectangle(g, sideOffset, sideOffset, float64(width-r.lineThickness), float64(height-r.lineThickness))
 	g.FillStroke()
 
-	g.SetLineWidth(LineThickness)
-	g.SetFillColor(BlackBg)
+	g.SetLineWidth(float64(r.lineThickness))
+	g.SetFillColor(r.theme.Line)
 
 	// draw black horizontal lines
 	for i := 0; i < boardSize+1; i++ {
-		y := float64(i*TileSize + SideOffset)
-		g.MoveTo(SideOffset, y)
+		y := float64(i*r.tileSize + r.sideOffset)
+		g.MoveTo(sideOffset, y)
 		g.LineTo(float64(width), y)
 		g.Close()
 		g.FillStroke()
@@ -169,44 +370,125 @@ func drawBackground(boardSize int) image.Image {
 
 	// draw black vertical lines
 	for i := 0; i < boardSize+1; i++ {
-		x := float64(i*TileSize + SideOffset)
-		g.MoveTo(x, SideOffset)
+		x := float64(i*r.tileSize + r.sideOffset)
+		g.MoveTo(x, sideOffset)
 		g.LineTo(x, float64(height))
 		g.Close()
 		g.FillStroke()
 	}
 
-	g.SetFillColor(WhiteFill)
+	g.SetFillColor(r.theme.Text)
 
 	// draw letters on horizontal sidebar
 	for i := 0; i < boardSize; i++ {
-		text := string(rune(i) + 'A')
-		x := SideOffset + i*TileSize
-		drawCenterString(g, SideFont, text, x, 0, TileSize, SideOffset)
+		label := i
+		if flip {
+			label = boardSize - 1 - i
+		}
+		text := string(rune(label) + 'A')
+		x := r.sideOffset + i*r.tileSize
+		drawCenterString(g, SideFont*r.scale(), text, x, 0, r.tileSize, r.sideOffset)
 	}
 
 	// draw numbers on vertical sidebar
 	for i := 0; i < boardSize; i++ {
-		text := strconv.Itoa(i + 1)
-		y := SideOffset + i*TileSize
-		drawCenterString(g, SideFont, text, 0, y, SideOffset, TileSize)
+		label := i
+		if flip {
+			label = boardSize - 1 - i
+		}
+		text := strconv.Itoa(label + 1)
+		y := r.sideOffset + i*r.tileSize
+		drawCenterString(g, SideFont*r.scale(), text, 0, y, r.sideOffset, r.tileSize)
 	}
 
-	g.SetFillColor(BlackFill)
+	g.SetFillColor(r.theme.BlackDisc)
 	for _, location := range DotLocations {
 		// fills an oval, the x,y is subtracted by the dot size and line thickness to center it
 		col := location[0]
 		row := location[1]
-		x := SideOffset + col*TileSize
-		y := SideOffset + row*TileSize
+		x := r.sideOffset + col*r.tileSize
+		y := r.sideOffset + row*r.tileSize
 
-		draw2dkit.Circle(g, float64(x), float64(y), DotSize)
+		draw2dkit.Circle(g, float64(x), float64(y), DotSize*r.scale())
 		g.FillStroke()
 	}
 
 	return img
 }
 
+// RenderReplayGIF draws an animated replay of the game's moves from the initial board, each move is a frame with a ring
+// around the placed disc. A pass doesn't change the discs, so it only gives the turn to the opponent without a frame
+func (r Renderer) RenderReplayGIF(game OthelloGame) (*bytes.Buffer, error) {
+	anim := gif.GIF{}
+	// boards only have a few colors besides the edges of the discs, so the nearest palette color is found once per color
+	indexes := make(map[color.RGBA]uint8)
+	addFrame := func(img *image.RGBA, delay int) {
+		frame := image.NewPaletted(img.Bounds(), palette.Plan9)
+		for y := img.Rect.Min.Y; y < img.Rect.Max.Y; y++ {
+			for x := img.Rect.Min.X; x < img.Rect.Max.X; x++ {
+				c := img.RGBAAt(x, y)
+				index, ok := indexes[c]
+				if !ok {
+					index = uint8(frame.Palette.Index(c))
+					indexes[c] = index
+				}
+				frame.SetColorIndex(x, y, index)
+			}
+		}
+		anim.Image = append(anim.Image, frame)
+		anim.Delay = append(anim.Delay, delay)
+	}
+
+	board := InitialBoard
+	start := image.NewRGBA(r.background.Bounds())
+	r.DrawBoardDiscs(board, start)
+	addFrame(start, ReplayFrameDelay)
+
+	for _, move := range game.MoveList {
+		if move.Pass {
+			board.IsBlackMove = !board.IsBlackMove
+			continue
+		}
+		if !board.IsLegalMove(move.Tile) {
+			return nil, fmt.Errorf("%w: %s cannot be replayed", ErrInvalidMove, move.Tile)
+		}
+		board.MakeMove(move.Tile)
+		addFrame(r.drawBoardLastMove(board, nil, move.Tile), ReplayFrameDelay)
+	}
+	anim.Delay[len(anim.Delay)-1] = ReplayEndDelay
+
+	var buf bytes.Buffer
+	if err := gif.EncodeAll(&buf, &anim); err != nil {
+		return nil, fmt.Errorf("failed to encode replay gif: %w", err)
+	}
+	return &buf, nil
+}
+
+// EvalBarFill is the proportion of the evaluation bar filled for black, an even position fills half of the bar
+func EvalBarFill(eval float64) float64 {
+	eval = math.Max(-MaxEval, math.Min(MaxEval, eval))
+	return 0.5 + eval/(2*MaxEval)
+}
+
+// DrawEvalBar composes a vertical evaluation bar on the right of a board image, black's share fills from the bottom
+func (r Renderer) DrawEvalBar(boardImg image.Image, eval float64) image.Image {
+	bounds := boardImg.Bounds()
+	barWidth := int(EvalBarWidth * r.scale())
+
+	img := image.NewRGBA(image.Rect(0, 0, bounds.Dx()+barWidth, bounds.Dy()))
+	draw.Draw(img, bounds, boardImg, bounds.Min, draw.Src)
+
+	height := bounds.Dy()
+	blackHeight := int(math.Round(EvalBarFill(eval) * float64(height)))
+
+	white := image.Rect(bounds.Dx(), 0, bounds.Dx()+barWidth, height-blackHeight)
+	black := image.Rect(bounds.Dx(), height-blackHeight, bounds.Dx()+barWidth, height)
+	draw.Draw(img, white, image.NewUniform(WhiteFill), image.Point{}, draw.Src)
+	draw.Draw(img, black, image.NewUniform(BlackFill), image.Point{}, draw.Src)
+
+	return img
+}
+
 func drawCenterString(g *draw2dimg.GraphicContext, fontSize float64, text string, x, y, width, height int) {
 	g.SetFontData(FontData)
 	g.SetFontSize(fontSize)
@@ -222,8 +504,29 @@ func drawCenterString(g *draw2dimg.GraphicContext, fontSize float64, text string
 	g.FillStringAt(text, xDraw, yDraw)
 }
 
+// drawRing draws an unfilled circle just inside the outline of a disc
+func drawRing(tileSize int, lineThickness int, strokeColor color.RGBA, thickness float64) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
+
+	g := draw2dimg.NewGraphicContext(img)
+
+	g.SetStrokeColor(strokeColor)
+	g.SetLineWidth(thickness)
+
+	margin := 6*float64(tileSize)/TileSize + thickness/2
+	center := float64(lineThickness/2 + tileSize/2)
+	draw2dkit.Circle(g, center, center, float64(tileSize/2)-margin)
+	g.Stroke()
+
+	return img
+}
+
 func DrawDisc(fillColor color.RGBA, thickness float64) image.Image {
-	img := image.NewRGBA(image.Rect(0, 0, TileSize, TileSize))
+	return drawDisc(TileSize, LineThickness, fillColor, thickness)
+}
+
+func drawDisc(tileSize int, lineThickness int, fillColor color.RGBA, thickness float64) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, tileSize, tileSize))
 
 	g := draw2dimg.NewGraphicContext(img)
 
@@ -231,8 +534,29 @@ func DrawDisc(fillColor color.RGBA, thickness float64) image.Image {
 	g.SetStrokeColor(OutlineBg)
 	g.SetLineWidth(thickness)
 
-	draw2dkit.Circle(g, float64(LineThickness/2+TileSize/2), LineThickness/2+float64(TileSize/2), TileSize/2-6)
+	margin := 6 * float64(tileSize) / TileSize
+	center := float64(lineThickness/2 + tileSize/2)
+	draw2dkit.Circle(g, center, center, float64(tileSize/2)-margin)
 	g.FillStroke()
 
 	return img
 }
+
+// ThemeRenderers holds a renderer for each theme by name, so each theme keeps its own cache of drawn boards
+type ThemeRenderers map[string]Renderer
+
+func MakeThemeRenderers(scale float64) ThemeRenderers {
+	tr := make(ThemeRenderers)
+	for _, theme := range Themes {
+		tr[theme.Name] = MakeRenderCacheWithScale(scale, theme)
+	}
+	return tr
+}
+
+// Get finds the renderer for a theme, or the renderer for the classic theme if there is no theme with the name
+func (tr ThemeRenderers) Get(name string) Renderer {
+	if r, ok := tr[name]; ok {
+		return r
+	}
+	return tr[ClassicTheme.Name]
+}
@@ -0,0 +1,267 @@
+package app
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/gif"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRenderer_Size(t *testing.T) {
+	board := MakeInitialBoard()
+
+	defaultImg := MakeRenderCache().DrawBoardMoves(board, board.FindCurrentMoves())
+	smallImg := MakeRenderCacheWithSize(DiscSize/2, SideOffset/2).DrawBoardMoves(board, board.FindCurrentMoves())
+
+	expSize := TileSize*BoardSize + LineThickness + SideOffset
+	assert.Equal(t, expSize, defaultImg.Bounds().Dx())
+	assert.Equal(t, expSize, defaultImg.Bounds().Dy())
+
+	assert.Less(t, smallImg.Bounds().Dx(), defaultImg.Bounds().Dx())
+	assert.Less(t, smallImg.Bounds().Dy(), defaultImg.Bounds().Dy())
+}
+
+func TestRenderer_Scale(t *testing.T) {
+	board := MakeInitialBoard()
+
+	defaultImg := MakeRenderCacheWithScale(1, ClassicTheme).DrawBoardLastMove(board, board.FindCurrentMoves(), ParseTile("d4"))
+	assert.Equal(t, MakeRenderCache().DrawBoardLastMove(board, board.FindCurrentMoves(), ParseTile("d4")), defaultImg)
+
+	// every part of the board doubles, so the image is twice as large on each side
+	largeImg := MakeRenderCacheWithScale(2, ClassicTheme).DrawBoardLastMove(board, board.FindCurrentMoves(), ParseTile("d4"))
+	assert.Equal(t, 2*defaultImg.Bounds().Dx(), largeImg.Bounds().Dx())
+	assert.Equal(t, 2*defaultImg.Bounds().Dy(), largeImg.Bounds().Dy())
+
+	smallImg := MakeRenderCacheWithScale(0.5, ClassicTheme).DrawBoard(board)
+	assert.Equal(t, defaultImg.Bounds().Dx()/2, smallImg.Bounds().Dx())
+
+	// a disc at the same relative position is drawn in both sizes
+	x, y := SideOffset+3*TileSize+TileSize/2, SideOffset+3*TileSize+TileSize/2
+	assert.Equal(t, defaultImg.At(x, y), largeImg.At(2*x, 2*y))
+}
+
+func TestRenderer_DrawBoardOriented(t *testing.T) {
+	board := MakeInitialBoard()
+	board.MakeMove(ParseTile("d3"))
+	moves := board.FindCurrentMoves()
+
+	r := MakeRenderCache()
+	img := r.DrawBoardOriented(board, moves, false)
+	flippedImg := r.DrawBoardOriented(board, moves, true)
+	assert.NotEqual(t, img, flippedImg)
+	assert.Equal(t, img, r.DrawBoardMoves(board, moves))
+
+	// every tile is drawn at the opposite corner, including the move indicators
+	center := func(row, col int) (int, int) {
+		return SideOffset + col*TileSize + TileSize/2, SideOffset + row*TileSize + TileSize/2
+	}
+	edge := func(row, col int) (int, int) {
+		return SideOffset + col*TileSize + 6, SideOffset + row*TileSize + TileSize/2
+	}
+	for _, tile := range AllTiles {
+		x, y := center(tile.Row, tile.Col)
+		fx, fy := center(BoardSize-1-tile.Row, BoardSize-1-tile.Col)
+		assert.Equal(t, img.At(x, y), flippedImg.At(fx, fy), "tile %s", tile)
+	}
+	emptyX, emptyY := edge(0, 0)
+	for _, move := range moves {
+		x, y := edge(move.Row, move.Col)
+		assert.NotEqual(t, img.At(emptyX, emptyY), img.At(x, y), "move %s", move)
+		fx, fy := edge(BoardSize-1-move.Row, BoardSize-1-move.Col)
+		assert.Equal(t, img.At(x, y), flippedImg.At(fx, fy), "move %s", move)
+	}
+
+	// the flipped renderer shares the cache without reusing the unflipped image
+	assert.Equal(t, flippedImg, r.WithFlip(true).DrawBoardMoves(board, moves))
+}
+
+func TestParseRenderScale(t *testing.T) {
+	assert.Equal(t, 1.0, parseRenderScale(""))
+	assert.Equal(t, 2.0, parseRenderScale("2"))
+	assert.Equal(t, 0.5, parseRenderScale("0.5"))
+	assert.Equal(t, 1.0, parseRenderScale("huge"))
+	assert.Equal(t, 1.0, parseRenderScale("10"))
+	assert.Equal(t, 1.0, parseRenderScale("0"))
+}
+
+func TestRenderer_DrawEvalBar(t *testing.T) {
+	type Test struct {
+		eval    float64
+		expFill float64
+	}
+	tests := []Test{
+		{eval: 0, expFill: 0.5},
+		{eval: 32, expFill: 0.75},
+		{eval: -32, expFill: 0.25},
+		{eval: 100, expFill: 1},
+		{eval: -100, expFill: 0},
+	}
+
+	r := MakeRenderCache()
+	boardImg := r.DrawBoard(MakeInitialBoard())
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			assert.Equal(t, test.expFill, EvalBarFill(test.eval))
+
+			img := r.DrawEvalBar(boardImg, test.eval)
+			assert.Equal(t, boardImg.Bounds().Dx()+EvalBarWidth, img.Bounds().Dx())
+			assert.Equal(t, boardImg.Bounds().Dy(), img.Bounds().Dy())
+
+			// count the black pixels down the middle of the bar to measure the fill
+			x := boardImg.Bounds().Dx() + EvalBarWidth/2
+			blackPixels := 0
+			for y := 0; y < img.Bounds().Dy(); y++ {
+				if img.At(x, y) == BlackFill {
+					blackPixels++
+				}
+			}
+			assert.InDelta(t, test.expFill, float64(blackPixels)/float64(img.Bounds().Dy()), 0.01)
+		})
+	}
+}
+
+func TestRenderer_DrawBoardLastMove(t *testing.T) {
+	board := MakeInitialBoard()
+	last := board.FindCurrentMoves()[0]
+	board.MakeMove(last)
+
+	r := MakeRenderCache()
+	countCyan := func(img image.Image) int {
+		x := SideOffset + last.Col*TileSize
+		y := SideOffset + last.Row*TileSize
+		count := 0
+		for px := x; px < x+TileSize; px++ {
+			for py := y; py < y+TileSize; py++ {
+				if img.At(px, py) == CyanBg {
+					count++
+				}
+			}
+		}
+		return count
+	}
+
+	assert.Greater(t, countCyan(r.DrawBoardLastMove(board, board.FindCurrentMoves(), last)), 0)
+	assert.Equal(t, 0, countCyan(r.DrawBoardMoves(board, board.FindCurrentMoves())))
+	assert.Equal(t, 0, countCyan(r.DrawBoard(board)))
+}
+
+func TestRenderer_DrawBoardAnalysis_Coach(t *testing.T) {
+	le := MakeLocalEngineShell()
+	game := OthelloGame{Board: MakeInitialBoard()}
+
+	resp := <-le.FindRankedMoves(game, 4)
+	if resp.Err != nil {
+		t.Fatalf("failed to rank moves: %v", resp.Err)
+	}
+	candidates := topRankTiles(resp.Moves, CoachCandidates)
+	assert.Len(t, candidates, CoachCandidates)
+
+	img := MakeRenderCache().DrawBoardAnalysis(game.Board, candidates)
+	countColor := func(tile Tile, c color.RGBA) int {
+		x := SideOffset + tile.Col*TileSize
+		y := SideOffset + tile.Row*TileSize
+		count := 0
+		for px := x; px < x+TileSize; px++ {
+			for py := y; py < y+TileSize; py++ {
+				if img.At(px, py) == c {
+					count++
+				}
+			}
+		}
+		return count
+	}
+
+	// the bot's choice is highlighted apart from the other candidates
+	assert.Greater(t, countColor(candidates[0].Tile, CyanBg), 0)
+	for _, candidate := range candidates[1:] {
+		assert.Greater(t, countColor(candidate.Tile, YellowBg), 0)
+	}
+}
+
+func TestRenderer_Cache(t *testing.T) {
+	r := MakeRenderCache()
+	board := MakeInitialBoard()
+	moves := board.FindCurrentMoves()
+
+	img := r.DrawBoardMoves(board, moves)
+	assert.Same(t, img, r.DrawBoardMoves(board, moves))
+
+	// each overlay is cached apart from the others drawn onto the same position
+	assert.NotSame(t, img, r.DrawBoard(board))
+	assert.NotSame(t, img, r.DrawBoardLastMove(board, moves, moves[0]))
+	assert.NotSame(t, img, r.DrawBoardAnalysis(board, []RankTile{{Tile: moves[0], H: 1}}))
+	assert.NotSame(t, r.DrawBoardAnalysis(board, []RankTile{{Tile: moves[0], H: 1}}), r.DrawBoardAnalysis(board, []RankTile{{Tile: moves[0], H: 2}}))
+
+	// the cache is bounded, so drawing many positions evicts the first
+	for i := range RenderCacheSize {
+		other, _ := RandomBoard(i + 1)
+		r.DrawBoard(other)
+	}
+	assert.Equal(t, RenderCacheSize, r.images.Len())
+	assert.NotSame(t, img, r.DrawBoardMoves(board, moves))
+}
+
+func BenchmarkRenderer_DrawBoardMoves(b *testing.B) {
+	r := MakeRenderCache()
+	r.images = nil
+	board, _ := RandomBoard(30)
+	for b.Loop() {
+		r.DrawBoardMoves(board, board.FindCurrentMoves())
+	}
+}
+
+func BenchmarkRenderer_DrawBoardMovesCached(b *testing.B) {
+	r := MakeRenderCache()
+	board, _ := RandomBoard(30)
+	for b.Loop() {
+		r.DrawBoardMoves(board, board.FindCurrentMoves())
+	}
+}
+
+func TestRenderer_Theme(t *testing.T) {
+	board := MakeInitialBoard()
+	// the center of an empty tile shows the board color of the theme
+	x := SideOffset + TileSize/2
+	y := SideOffset + TileSize/2
+
+	themes := MakeThemeRenderers(1)
+	for _, theme := range Themes {
+		t.Run(theme.Name, func(t *testing.T) {
+			img := themes.Get(theme.Name).DrawBoard(board)
+			assert.Equal(t, theme.Board, img.At(x, y))
+		})
+	}
+
+	assert.Equal(t, GreenBg, MakeRenderCache().DrawBoard(board).At(x, y))
+	assert.Equal(t, GreenBg, themes.Get("unknown").DrawBoard(board).At(x, y))
+}
+
+func TestRenderer_ReplayGIF(t *testing.T) {
+	moves, err := UnmarshalMoveList("f5,f6,e6,f4")
+	if err != nil {
+		t.Fatalf("failed to unmarshal moves: %v", err)
+	}
+	moves = append(moves, Move{Pass: true})
+
+	r := MakeRenderCacheWithScale(MinRenderScale, ClassicTheme)
+	buf, err := r.RenderReplayGIF(OthelloGame{MoveList: moves})
+	if err != nil {
+		t.Fatalf("failed to render replay: %v", err)
+	}
+
+	replay, err := gif.DecodeAll(buf)
+	if err != nil {
+		t.Fatalf("failed to decode replay: %v", err)
+	}
+	// one frame for the starting board and one for each move, passes don't draw a frame
+	assert.Equal(t, 5, len(replay.Image))
+	assert.Equal(t, ReplayFrameDelay, replay.Delay[0])
+	assert.Equal(t, ReplayEndDelay, replay.Delay[len(replay.Delay)-1])
+
+	_, err = r.RenderReplayGIF(OthelloGame{MoveList: []Move{{Tile: ParseTile("a1")}}})
+	assert.ErrorIs(t, err, ErrInvalidMove)
+}
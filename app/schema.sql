@@ -3,7 +3,9 @@ CREATE TABLE IF NOT EXISTS stats (
     elo FLOAT NOT NULL,
     won INTEGER NOT NULL,
     drawn INTEGER NOT NULL,
-    lost INTEGER NOT NULL
+    lost INTEGER NOT NULL,
+    forfeits INTEGER NOT NULL DEFAULT 0,
+    peak_elo FLOAT NOT NULL DEFAULT 1500
 );
 CREATE TABLE IF NOT EXISTS games (
     id TEXT NOT NULL,
@@ -14,9 +16,58 @@ CREATE TABLE IF NOT EXISTS games (
     black_name TEXT NOT NULL,
     moves TEXT NOT NULL,
     expire_time INTEGER NOT NULL,
+    created_time INTEGER NOT NULL DEFAULT 0,
+    guild_id TEXT NOT NULL DEFAULT '',
+    channel_id TEXT NOT NULL DEFAULT '',
+    server_id TEXT NOT NULL DEFAULT '',
+    warned BOOLEAN NOT NULL DEFAULT FALSE,
     PRIMARY KEY (id)
 );
+CREATE TABLE IF NOT EXISTS guilds (
+    guild_id TEXT PRIMARY KEY,
+    title_prefix TEXT NOT NULL,
+    color INTEGER NOT NULL,
+    guild_stats BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE TABLE IF NOT EXISTS settings (
+    player_id TEXT PRIMARY KEY,
+    eval_bar BOOLEAN NOT NULL,
+    show_moves BOOLEAN NOT NULL DEFAULT TRUE,
+    move_order TEXT NOT NULL DEFAULT 'scan',
+    theme TEXT NOT NULL DEFAULT 'classic',
+    flip_board BOOLEAN NOT NULL DEFAULT FALSE
+);
+CREATE TABLE IF NOT EXISTS game_results (
+    game_id TEXT NOT NULL,
+    winner_id TEXT NOT NULL,
+    loser_id TEXT NOT NULL,
+    is_draw BOOLEAN NOT NULL,
+    finished_time INTEGER NOT NULL,
+    moves TEXT NOT NULL DEFAULT '',
+    server_id TEXT NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS fastest_wins (
+    player_id TEXT PRIMARY KEY,
+    game_id TEXT NOT NULL,
+    duration INTEGER NOT NULL,
+    finished_time INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS matches (
+    id TEXT PRIMARY KEY,
+    game_id TEXT NOT NULL,
+    player1_id TEXT NOT NULL,
+    player2_id TEXT NOT NULL,
+    player1_name TEXT NOT NULL,
+    player2_name TEXT NOT NULL,
+    best_of INTEGER NOT NULL,
+    player1_wins INTEGER NOT NULL,
+    player2_wins INTEGER NOT NULL,
+    draws INTEGER NOT NULL
+);
 
 CREATE INDEX IF NOT EXISTS idx_stats_elo ON stats(elo);
 CREATE INDEX IF NOT EXISTS idx_games_expire_time ON games(expire_time);
-CREATE INDEX IF NOT EXISTS idx_games_player_ids ON games(white_id, black_id);
\ No newline at end of file
+CREATE INDEX IF NOT EXISTS idx_games_player_ids ON games(white_id, black_id);
+CREATE INDEX IF NOT EXISTS idx_fastest_wins_duration ON fastest_wins(duration);
+CREATE INDEX IF NOT EXISTS idx_game_results_player_ids ON game_results(winner_id, loser_id);
+CREATE INDEX IF NOT EXISTS idx_matches_game_id ON matches(game_id);
\ No newline at end of file
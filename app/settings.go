@@ -0,0 +1,96 @@
+package app
+
+import (
+	"cmp"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// move orders for the choices suggested by the move autocomplete
+const (
+	ScanOrder         = "scan"
+	RankedOrder       = "ranked"
+	AlphabeticalOrder = "alphabetical"
+)
+
+var MoveOrders = []string{ScanOrder, RankedOrder, AlphabeticalOrder}
+
+type UserSettings struct {
+	PlayerID  string `db:"player_id"`
+	EvalBar   bool   `db:"eval_bar"`
+	ShowMoves bool   `db:"show_moves"`
+	MoveOrder string `db:"move_order"`
+	Theme     string `db:"theme"`
+	FlipBoard bool   `db:"flip_board"` // draws the player's boards from white's side
+}
+
+func DefaultUserSettings(playerID string) UserSettings {
+	return UserSettings{PlayerID: playerID, EvalBar: false, ShowMoves: true, MoveOrder: ScanOrder, Theme: ClassicTheme.Name}
+}
+
+// VisibleMoves returns the legal moves drawn on the board, or none if the player has hidden the move indicators
+func (settings UserSettings) VisibleMoves(board OthelloBoard) []Tile {
+	if !settings.ShowMoves {
+		return nil
+	}
+	return board.FindCurrentMoves()
+}
+
+// OrderMoves sorts the moves suggested by the autocomplete, ranked ordering puts the moves in the order of a cached
+// analysis and keeps the scan order if the position hasn't been analyzed
+func (settings UserSettings) OrderMoves(moves []Tile, ranked []RankTile) []Tile {
+	moves = slices.Clone(moves)
+	switch settings.MoveOrder {
+	case RankedOrder:
+		if len(ranked) == 0 {
+			return moves
+		}
+		rankOf := func(tile Tile) int {
+			i := slices.IndexFunc(ranked, func(rt RankTile) bool { return rt.Tile == tile })
+			if i < 0 {
+				return len(ranked)
+			}
+			return i
+		}
+		slices.SortStableFunc(moves, func(a, b Tile) int {
+			return cmp.Compare(rankOf(a), rankOf(b))
+		})
+	case AlphabeticalOrder:
+		slices.SortStableFunc(moves, func(a, b Tile) int {
+			return strings.Compare(a.String(), b.String())
+		})
+	}
+	return moves
+}
+
+func GetUserSettings(ctx context.Context, db *sqlx.DB, playerID string) (UserSettings, error) {
+	var settings UserSettings
+	err := db.GetContext(ctx, &settings, "SELECT player_id, eval_bar, show_moves, move_order, theme, flip_board FROM settings WHERE player_id = $1;", playerID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return DefaultUserSettings(playerID), nil
+	}
+	if err != nil {
+		slog.Error("failed to select user settings", "trace", ctx.Value(TraceKey), "playerID", playerID, "err", err)
+		return UserSettings{}, fmt.Errorf("failed to select user settings: %w", err)
+	}
+	return settings, nil
+}
+
+func SetUserSettings(ctx context.Context, db *sqlx.DB, settings UserSettings) error {
+	_, err := db.ExecContext(ctx,
+		"INSERT OR REPLACE INTO settings (player_id, eval_bar, show_moves, move_order, theme, flip_board) VALUES ($1, $2, $3, $4, $5, $6);",
+		settings.PlayerID, settings.EvalBar, settings.ShowMoves, settings.MoveOrder, settings.Theme, settings.FlipBoard,
+	)
+	if err != nil {
+		slog.Error("failed to set user settings", "trace", ctx.Value(TraceKey), "settings", settings, "err", err)
+		return fmt.Errorf("failed to insert or replace user settings: %w", err)
+	}
+	return nil
+}
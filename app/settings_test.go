@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUserSettings_Store(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-user-settings")
+
+	settings, err := GetUserSettings(ctx, db, "id1")
+	if err != nil {
+		t.Fatalf("failed to get user settings: %v", err)
+	}
+	assert.Equal(t, DefaultUserSettings("id1"), settings)
+
+	settings.EvalBar = true
+	if err := SetUserSettings(ctx, db, settings); err != nil {
+		t.Fatalf("failed to set user settings: %v", err)
+	}
+
+	settings, err = GetUserSettings(ctx, db, "id1")
+	if err != nil {
+		t.Fatalf("failed to get user settings: %v", err)
+	}
+	assert.Equal(t, UserSettings{PlayerID: "id1", EvalBar: true, ShowMoves: true, MoveOrder: ScanOrder, Theme: ClassicTheme.Name}, settings)
+
+	settings.Theme = DarkTheme.Name
+	settings.FlipBoard = true
+	if err := SetUserSettings(ctx, db, settings); err != nil {
+		t.Fatalf("failed to set user settings: %v", err)
+	}
+
+	settings, err = GetUserSettings(ctx, db, "id1")
+	if err != nil {
+		t.Fatalf("failed to get user settings: %v", err)
+	}
+	assert.Equal(t, UserSettings{PlayerID: "id1", EvalBar: true, ShowMoves: true, MoveOrder: ScanOrder, Theme: "dark", FlipBoard: true}, settings)
+}
+
+func TestUserSettings_VisibleMoves(t *testing.T) {
+	board := MakeInitialBoard()
+	r := MakeRenderCache()
+
+	settings := DefaultUserSettings("id1")
+	assert.Equal(t, board.FindCurrentMoves(), settings.VisibleMoves(board))
+	assert.NotEqual(t, r.DrawBoard(board), r.DrawBoardMoves(board, settings.VisibleMoves(board)))
+
+	settings.ShowMoves = false
+	assert.Empty(t, settings.VisibleMoves(board))
+	assert.Equal(t, r.DrawBoard(board), r.DrawBoardMoves(board, settings.VisibleMoves(board)))
+}
+
+func TestUserSettings_OrderMoves(t *testing.T) {
+	board := MakeInitialBoard()
+	moves := board.FindCurrentMoves()
+
+	ac := MakeAnalysisCache()
+	ac.Set(board, []RankTile{
+		{Tile: ParseTile("c4"), H: -1},
+		{Tile: ParseTile("d3"), H: 0},
+		{Tile: ParseTile("e6"), H: 2},
+		{Tile: ParseTile("f5"), H: 4},
+	})
+	ranked, ok := ac.Get(board)
+	assert.True(t, ok)
+
+	settings := DefaultUserSettings("id1")
+	assert.Equal(t, moves, settings.OrderMoves(moves, ranked))
+
+	settings.MoveOrder = RankedOrder
+	ordered := settings.OrderMoves(moves, ranked)
+	assert.Equal(t, ParseTile("f5"), ordered[0])
+	assert.Equal(t, []Tile{ParseTile("f5"), ParseTile("e6"), ParseTile("d3"), ParseTile("c4")}, ordered)
+	// without a cached analysis the moves keep the scan order
+	assert.Equal(t, moves, settings.OrderMoves(moves, nil))
+
+	settings.MoveOrder = AlphabeticalOrder
+	assert.Equal(t, []Tile{ParseTile("c4"), ParseTile("d3"), ParseTile("e6"), ParseTile("f5")}, settings.OrderMoves(moves, nil))
+}
@@ -30,46 +30,149 @@ func MakeSimCache() SimCache {
 	return cache
 }
 
+type SimScore struct {
+	BlackWins int
+	WhiteWins int
+	Draws     int
+}
+
+// Add counts a finished game by color, both bots may be the same level so the players can't be told apart by id
+func (s *SimScore) Add(game OthelloGame) {
+	diff := game.Board.BlackScore() - game.Board.WhiteScore()
+	switch {
+	case diff > 0:
+		s.BlackWins++
+	case diff < 0:
+		s.WhiteWins++
+	default:
+		s.Draws++
+	}
+}
+
 type SimStep struct {
-	Game     OthelloGame
-	Move     Tile
-	Finished bool
-	Ok       bool
+	Game      OthelloGame
+	Move      Tile
+	Finished  bool
+	Ok        bool
+	GameNum   int      // the game in a looping simulation starting at 1
+	GameCount int      // the number of games in a looping simulation
+	Score     SimScore // score of the finished games in a looping simulation
+	Restart   bool     // another game will start after this finished step
+	Capped    bool     // the game was stopped by the move cap before it ended
 }
 
-const MaxSimCount = BoardSize * BoardSize // maximum number of possible simulation states
+const MaxSimCount = BoardSize * BoardSize   // maximum number of possible simulation states
+const MaxSimGames = 10                      // bounds a looping simulation so it ends within the simulation context
+const KeyFrameInterval = 15                 // moves between the checkpoints of a key frame simulation
+const MaxSimMoves = BoardSize*BoardSize - 4 // the most moves a game can have
 
-func GenerateSimulation(ctx context.Context, sh *NTestShell, initialGame OthelloGame, simChan chan SimStep) {
-	trace := ctx.Value(TraceKey)
+// GenerateSimulation plays a single game from the initial game, a maxMoves of 0 plays the game until it ends
+func GenerateSimulation(ctx context.Context, sh EngineShell, initialGame OthelloGame, maxMoves int, simChan chan SimStep) {
+	GenerateSimulations(ctx, sh, initialGame, 1, false, maxMoves, simChan)
+}
 
+// GenerateSimulations plays gameCount games from the initial game one after another, sending every step on simChan.
+// If keyFrames is set only every KeyFrameInterval moves and the finished game are sent, so there are fewer edits.
+// If maxMoves is set each game is stopped and scored by its discs once that many moves are made
+func GenerateSimulations(ctx context.Context, sh EngineShell, initialGame OthelloGame, gameCount int, keyFrames bool, maxMoves int, simChan chan SimStep) {
 	defer close(simChan)
+	defer recoverPanic(ctx, func(_ error) {
+		select {
+		case simChan <- SimStep{Ok: false}:
+		default:
+		}
+	})
+
+	var score SimScore
+	for n := 1; n <= gameCount; n++ {
+		if !generateGame(ctx, sh, initialGame, gameCount, n, keyFrames, maxMoves, &score, simChan) {
+			return
+		}
+	}
+}
+
+func generateGame(ctx context.Context, sh EngineShell, initialGame OthelloGame, gameCount int, gameNum int, keyFrames bool, maxMoves int, score *SimScore, simChan chan SimStep) bool {
+	trace := ctx.Value(TraceKey)
+
+	send := func(step SimStep) bool {
+		step.GameNum = gameNum
+		step.Score = *score
+		step.GameCount = gameCount
+		select {
+		case simChan <- step:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
 
 	var game = initialGame
 	var move RankTile
 
 	for i := 0; ; i++ {
+		if maxMoves > 0 && i >= maxMoves && game.HasMoves() {
+			slog.Info("capped simulation", "trace", trace, "move", move, "gameNum", gameNum, "maxMoves", maxMoves)
+			score.Add(game)
+			return send(SimStep{Game: game, Move: move.Tile, Finished: true, Ok: true, Restart: gameNum < gameCount, Capped: true})
+		}
 		if game.HasMoves() {
-			respCh := sh.FindBestMove(game, game.CurrentPlayer().LevelToDepth())
+			respCh := sh.FindBestMove(game, AllocateDepth(game.Board, game.CurrentPlayer().LevelToDepth()))
 			var resp MoveResp
 
 			select {
 			case resp = <-respCh:
 			case <-ctx.Done():
 				slog.Info("cancelled simulation", "index", i, "trace", trace, "move", move)
-				return
+				return false
 			}
 			if resp.Err != nil {
-				simChan <- SimStep{Ok: false}
-				return
+				send(SimStep{Ok: false})
+				return false
 			}
 
-			move = resp.assertValidMove(game)
+			var err error
+			if move, err = resp.validMove(game); err != nil {
+				slog.Error("engine produced an invalid simulation move", "trace", trace, "err", err)
+				send(SimStep{Ok: false})
+				return false
+			}
 			game.MakeMove(move.Tile)
-			simChan <- SimStep{Game: game, Move: move.Tile, Ok: true}
+			if keyFrames && !isKeyFrame(i+1) {
+				continue
+			}
+			if !send(SimStep{Game: game, Move: move.Tile, Ok: true}) {
+				return false
+			}
 		} else {
-			slog.Info("finished simulation", "trace", trace, "move", move)
-			simChan <- SimStep{Game: game, Move: move.Tile, Finished: true, Ok: true}
-			return
+			slog.Info("finished simulation", "trace", trace, "move", move, "gameNum", gameNum)
+			score.Add(game)
+			return send(SimStep{Game: game, Move: move.Tile, Finished: true, Ok: true, Restart: gameNum < gameCount})
 		}
 	}
 }
+
+// estimateSimulationTime is the least time a simulation takes to show every step, each step is shown at most once
+// every delay and the engine may take longer to find a move at higher levels
+func estimateSimulationTime(delay time.Duration, gameCount int, keyFrames bool, maxMoves int) time.Duration {
+	moves := MaxSimMoves
+	if maxMoves > 0 {
+		moves = min(maxMoves, MaxSimMoves)
+	}
+	steps := moves
+	if keyFrames {
+		steps = moves / KeyFrameInterval
+	}
+	steps++ // the finished step
+	return delay * time.Duration(steps*gameCount)
+}
+
+// isFloodingSimulation checks if a simulation would edit its message as fast as allowed for every move between the
+// slowest bots, which floods the channel with edits for a long time
+func isFloodingSimulation(whiteLevel uint64, blackLevel uint64, delay time.Duration, keyFrames bool) bool {
+	return whiteLevel == MaxBotLevel && blackLevel == MaxBotLevel && delay <= time.Second*MinDelay && !keyFrames
+}
+
+// isKeyFrame checks if the step after a number of moves is a checkpoint of a key frame simulation
+func isKeyFrame(moveNum int) bool {
+	return moveNum%KeyFrameInterval == 0
+}
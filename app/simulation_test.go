@@ -0,0 +1,142 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// MockEngineShell plays the first legal move so simulations finish quickly without an engine
+type MockEngineShell struct{}
+
+func (mock *MockEngineShell) FindBestMove(game OthelloGame, _ uint64) chan MoveResp {
+	ch := make(chan MoveResp, 1)
+	ch <- MoveResp{Moves: []RankTile{{Tile: game.Board.FindCurrentMoves()[0]}}}
+	return ch
+}
+
+func (mock *MockEngineShell) FindRankedMoves(game OthelloGame, _ uint64) chan MoveResp {
+	ch := make(chan MoveResp, 1)
+	var moves []RankTile
+	for _, tile := range game.Board.FindCurrentMoves() {
+		moves = append(moves, RankTile{Tile: tile})
+	}
+	ch <- MoveResp{Moves: moves}
+	return ch
+}
+
+func TestGenerateSimulations_Restart(t *testing.T) {
+	ctx := context.WithValue(context.Background(), TraceKey, "test-simulation-restart")
+
+	initialGame := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(1), Board: MakeInitialBoard()}
+	simChan := make(chan SimStep, MaxSimCount)
+
+	go GenerateSimulations(ctx, &MockEngineShell{}, initialGame, 2, false, 0, simChan)
+
+	var finished []SimStep
+	var gameNums []int
+	for step := range simChan {
+		assert.True(t, step.Ok)
+		if len(gameNums) == 0 || gameNums[len(gameNums)-1] != step.GameNum {
+			gameNums = append(gameNums, step.GameNum)
+		}
+		if step.Finished {
+			finished = append(finished, step)
+		}
+	}
+
+	assert.Equal(t, []int{1, 2}, gameNums)
+	assert.Len(t, finished, 2)
+	assert.True(t, finished[0].Restart)
+	assert.False(t, finished[1].Restart)
+
+	// the first legal move is deterministic, so both games have the same result and the score counts both
+	score := finished[1].Score
+	assert.Equal(t, 2, score.BlackWins+score.WhiteWins+score.Draws)
+	assert.Equal(t, 1, finished[0].Score.BlackWins+finished[0].Score.WhiteWins+finished[0].Score.Draws)
+}
+
+func TestGenerateSimulations_KeyFrames(t *testing.T) {
+	ctx := context.WithValue(context.Background(), TraceKey, "test-simulation-key-frames")
+
+	initialGame := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(1), Board: MakeInitialBoard()}
+
+	countSteps := func(keyFrames bool) (steps []SimStep) {
+		simChan := make(chan SimStep, MaxSimCount)
+		go GenerateSimulations(ctx, &MockEngineShell{}, initialGame, 1, keyFrames, 0, simChan)
+		for step := range simChan {
+			assert.True(t, step.Ok)
+			steps = append(steps, step)
+		}
+		return steps
+	}
+
+	allSteps := countSteps(false)
+	keySteps := countSteps(true)
+
+	// the mock plays the same game both times, so the key frames end on the same result
+	assert.Less(t, len(keySteps)*5, len(allSteps))
+	assert.True(t, keySteps[len(keySteps)-1].Finished)
+	assert.Equal(t, allSteps[len(allSteps)-1].Game, keySteps[len(keySteps)-1].Game)
+	for _, step := range keySteps[:len(keySteps)-1] {
+		assert.False(t, step.Finished)
+	}
+}
+
+func TestGenerateSimulations_MaxMoves(t *testing.T) {
+	ctx := context.WithValue(context.Background(), TraceKey, "test-simulation-max-moves")
+
+	initialGame := OthelloGame{WhitePlayer: MakeBotPlayer(1), BlackPlayer: MakeBotPlayer(1), Board: MakeInitialBoard()}
+	simChan := make(chan SimStep, MaxSimCount)
+
+	go GenerateSimulations(ctx, &MockEngineShell{}, initialGame, 2, false, 10, simChan)
+
+	var steps []SimStep
+	for step := range simChan {
+		assert.True(t, step.Ok)
+		steps = append(steps, step)
+	}
+
+	// each game sends its 10 moves then stops with a capped finished step
+	assert.Len(t, steps, 22)
+	for _, step := range []SimStep{steps[10], steps[21]} {
+		assert.True(t, step.Finished)
+		assert.True(t, step.Capped)
+		assert.Equal(t, 60-10, step.Game.Board.CountEmpty())
+	}
+	assert.True(t, steps[10].Restart)
+	assert.Equal(t, 2, steps[21].Score.BlackWins+steps[21].Score.WhiteWins+steps[21].Score.Draws)
+}
+
+func TestEstimateSimulationTime(t *testing.T) {
+	type Test struct {
+		delay     time.Duration
+		gameCount int
+		keyFrames bool
+		maxMoves  int
+		expTime   time.Duration
+	}
+	tests := []Test{
+		{delay: time.Second * 2, gameCount: 1, expTime: time.Second * 122},
+		{delay: time.Second, gameCount: MaxSimGames, expTime: time.Second * 610},
+		{delay: time.Second, gameCount: 1, keyFrames: true, expTime: time.Second * 5},
+		{delay: time.Second, gameCount: 1, maxMoves: 20, expTime: time.Second * 21},
+		{delay: time.Second, gameCount: 2, keyFrames: true, maxMoves: 30, expTime: time.Second * 6},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			assert.Equal(t, test.expTime, estimateSimulationTime(test.delay, test.gameCount, test.keyFrames, test.maxMoves))
+		})
+	}
+}
+
+func TestIsFloodingSimulation(t *testing.T) {
+	assert.True(t, isFloodingSimulation(MaxBotLevel, MaxBotLevel, time.Second*MinDelay, false))
+	assert.False(t, isFloodingSimulation(MaxBotLevel, MaxBotLevel, time.Second*MinDelay, true))
+	assert.False(t, isFloodingSimulation(MaxBotLevel, MaxBotLevel, DefaultDelay, false))
+	assert.False(t, isFloodingSimulation(MaxBotLevel-1, MaxBotLevel, time.Second*MinDelay, false))
+}
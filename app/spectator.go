@@ -0,0 +1,176 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/jellydator/ttlcache/v3"
+)
+
+// MaxSpectators bounds the channels each move of a game is sent to
+const MaxSpectators = 5
+
+// SpectatorSendTimeout is how long a spectator's update can take to send before the spectator is dropped
+const SpectatorSendTimeout = time.Second * 5
+
+var ErrTooManySpectators = errors.New("game has too many spectators")
+var ErrAlreadyWatching = errors.New("already watching game")
+
+type Spectator struct {
+	ChannelID string
+	UserID    string
+}
+
+type ComplexMessageSender interface {
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+}
+
+type SpectatorCache struct {
+	mu      *sync.Mutex
+	store   *ttlcache.Cache[string, []Spectator] // maps a game id to the spectators watching it
+	pending map[string]chan struct{}             // maps a game id to a channel closed once its last notification is sent
+	wg      *sync.WaitGroup
+}
+
+// MakeSpectatorCache creates a cache where a game's spectators expire once the game hasn't been updated for the game
+// ttl, so spectators of games that ended outside the move flow are eventually removed
+func MakeSpectatorCache(ttl time.Duration) SpectatorCache {
+	return SpectatorCache{
+		mu:      &sync.Mutex{},
+		store:   ttlcache.New[string, []Spectator](ttlcache.WithTTL[string, []Spectator](ttl)),
+		pending: make(map[string]chan struct{}),
+		wg:      &sync.WaitGroup{},
+	}
+}
+
+// Watch subscribes the spectator to the game, a user can only watch a game once from each channel
+func (sc SpectatorCache) Watch(ctx context.Context, gameID string, spectator Spectator) error {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	var spectators []Spectator
+	if item := sc.store.Get(gameID); item != nil {
+		spectators = item.Value()
+	}
+	if slices.Contains(spectators, spectator) {
+		return ErrAlreadyWatching
+	}
+	if len(spectators) >= MaxSpectators {
+		return ErrTooManySpectators
+	}
+
+	sc.store.Set(gameID, append(slices.Clone(spectators), spectator), ttlcache.DefaultTTL)
+	slog.Info("added spectator to game", "trace", ctx.Value(TraceKey), "gameID", gameID, "spectator", spectator)
+	return nil
+}
+
+// Unwatch removes the user's subscriptions to the game from every channel, returns false if the user wasn't watching
+func (sc SpectatorCache) Unwatch(gameID string, userID string) bool {
+	return sc.removeWhere(gameID, func(spectator Spectator) bool {
+		return spectator.UserID == userID
+	})
+}
+
+// Clear removes every spectator of the game, such as when the game is over
+func (sc SpectatorCache) Clear(gameID string) {
+	sc.store.Delete(gameID)
+}
+
+func (sc SpectatorCache) Spectators(gameID string) []Spectator {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	item := sc.store.Get(gameID)
+	if item == nil {
+		return nil
+	}
+	return item.Value()
+}
+
+func (sc SpectatorCache) removeWhere(gameID string, remove func(Spectator) bool) bool {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	item := sc.store.Get(gameID)
+	if item == nil {
+		return false
+	}
+	spectators := slices.DeleteFunc(slices.Clone(item.Value()), remove)
+	if len(spectators) == len(item.Value()) {
+		return false
+	}
+
+	if len(spectators) == 0 {
+		sc.store.Delete(gameID)
+	} else {
+		sc.store.Set(gameID, spectators, ttlcache.DefaultTTL)
+	}
+	return true
+}
+
+// Broadcast sends a message to each spectator of the game and waits for the sends to finish, a spectator whose send
+// fails or takes longer than SpectatorSendTimeout is dropped so a slow channel can't hold up the others
+func (sc SpectatorCache) Broadcast(ctx context.Context, ms ComplexMessageSender, gameID string, createSend func() *discordgo.MessageSend) {
+	trace := ctx.Value(TraceKey)
+
+	var wg sync.WaitGroup
+	for _, spectator := range sc.Spectators(gameID) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			sendCtx, cancel := context.WithTimeout(ctx, SpectatorSendTimeout)
+			defer cancel()
+
+			if _, err := ms.ChannelMessageSendComplex(spectator.ChannelID, createSend(), discordgo.WithContext(sendCtx)); err != nil {
+				slog.Warn("dropping spectator after failed send", "trace", trace, "gameID", gameID, "spectator", spectator, "err", err)
+				sc.removeWhere(gameID, func(s Spectator) bool {
+					return s == spectator
+				})
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// Notify broadcasts a message to the game's spectators in the background so the sends don't hold up the move, the
+// notifications of a game are sent in the order they were made. The spectators are removed after the message when clear
+// is true, such as when the game is over
+func (sc SpectatorCache) Notify(ctx context.Context, ms ComplexMessageSender, gameID string, createSend func() *discordgo.MessageSend, clear bool) {
+	sc.mu.Lock()
+	prev := sc.pending[gameID]
+	done := make(chan struct{})
+	sc.pending[gameID] = done
+	sc.mu.Unlock()
+
+	sc.wg.Add(1)
+	go func() {
+		defer sc.wg.Done()
+		defer func() {
+			sc.mu.Lock()
+			if sc.pending[gameID] == done {
+				delete(sc.pending, gameID)
+			}
+			sc.mu.Unlock()
+			close(done)
+		}()
+
+		if prev != nil {
+			<-prev
+		}
+		sc.Broadcast(ctx, ms, gameID, createSend)
+		if clear {
+			sc.Clear(gameID)
+		}
+	}()
+}
+
+// Wait blocks until every notification has been sent
+func (sc SpectatorCache) Wait() {
+	sc.wg.Wait()
+}
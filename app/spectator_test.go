@@ -0,0 +1,86 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpectatorCache_Watch(t *testing.T) {
+	ctx := context.WithValue(context.Background(), TraceKey, "test-watch")
+	sc := MakeSpectatorCache(GameStoreTtl)
+
+	for i := range MaxSpectators {
+		assert.NoError(t, sc.Watch(ctx, "game1", Spectator{ChannelID: fmt.Sprintf("channel%d", i), UserID: "id1"}))
+	}
+	assert.ErrorIs(t, sc.Watch(ctx, "game1", Spectator{ChannelID: "channel0", UserID: "id1"}), ErrAlreadyWatching)
+	assert.ErrorIs(t, sc.Watch(ctx, "game1", Spectator{ChannelID: "channel9", UserID: "id2"}), ErrTooManySpectators)
+	assert.NoError(t, sc.Watch(ctx, "game2", Spectator{ChannelID: "channel0", UserID: "id2"}))
+
+	assert.False(t, sc.Unwatch("game1", "id2"))
+	assert.True(t, sc.Unwatch("game1", "id1"))
+	assert.Empty(t, sc.Spectators("game1"))
+	assert.Len(t, sc.Spectators("game2"), 1)
+}
+
+type MockComplexSender struct {
+	mu       sync.Mutex
+	failing  string
+	channels []string
+	contents []string
+}
+
+func (m *MockComplexSender) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, _ ...discordgo.RequestOption) (*discordgo.Message, error) {
+	if channelID == m.failing {
+		return nil, errors.New("missing access")
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.channels = append(m.channels, channelID)
+	m.contents = append(m.contents, data.Content)
+	return &discordgo.Message{}, nil
+}
+
+func TestSpectatorCache_Broadcast(t *testing.T) {
+	ctx := context.WithValue(context.Background(), TraceKey, "test-broadcast")
+	sc := MakeSpectatorCache(GameStoreTtl)
+
+	for _, channelID := range []string{"channel1", "channel2", "channel3"} {
+		assert.NoError(t, sc.Watch(ctx, "game1", Spectator{ChannelID: channelID, UserID: "id1"}))
+	}
+
+	ms := &MockComplexSender{failing: "channel2"}
+	sc.Broadcast(ctx, ms, "game1", func() *discordgo.MessageSend {
+		return createStringSend("moved")
+	})
+
+	assert.ElementsMatch(t, []string{"channel1", "channel3"}, ms.channels)
+	// the spectator that failed to receive the move is dropped
+	assert.ElementsMatch(t, []Spectator{{ChannelID: "channel1", UserID: "id1"}, {ChannelID: "channel3", UserID: "id1"}}, sc.Spectators("game1"))
+}
+
+func TestSpectatorCache_Notify(t *testing.T) {
+	ctx := context.WithValue(context.Background(), TraceKey, "test-notify")
+	sc := MakeSpectatorCache(GameStoreTtl)
+	assert.NoError(t, sc.Watch(ctx, "game1", Spectator{ChannelID: "channel1", UserID: "id1"}))
+
+	ms := &MockComplexSender{}
+	var expContents []string
+	for i := range 5 {
+		content := fmt.Sprintf("move %d", i)
+		expContents = append(expContents, content)
+		sc.Notify(ctx, ms, "game1", func() *discordgo.MessageSend {
+			return createStringSend(content)
+		}, i == 4)
+	}
+	sc.Wait()
+
+	// the moves arrive in the order they were made, and the game ending removes its spectators
+	assert.Equal(t, expContents, ms.contents)
+	assert.Empty(t, sc.Spectators("game1"))
+}
@@ -4,9 +4,11 @@ import (
 	"context"
 	"database/sql"
 	_ "embed"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"log"
 	"os"
+	"strings"
 )
 
 const TestDb = "./othellocord-temp.db"
@@ -14,6 +16,47 @@ const TestDb = "./othellocord-temp.db"
 //go:embed schema.sql
 var CreateSchema string
 
+// Migrations add columns to tables created by an older schema, CreateSchema already includes them for new databases.
+// Indexes on added columns are created here since CreateSchema runs before the columns exist on an older database, and
+// added columns derived from existing ones are backfilled here
+var Migrations = []string{
+	"ALTER TABLE games ADD COLUMN created_time INTEGER NOT NULL DEFAULT 0;",
+	"ALTER TABLE settings ADD COLUMN show_moves BOOLEAN NOT NULL DEFAULT TRUE;",
+	"ALTER TABLE stats ADD COLUMN forfeits INTEGER NOT NULL DEFAULT 0;",
+	"ALTER TABLE guilds ADD COLUMN guild_stats BOOLEAN NOT NULL DEFAULT FALSE;",
+	"ALTER TABLE games ADD COLUMN guild_id TEXT NOT NULL DEFAULT '';",
+	"ALTER TABLE settings ADD COLUMN theme TEXT NOT NULL DEFAULT 'classic';",
+	"ALTER TABLE settings ADD COLUMN move_order TEXT NOT NULL DEFAULT 'scan';",
+	"ALTER TABLE game_results ADD COLUMN server_id TEXT NOT NULL DEFAULT '';",
+	"ALTER TABLE games ADD COLUMN server_id TEXT NOT NULL DEFAULT '';",
+	"UPDATE games SET server_id = guild_id WHERE server_id = '';",
+	"ALTER TABLE games ADD COLUMN channel_id TEXT NOT NULL DEFAULT '';",
+	"CREATE INDEX IF NOT EXISTS idx_games_channel_id ON games(channel_id);",
+	"ALTER TABLE games ADD COLUMN warned BOOLEAN NOT NULL DEFAULT FALSE;",
+	"ALTER TABLE stats ADD COLUMN peak_elo FLOAT NOT NULL DEFAULT 1500;",
+	"UPDATE stats SET peak_elo = elo WHERE peak_elo < elo;",
+	"ALTER TABLE settings ADD COLUMN flip_board BOOLEAN NOT NULL DEFAULT FALSE;",
+	"ALTER TABLE game_results ADD COLUMN moves TEXT NOT NULL DEFAULT '';",
+}
+
+type Execer interface {
+	Exec(query string, args ...any) (sql.Result, error)
+}
+
+// MigrateSchema creates any missing tables then applies the migrations, a migration that was already applied is skipped
+func MigrateSchema(db Execer) error {
+	if _, err := db.Exec(CreateSchema); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+	for _, migration := range Migrations {
+		_, err := db.Exec(migration)
+		if err != nil && !strings.Contains(err.Error(), "duplicate column name") {
+			return fmt.Errorf("failed to apply migration %s: %w", migration, err)
+		}
+	}
+	return nil
+}
+
 type CtxQuerier interface {
 	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
 	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
@@ -34,7 +77,7 @@ func createTestDB() (*sqlx.DB, func()) {
 			fail(err)
 		}
 	}
-	if _, err := db.Exec(CreateSchema); err != nil {
+	if err := MigrateSchema(db); err != nil {
 		fail(err)
 	}
 	return db, closer
@@ -0,0 +1,73 @@
+package app
+
+import (
+	"os"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+// BaselineSchema is the schema of a database created before any migrations were added
+const BaselineSchema = `CREATE TABLE IF NOT EXISTS stats (
+    player_id TEXT PRIMARY KEY,
+    elo FLOAT NOT NULL,
+    won INTEGER NOT NULL,
+    drawn INTEGER NOT NULL,
+    lost INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS games (
+    id TEXT NOT NULL,
+    board TEXT NOT NULL,
+    white_id TEXT NOT NULL,
+    black_id TEXT NOT NULL,
+    white_name TEXT NOT NULL,
+    black_name TEXT NOT NULL,
+    moves TEXT NOT NULL,
+    expire_time INTEGER NOT NULL,
+    PRIMARY KEY (id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_stats_elo ON stats(elo);
+CREATE INDEX IF NOT EXISTS idx_games_expire_time ON games(expire_time);
+CREATE INDEX IF NOT EXISTS idx_games_player_ids ON games(white_id, black_id);`
+
+func TestMigrateSchema_Baseline(t *testing.T) {
+	db, err := sqlx.Open("sqlite", TestDb)
+	if err != nil {
+		t.Fatalf("failed to open test db: %v", err)
+	}
+	defer func() {
+		_ = db.Close()
+		_ = os.Remove(TestDb)
+	}()
+
+	_, err = db.Exec(BaselineSchema)
+	assert.NoError(t, err)
+	_, err = db.Exec("INSERT INTO stats (player_id, elo, won, drawn, lost) VALUES ('id1', 1700, 2, 0, 0), ('id2', 1400, 0, 0, 2);")
+	assert.NoError(t, err)
+
+	assert.NoError(t, MigrateSchema(db))
+	// a game rated on its guild's ladder before the server was stored
+	_, err = db.Exec("INSERT INTO games (id, board, white_id, black_id, white_name, black_name, moves, expire_time, guild_id) VALUES ('game1', '', 'id1', 'id2', '', '', '', 0, 'guild1');")
+	assert.NoError(t, err)
+
+	// migrating again must skip the migrations that were already applied
+	assert.NoError(t, MigrateSchema(db))
+
+	var serverID string
+	err = db.Get(&serverID, "SELECT server_id FROM games WHERE id = 'game1';")
+	assert.NoError(t, err)
+	assert.Equal(t, "guild1", serverID)
+
+	var indexes []string
+	err = db.Select(&indexes, "SELECT name FROM sqlite_master WHERE type = 'index' AND tbl_name = 'games' ORDER BY name;")
+	assert.NoError(t, err)
+	assert.Contains(t, indexes, "idx_games_channel_id")
+
+	// the peak of an existing player is at least their rating
+	var peaks []float64
+	err = db.Select(&peaks, "SELECT peak_elo FROM stats ORDER BY player_id;")
+	assert.NoError(t, err)
+	assert.Equal(t, []float64{1700, 1500}, peaks)
+}
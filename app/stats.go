@@ -8,7 +8,11 @@ import (
 	"github.com/jmoiron/sqlx"
 	"log/slog"
 	"math"
+	"math/rand"
+	"slices"
+	"strings"
 
+	"github.com/google/uuid"
 	"golang.org/x/sync/errgroup"
 )
 
@@ -18,14 +22,31 @@ type StatsRow struct {
 	Won      int     `db:"won"`
 	Drawn    int     `db:"drawn"`
 	Lost     int     `db:"lost"`
+	Forfeits int     `db:"forfeits"`
+	PeakElo  float64 `db:"peak_elo"`
+}
+
+// Games counts every finished game that changed the player's record
+func (row StatsRow) Games() int {
+	return row.Won + row.Lost + row.Drawn
+}
+
+// EloK is the K-factor of the player's rating, a provisional player's rating moves faster to find their level
+func (row StatsRow) EloK() float64 {
+	if row.Games() < ProvisionalGames {
+		return ProvisionalEloK
+	}
+	return EloK
 }
 
 type Stats struct {
-	Player Player
-	Elo    float64
-	Won    int
-	Drawn  int
-	Lost   int
+	Player   Player
+	Elo      float64
+	Won      int
+	Drawn    int
+	Lost     int
+	Forfeits int // losses by forfeit, also counted in Lost
+	PeakElo  float64
 }
 
 func (s Stats) WinRate() string {
@@ -44,16 +65,35 @@ func DefaultStats(playerID string) StatsRow {
 		Won:      0,
 		Drawn:    0,
 		Lost:     0,
+		PeakElo:  1500,
 	}
 }
 
+// StatsKey is the player ID stats are stored under, players in a guild with its own ladder are namespaced by the guild
+func StatsKey(guildID string, playerID string) string {
+	if guildID == "" {
+		return playerID
+	}
+	return fmt.Sprintf("%s:%s", guildID, playerID)
+}
+
+// statsPlayerID strips the guild namespace from a stats key
+func statsPlayerID(key string) string {
+	if _, playerID, found := strings.Cut(key, ":"); found {
+		return playerID
+	}
+	return key
+}
+
 func MapStats(row StatsRow) Stats {
 	return Stats{
-		Player: MakePlayer(row.PlayerID, ""),
-		Elo:    row.Elo,
-		Won:    row.Won,
-		Drawn:  row.Drawn,
-		Lost:   row.Lost,
+		Player:   MakePlayer(statsPlayerID(row.PlayerID), ""),
+		Elo:      row.Elo,
+		Won:      row.Won,
+		Drawn:    row.Drawn,
+		Lost:     row.Lost,
+		Forfeits: row.Forfeits,
+		PeakElo:  max(row.PeakElo, row.Elo),
 	}
 }
 
@@ -72,12 +112,12 @@ func GetStatsDefault(ctx context.Context, q CtxQuerier, defaultStats StatsRow) (
 	var stats StatsRow
 	isCreated := false
 
-	err := q.GetContext(ctx, &stats, "SELECT player_id, elo, won, lost, drawn FROM stats WHERE player_id = $1;", defaultStats.PlayerID)
+	err := q.GetContext(ctx, &stats, "SELECT player_id, elo, won, lost, drawn, forfeits, peak_elo FROM stats WHERE player_id = $1;", defaultStats.PlayerID)
 	if errors.Is(err, sql.ErrNoRows) {
 		stats = defaultStats
 		_, err = q.ExecContext(ctx,
-			"INSERT INTO STATS (player_id, elo, won, lost, drawn) VALUES ($1, $2, $3, $4, $5)",
-			stats.PlayerID, stats.Elo, stats.Won, stats.Lost, stats.Drawn,
+			"INSERT INTO STATS (player_id, elo, won, lost, drawn, forfeits, peak_elo) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+			stats.PlayerID, stats.Elo, stats.Won, stats.Lost, stats.Drawn, stats.Forfeits, stats.PeakElo,
 		)
 		isCreated = true
 	}
@@ -89,11 +129,19 @@ func GetStatsDefault(ctx context.Context, q CtxQuerier, defaultStats StatsRow) (
 	return stats, nil
 }
 
-func GetTopStats(ctx context.Context, db *sqlx.DB, count int) ([]StatsRow, error) {
+// GetTopStats selects the highest rated players on the guild's ladder after skipping offset players, or the global
+// ladder if the guild ID is empty. Players with the same rating keep the newest first order of the elo index, the order
+// is fixed so the pages don't overlap
+func GetTopStats(ctx context.Context, db *sqlx.DB, guildID string, count int, offset int) ([]StatsRow, error) {
 	trace := ctx.Value(TraceKey)
 
 	var stats []StatsRow
-	err := db.SelectContext(ctx, &stats, "SELECT player_id, elo, won, lost, drawn FROM stats ORDER BY elo DESC LIMIT $1;", count)
+	var err error
+	if guildID == "" {
+		err = db.SelectContext(ctx, &stats, "SELECT player_id, elo, won, lost, drawn, forfeits, peak_elo FROM stats WHERE instr(player_id, ':') = 0 ORDER BY elo DESC, rowid DESC LIMIT $1 OFFSET $2;", count, offset)
+	} else {
+		err = db.SelectContext(ctx, &stats, "SELECT player_id, elo, won, lost, drawn, forfeits, peak_elo FROM stats WHERE player_id LIKE $1 ORDER BY elo DESC, rowid DESC LIMIT $2 OFFSET $3;", StatsKey(guildID, "%"), count, offset)
+	}
 	if err != nil {
 		slog.Error("failed to get top stats", "trace", trace, "err", err)
 		return nil, err
@@ -103,10 +151,147 @@ func GetTopStats(ctx context.Context, db *sqlx.DB, count int) ([]StatsRow, error
 	return stats, nil
 }
 
+// CountStatsRows counts the players on the guild's ladder, or the global ladder if the guild ID is empty
+func CountStatsRows(ctx context.Context, db *sqlx.DB, guildID string) (int, error) {
+	var count int
+	var err error
+	if guildID == "" {
+		err = db.GetContext(ctx, &count, "SELECT COUNT(*) FROM stats WHERE instr(player_id, ':') = 0;")
+	} else {
+		err = db.GetContext(ctx, &count, "SELECT COUNT(*) FROM stats WHERE player_id LIKE $1;", StatsKey(guildID, "%"))
+	}
+	if err != nil {
+		slog.Error("failed to count stats", "trace", ctx.Value(TraceKey), "guildID", guildID, "err", err)
+		return 0, fmt.Errorf("failed to count stats: %w", err)
+	}
+	return count, nil
+}
+
+// guildPlayersQuery selects the players who finished a game in the guild
+const guildPlayersQuery = "SELECT winner_id FROM game_results WHERE server_id = $1 UNION SELECT loser_id FROM game_results WHERE server_id = $1"
+
+// GetTopStatsByGuild selects the highest rated players of the guild after skipping offset players, a guild with its
+// own ladder ranks its ladder while any other guild ranks the global ratings of the players who finished a game in it
+func GetTopStatsByGuild(ctx context.Context, db *sqlx.DB, guildID string, ladder bool, count int, offset int) ([]StatsRow, error) {
+	if ladder {
+		return GetTopStats(ctx, db, guildID, count, offset)
+	}
+	trace := ctx.Value(TraceKey)
+
+	var stats []StatsRow
+	err := db.SelectContext(ctx, &stats,
+		"SELECT player_id, elo, won, lost, drawn, forfeits, peak_elo FROM stats WHERE player_id IN ("+guildPlayersQuery+") ORDER BY elo DESC, rowid DESC LIMIT $2 OFFSET $3;",
+		guildID, count, offset,
+	)
+	if err != nil {
+		slog.Error("failed to get top stats by guild", "trace", trace, "guildID", guildID, "err", err)
+		return nil, err
+	}
+
+	slog.Info("selected top stats by guild", "trace", trace, "guildID", guildID, "stats", stats)
+	return stats, nil
+}
+
+// CountStatsRowsByGuild counts the players of the guild ranked by GetTopStatsByGuild
+func CountStatsRowsByGuild(ctx context.Context, db *sqlx.DB, guildID string, ladder bool) (int, error) {
+	if ladder {
+		return CountStatsRows(ctx, db, guildID)
+	}
+
+	var count int
+	if err := db.GetContext(ctx, &count, "SELECT COUNT(*) FROM stats WHERE player_id IN ("+guildPlayersQuery+");", guildID); err != nil {
+		slog.Error("failed to count stats by guild", "trace", ctx.Value(TraceKey), "guildID", guildID, "err", err)
+		return 0, fmt.Errorf("failed to count stats: %w", err)
+	}
+	return count, nil
+}
+
+// SeedPrefix flags synthetic stats rows so they can be told apart from real players and purged
+const SeedPrefix = "seed-"
+
+func IsSeededID(playerID string) bool {
+	return strings.HasPrefix(playerID, SeedPrefix)
+}
+
+func RandomStats(playerID string) StatsRow {
+	elo := math.Round((1000+rand.Float64()*1000)*100) / 100
+	return StatsRow{
+		PlayerID: playerID,
+		Elo:      elo,
+		Won:      rand.Intn(50),
+		Drawn:    rand.Intn(10),
+		Lost:     rand.Intn(50),
+		PeakElo:  elo,
+	}
+}
+
+func SeedRandomStats(ctx context.Context, db *sqlx.DB, n int) error {
+	trace := ctx.Value(TraceKey)
+
+	fail := func(err error) error {
+		slog.Error("failed to seed random stats", "trace", trace, "count", n, "err", err)
+		return err
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fail(err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	for range n {
+		stats := RandomStats(SeedPrefix + uuid.NewString())
+		_, err = tx.ExecContext(ctx,
+			"INSERT INTO stats (player_id, elo, won, lost, drawn, peak_elo) VALUES ($1, $2, $3, $4, $5, $6)",
+			stats.PlayerID, stats.Elo, stats.Won, stats.Lost, stats.Drawn, stats.PeakElo,
+		)
+		if err != nil {
+			return fail(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fail(err)
+	}
+
+	slog.Info("seeded random stats", "trace", trace, "count", n)
+	return nil
+}
+
+func PurgeSeededStats(ctx context.Context, db *sqlx.DB) (int64, error) {
+	trace := ctx.Value(TraceKey)
+
+	result, err := db.ExecContext(ctx, "DELETE FROM stats WHERE player_id LIKE $1;", SeedPrefix+"%")
+	if err != nil {
+		slog.Error("failed to purge seeded stats", "trace", trace, "err", err)
+		return 0, err
+	}
+	count, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	slog.Info("purged seeded stats", "trace", trace, "count", count)
+	return count, nil
+}
+
+// ResetStats sets the player's rating back to the default and clears their record, a player without stats is left as is
+// since they already start from the default
+func ResetStats(ctx context.Context, db *sqlx.DB, playerID string) error {
+	trace := ctx.Value(TraceKey)
+
+	if err := updateStat(ctx, db, DefaultStats(playerID)); err != nil {
+		slog.Error("failed to reset stats", "trace", trace, "playerID", playerID, "err", err)
+		return err
+	}
+
+	slog.Info("reset stats", "trace", trace, "playerID", playerID)
+	return nil
+}
+
 func updateStat(ctx context.Context, q CtxQuerier, stats StatsRow) error {
 	_, err := q.ExecContext(ctx,
-		"UPDATE stats SET elo = ?, won = ?, lost = ?, drawn = ? WHERE player_id = ?;",
-		stats.Elo, stats.Won, stats.Lost, stats.Drawn, stats.PlayerID,
+		"UPDATE stats SET elo = ?, won = ?, lost = ?, drawn = ?, forfeits = ?, peak_elo = ? WHERE player_id = ?;",
+		stats.Elo, stats.Won, stats.Lost, stats.Drawn, stats.Forfeits, stats.PeakElo, stats.PlayerID,
 	)
 	return err
 }
@@ -116,6 +301,7 @@ type StatsResult struct {
 	LoserElo  float64
 	WinDiff   float64
 	LoseDiff  float64
+	Match     *MatchResult // the standing of the match the game belonged to, nil when it wasn't part of one
 }
 
 func formatElo(elo float64) string {
@@ -134,7 +320,8 @@ func (s StatsResult) FormatLoserEloDiff() string {
 	return formatElo(s.LoseDiff)
 }
 
-func UpdateStats(ctx context.Context, q CtxQuerier, gr GameResult) (StatsResult, error) {
+// UpdateStats rates the result on the guild's ladder, or the global ladder if the guild ID is empty
+func UpdateStats(ctx context.Context, q CtxQuerier, guildID string, gr GameResult) (StatsResult, error) {
 	trace := ctx.Value(TraceKey)
 
 	fail := func(err error) (StatsResult, error) {
@@ -142,11 +329,11 @@ func UpdateStats(ctx context.Context, q CtxQuerier, gr GameResult) (StatsResult,
 		return StatsResult{}, err
 	}
 
-	winner, err := GetStats(ctx, q, gr.Winner.ID)
+	winner, err := GetStats(ctx, q, StatsKey(guildID, gr.Winner.ID))
 	if err != nil {
 		return fail(fmt.Errorf("failed to get winner stats: %w", err))
 	}
-	loser, err := GetStats(ctx, q, gr.Loser.ID)
+	loser, err := GetStats(ctx, q, StatsKey(guildID, gr.Loser.ID))
 	if err != nil {
 		return fail(fmt.Errorf("failed to get loser stats: %w", err))
 	}
@@ -157,10 +344,14 @@ func UpdateStats(ctx context.Context, q CtxQuerier, gr GameResult) (StatsResult,
 
 	winBefore := winner.Elo
 	lossBefore := loser.Elo
-	winner.Elo = calcEloWon(winner.Elo, probability(loser.Elo, winner.Elo))
-	loser.Elo = calcEloLost(loser.Elo, probability(winner.Elo, loser.Elo))
+	winner.Elo = calcEloWon(winner.Elo, probability(loser.Elo, winner.Elo), winner.EloK())
+	loser.Elo = calcEloLost(loser.Elo, probability(winner.Elo, loser.Elo), loser.EloK())
+	winner.PeakElo = max(winner.PeakElo, winner.Elo)
 	winner.Won++
 	loser.Lost++
+	if gr.Kind == Forfeit {
+		loser.Forfeits++
+	}
 
 	if err := updateStat(ctx, q, winner); err != nil {
 		return fail(fmt.Errorf("failed to update winner stat: %w", err))
@@ -178,27 +369,32 @@ func UpdateStats(ctx context.Context, q CtxQuerier, gr GameResult) (StatsResult,
 }
 
 const EloK = 30
+const ProvisionalEloK = 40
+const ProvisionalGames = 10 // players with fewer games than this are provisional
+const EloFloor = 100        // ratings never drop below the floor
 
 func probability(rating1, rating2 float64) float64 {
 	return 1.0 / (1.0 + math.Pow(10, (rating1-rating2)/400.0))
 }
 
-func calcEloWon(rating, probability float64) float64 {
-	return rating + EloK*(1.0-probability)
+func calcEloWon(rating, probability, k float64) float64 {
+	return rating + k*(1.0-probability)
 }
 
-func calcEloLost(rating, probability float64) float64 {
-	return rating - EloK*probability
+func calcEloLost(rating, probability, k float64) float64 {
+	return math.Max(rating-k*probability, EloFloor)
 }
 
-func ReadStats(ctx context.Context, db *sqlx.DB, uc UserCacheApi, playerID string) (Stats, error) {
-	row, err := GetStats(ctx, db, playerID)
+func ReadStats(ctx context.Context, db *sqlx.DB, uc UserCacheApi, guildID string, playerID string) (Stats, error) {
+	row, err := GetStats(ctx, db, StatsKey(guildID, playerID))
 	if err != nil {
 		return Stats{}, fmt.Errorf("failed to next row: %w", err)
 	}
 	stats := MapStats(row)
 
-	if stats.Player.IsHuman() {
+	if IsSeededID(playerID) {
+		stats.Player.Name = playerID
+	} else if stats.Player.IsHuman() {
 		if stats.Player.Name, err = uc.GetUsername(ctx, playerID); err != nil {
 			return Stats{}, fmt.Errorf("failed to get username: %w", err)
 		}
@@ -206,14 +402,37 @@ func ReadStats(ctx context.Context, db *sqlx.DB, uc UserCacheApi, playerID strin
 	return stats, nil
 }
 
-func ReadTopStats(ctx context.Context, db *sqlx.DB, uc UserCacheApi, count int) ([]Stats, error) {
-	trace := ctx.Value(TraceKey)
-
-	rowList, err := GetTopStats(ctx, db, count)
+func ReadTopStats(ctx context.Context, db *sqlx.DB, uc UserCacheApi, guildID string, count int, offset int) ([]Stats, error) {
+	rowList, err := GetTopStats(ctx, db, guildID, count, offset)
 	if err != nil {
 		return nil, fmt.Errorf("failed to next top stats: %w", err)
 	}
+	statsList, err := readStatsRows(ctx, uc, rowList)
+	if err != nil {
+		return nil, err
+	}
 
+	slog.Info("fetched top stats", "trace", ctx.Value(TraceKey), "count", count, "offset", offset)
+	return statsList, nil
+}
+
+// ReadTopStatsByGuild reads the highest rated players of the guild, see GetTopStatsByGuild
+func ReadTopStatsByGuild(ctx context.Context, db *sqlx.DB, uc UserCacheApi, guildID string, ladder bool, count int, offset int) ([]Stats, error) {
+	rowList, err := GetTopStatsByGuild(ctx, db, guildID, ladder, count, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get top stats by guild: %w", err)
+	}
+	statsList, err := readStatsRows(ctx, uc, rowList)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.Info("fetched top stats by guild", "trace", ctx.Value(TraceKey), "guildID", guildID, "count", count, "offset", offset)
+	return statsList, nil
+}
+
+// readStatsRows maps the stats rows and fetches the name of each player, a player listed twice keeps the first entry
+func readStatsRows(ctx context.Context, uc UserCacheApi, rowList []StatsRow) ([]Stats, error) {
 	eg, ctx := errgroup.WithContext(ctx)
 	statsList := make([]Stats, len(rowList))
 
@@ -224,6 +443,10 @@ func ReadTopStats(ctx context.Context, db *sqlx.DB, uc UserCacheApi, count int)
 		if stats.Player.IsBot() {
 			continue
 		}
+		if IsSeededID(stats.Player.ID) {
+			stats.Player.Name = stats.Player.ID
+			continue
+		}
 
 		eg.Go(func() error {
 			username, err := uc.GetUsername(ctx, stats.Player.ID)
@@ -238,6 +461,19 @@ func ReadTopStats(ctx context.Context, db *sqlx.DB, uc UserCacheApi, count int)
 		return nil, err
 	}
 
-	slog.Info("fetched top stats", "trace", trace, "count", count)
-	return statsList, nil
+	return dedupeStats(statsList), nil
+}
+
+// dedupeStats removes later entries for a player already in the list, keeping the first (highest rated) entry
+func dedupeStats(statsList []Stats) []Stats {
+	var deduped []Stats
+	for _, stats := range statsList {
+		isDupe := slices.ContainsFunc(deduped, func(s Stats) bool {
+			return s.Player.Equal(stats.Player)
+		})
+		if !isDupe {
+			deduped = append(deduped, stats)
+		}
+	}
+	return deduped
 }
@@ -23,6 +23,7 @@ func setupStatsTest(t *testing.T) (*sqlx.DB, func()) {
 			Won:      3,
 			Lost:     2,
 			Drawn:    1,
+			PeakElo:  1800,
 		},
 		{
 			PlayerID: "id2",
@@ -30,6 +31,7 @@ func setupStatsTest(t *testing.T) (*sqlx.DB, func()) {
 			Won:      2,
 			Lost:     4,
 			Drawn:    1,
+			PeakElo:  1600,
 		},
 		{
 			PlayerID: "3",
@@ -37,6 +39,7 @@ func setupStatsTest(t *testing.T) (*sqlx.DB, func()) {
 			Won:      5,
 			Lost:     2,
 			Drawn:    0,
+			PeakElo:  1550,
 		},
 		{
 			PlayerID: "id6",
@@ -44,6 +47,7 @@ func setupStatsTest(t *testing.T) (*sqlx.DB, func()) {
 			Won:      2,
 			Lost:     4,
 			Drawn:    1,
+			PeakElo:  1500,
 		},
 		{
 			PlayerID: "id7",
@@ -51,6 +55,7 @@ func setupStatsTest(t *testing.T) (*sqlx.DB, func()) {
 			Won:      5,
 			Lost:     2,
 			Drawn:    0,
+			PeakElo:  1250,
 		},
 	}
 
@@ -74,11 +79,11 @@ func TestReadStats(t *testing.T) {
 	tests := []Test{
 		{
 			playerID: "id1",
-			expStats: Stats{Player: Player{ID: "id1", Name: "Player1"}, Elo: 1750, Won: 3, Lost: 2, Drawn: 1},
+			expStats: Stats{Player: Player{ID: "id1", Name: "Player1"}, Elo: 1750, Won: 3, Lost: 2, Drawn: 1, PeakElo: 1800},
 		},
 		{
 			playerID: "id4",
-			expStats: Stats{Player: Player{ID: "id4", Name: "Player4"}, Elo: 1500, Won: 0, Lost: 0, Drawn: 0},
+			expStats: Stats{Player: Player{ID: "id4", Name: "Player4"}, Elo: 1500, Won: 0, Lost: 0, Drawn: 0, PeakElo: 1500},
 		},
 	}
 
@@ -87,7 +92,7 @@ func TestReadStats(t *testing.T) {
 			ctx := context.WithValue(context.Background(), TraceKey, "test-next-stats")
 
 			uc := MakeUserCache(&MockUserFetcher{})
-			stats, err := ReadStats(ctx, db, &uc, test.playerID)
+			stats, err := ReadStats(ctx, db, &uc, "", test.playerID)
 			if err != nil {
 				t.Fatalf("failed to next stats: %v", err)
 			}
@@ -108,11 +113,11 @@ func TestGetTopStats(t *testing.T) {
 		{
 			playerID: "1",
 			expStats: []Stats{
-				{Player: Player{ID: "id1", Name: "Player1"}, Elo: 1750, Won: 3, Lost: 2, Drawn: 1},
-				{Player: Player{ID: "id2", Name: "Player2"}, Elo: 1600, Won: 2, Lost: 4, Drawn: 1},
-				{Player: MakeBotPlayer(3), Elo: 1550, Won: 5, Lost: 2, Drawn: 0},
-				{Player: Player{ID: "id6", Name: "Player6"}, Elo: 1500, Won: 2, Lost: 4, Drawn: 1},
-				{Player: Player{ID: "id7", Name: "Player7"}, Elo: 1250, Won: 5, Lost: 2, Drawn: 0},
+				{Player: Player{ID: "id1", Name: "Player1"}, Elo: 1750, Won: 3, Lost: 2, Drawn: 1, PeakElo: 1800},
+				{Player: Player{ID: "id2", Name: "Player2"}, Elo: 1600, Won: 2, Lost: 4, Drawn: 1, PeakElo: 1600},
+				{Player: MakeBotPlayer(3), Elo: 1550, Won: 5, Lost: 2, Drawn: 0, PeakElo: 1550},
+				{Player: Player{ID: "id6", Name: "Player6"}, Elo: 1500, Won: 2, Lost: 4, Drawn: 1, PeakElo: 1500},
+				{Player: Player{ID: "id7", Name: "Player7"}, Elo: 1250, Won: 5, Lost: 2, Drawn: 0, PeakElo: 1250},
 			},
 		},
 	}
@@ -122,7 +127,7 @@ func TestGetTopStats(t *testing.T) {
 			ctx := context.WithValue(context.Background(), TraceKey, "test-next-top-stats")
 
 			uc := MakeUserCache(&MockUserFetcher{})
-			stats, err := ReadTopStats(ctx, db, &uc, 20)
+			stats, err := ReadTopStats(ctx, db, &uc, "", 20, 0)
 			if err != nil {
 				t.Fatalf("failed to next stats: %v", err)
 			}
@@ -132,6 +137,93 @@ func TestGetTopStats(t *testing.T) {
 	}
 }
 
+func TestGetTopStats_Offset(t *testing.T) {
+	db, cleanup := setupStatsTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-top-stats-offset")
+
+	count, err := CountStatsRows(ctx, db, "")
+	if err != nil {
+		t.Fatalf("failed to count stats: %v", err)
+	}
+	assert.Equal(t, 5, count)
+
+	var pages [][]StatsRow
+	for offset := 0; offset < count; offset += 2 {
+		stats, err := GetTopStats(ctx, db, "", 2, offset)
+		if err != nil {
+			t.Fatalf("failed to get top stats: %v", err)
+		}
+		pages = append(pages, stats)
+	}
+
+	var ids [][]string
+	for _, page := range pages {
+		var pageIDs []string
+		for _, row := range page {
+			pageIDs = append(pageIDs, row.PlayerID)
+		}
+		ids = append(ids, pageIDs)
+	}
+	assert.Equal(t, [][]string{{"id1", "id2"}, {"3", "id6"}, {"id7"}}, ids)
+}
+
+func TestReadTopStatsByGuild(t *testing.T) {
+	db, cleanup := setupStatsTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-read-top-stats-by-guild")
+	uc := MakeUserCache(&MockUserFetcher{})
+
+	results := []GameResultRow{
+		{GameID: "1", WinnerID: "id1", LoserID: "id6", ServerID: "guild1"},
+		{GameID: "2", WinnerID: "3", LoserID: "id1", ServerID: "guild1"},
+		{GameID: "3", WinnerID: "id2", LoserID: "id7", ServerID: "guild2"},
+		{GameID: "4", WinnerID: "id2", LoserID: "id1"},
+	}
+	for _, row := range results {
+		if err := InsertGameResult(ctx, db, row); err != nil {
+			t.Fatalf("failed to insert game result: %v", err)
+		}
+	}
+	if _, err := GetStatsDefault(ctx, db, StatsRow{PlayerID: StatsKey("guild1", "id7"), Elo: 1520, PeakElo: 1520}); err != nil {
+		t.Fatalf("failed to insert stats: %v", err)
+	}
+
+	type Test struct {
+		guildID    string
+		ladder     bool
+		expPlayers []Player
+	}
+	tests := []Test{
+		{guildID: "guild1", expPlayers: []Player{{ID: "id1", Name: "Player1"}, MakeBotPlayer(3), {ID: "id6", Name: "Player6"}}},
+		{guildID: "guild2", expPlayers: []Player{{ID: "id2", Name: "Player2"}, {ID: "id7", Name: "Player7"}}},
+		{guildID: "guild1", ladder: true, expPlayers: []Player{{ID: "id7", Name: "Player7"}}},
+		{guildID: "guild3"},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			stats, err := ReadTopStatsByGuild(ctx, db, &uc, test.guildID, test.ladder, LeaderboardSize, 0)
+			if err != nil {
+				t.Fatalf("failed to read top stats: %v", err)
+			}
+			var players []Player
+			for _, s := range stats {
+				players = append(players, s.Player)
+			}
+			assert.Equal(t, test.expPlayers, players)
+
+			count, err := CountStatsRowsByGuild(ctx, db, test.guildID, test.ladder)
+			if err != nil {
+				t.Fatalf("failed to count stats: %v", err)
+			}
+			assert.Equal(t, len(test.expPlayers), count)
+		})
+	}
+}
+
 func TestUpdateStats(t *testing.T) {
 	db, cleanup := setupStatsTest(t)
 	defer cleanup()
@@ -146,14 +238,20 @@ func TestUpdateStats(t *testing.T) {
 		{
 			gr:            GameResult{Winner: Player{ID: "id1"}, Loser: Player{ID: "id1"}, IsDraw: false},
 			expSr:         StatsResult{WinnerElo: 1750, LoserElo: 1750, WinDiff: 0, LoseDiff: 0},
-			expWinStats:   StatsRow{PlayerID: "id1", Elo: 1750, Won: 3, Drawn: 1, Lost: 2},
-			expLoserStats: StatsRow{PlayerID: "id1", Elo: 1750, Won: 3, Drawn: 1, Lost: 2},
+			expWinStats:   StatsRow{PlayerID: "id1", Elo: 1750, Won: 3, Drawn: 1, Lost: 2, PeakElo: 1800},
+			expLoserStats: StatsRow{PlayerID: "id1", Elo: 1750, Won: 3, Drawn: 1, Lost: 2, PeakElo: 1800},
 		},
 		{
 			gr:            GameResult{Winner: Player{ID: "id6"}, Loser: Player{ID: "id7"}, IsDraw: false},
-			expSr:         StatsResult{WinnerElo: 1506, LoserElo: 1244, WinDiff: 6, LoseDiff: -6},
-			expWinStats:   StatsRow{PlayerID: "id6", Elo: 1506, Won: 3, Drawn: 1, Lost: 4},
-			expLoserStats: StatsRow{PlayerID: "id7", Elo: 1244, Won: 5, Drawn: 0, Lost: 3},
+			expSr:         StatsResult{WinnerElo: 1508, LoserElo: 1243, WinDiff: 8, LoseDiff: -7},
+			expWinStats:   StatsRow{PlayerID: "id6", Elo: 1508, Won: 3, Drawn: 1, Lost: 4, PeakElo: 1508},
+			expLoserStats: StatsRow{PlayerID: "id7", Elo: 1243, Won: 5, Drawn: 0, Lost: 3, PeakElo: 1250},
+		},
+		{
+			gr:            GameResult{Winner: Player{ID: "id2"}, Loser: MakeBotPlayer(3), IsDraw: false, Kind: Forfeit},
+			expSr:         StatsResult{WinnerElo: 1617, LoserElo: 1534, WinDiff: 17, LoseDiff: -16},
+			expWinStats:   StatsRow{PlayerID: "id2", Elo: 1617, Won: 3, Drawn: 1, Lost: 4, PeakElo: 1617},
+			expLoserStats: StatsRow{PlayerID: "3", Elo: 1534, Won: 5, Drawn: 0, Lost: 3, Forfeits: 1, PeakElo: 1550},
 		},
 	}
 
@@ -168,7 +266,7 @@ func TestUpdateStats(t *testing.T) {
 		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
 			ctx := context.WithValue(context.Background(), TraceKey, "test-next-top-stats")
 
-			sr, err := UpdateStats(ctx, db, test.gr)
+			sr, err := UpdateStats(ctx, db, "", test.gr)
 			if err != nil {
 				t.Fatalf("failed to update stats: %v", err)
 			}
@@ -185,6 +283,7 @@ func TestUpdateStats(t *testing.T) {
 				t.Fatalf("failed to get or insert loser stats: %v", err)
 			}
 			ws.Elo = math.Round(ws.Elo)
+			ws.PeakElo = math.Round(ws.PeakElo)
 			ls.Elo = math.Round(ls.Elo)
 
 			assert.Equal(t, test.expWinStats, ws)
@@ -192,3 +291,173 @@ func TestUpdateStats(t *testing.T) {
 		})
 	}
 }
+
+func TestUpdateStats_Provisional(t *testing.T) {
+	db, cleanup := createTestDB()
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-update-stats-provisional")
+
+	rows := []StatsRow{
+		{PlayerID: "new", Elo: 1500, Won: 5, Lost: 4},
+		{PlayerID: "veteran", Elo: 1500, Won: 50, Lost: 50, Drawn: 10},
+		{PlayerID: "rookie", Elo: 110, Won: 20, Lost: 20},
+		{PlayerID: "beginner", Elo: 110, Won: 1, Lost: 30},
+	}
+	for _, row := range rows {
+		if _, err := GetStatsDefault(ctx, db, row); err != nil {
+			t.Fatal("failed to insert stats:", err)
+		}
+	}
+	assert.Equal(t, float64(ProvisionalEloK), rows[0].EloK())
+	assert.Equal(t, float64(EloK), rows[1].EloK())
+
+	type Test struct {
+		gr          GameResult
+		expWinDiff  float64
+		expLoseDiff float64
+	}
+	tests := []Test{
+		// the provisional player wins with the higher K, then reaches the provisional game count
+		{gr: GameResult{Winner: Player{ID: "new"}, Loser: Player{ID: "veteran"}}, expWinDiff: 20, expLoseDiff: -14},
+		// the player is established now, so the next win uses the regular K
+		{gr: GameResult{Winner: Player{ID: "new"}, Loser: Player{ID: "veteran"}}, expWinDiff: 14, expLoseDiff: -13},
+		// the loss would drop the rating below the floor
+		{gr: GameResult{Winner: Player{ID: "rookie"}, Loser: Player{ID: "beginner"}}, expWinDiff: 15, expLoseDiff: -10},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			sr, err := UpdateStats(ctx, db, "", test.gr)
+			if err != nil {
+				t.Fatalf("failed to update stats: %v", err)
+			}
+			assert.Equal(t, test.expWinDiff, math.Round(sr.WinDiff))
+			assert.Equal(t, test.expLoseDiff, math.Round(sr.LoseDiff))
+			assert.GreaterOrEqual(t, sr.LoserElo, float64(EloFloor))
+		})
+	}
+
+	beginner, err := GetStats(ctx, db, "beginner")
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+	assert.Equal(t, float64(EloFloor), beginner.Elo)
+}
+
+func TestSeedRandomStats(t *testing.T) {
+	db, cleanup := setupStatsTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-seed-stats")
+
+	if err := SeedRandomStats(ctx, db, 25); err != nil {
+		t.Fatalf("failed to seed stats: %v", err)
+	}
+
+	var count int
+	if err := db.Get(&count, "SELECT COUNT(*) FROM stats WHERE player_id LIKE 'seed-%';"); err != nil {
+		t.Fatalf("failed to count seeded stats: %v", err)
+	}
+	assert.Equal(t, 25, count)
+
+	uc := MakeUserCache(&MockUserFetcher{})
+	stats, err := ReadTopStats(ctx, db, &uc, "", 50, 0)
+	if err != nil {
+		t.Fatalf("failed to read top stats: %v", err)
+	}
+	assert.Equal(t, 30, len(stats))
+
+	purged, err := PurgeSeededStats(ctx, db)
+	if err != nil {
+		t.Fatalf("failed to purge seeded stats: %v", err)
+	}
+	assert.Equal(t, int64(25), purged)
+}
+
+func TestResetStats(t *testing.T) {
+	db, cleanup := setupStatsTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-reset-stats")
+
+	if err := ResetStats(ctx, db, "id1"); err != nil {
+		t.Fatalf("failed to reset stats: %v", err)
+	}
+	stats, err := GetStats(ctx, db, "id1")
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+	assert.Equal(t, DefaultStats("id1"), stats)
+
+	// other players keep their stats
+	stats, err = GetStats(ctx, db, "id2")
+	if err != nil {
+		t.Fatalf("failed to get stats: %v", err)
+	}
+	assert.Equal(t, 1600.0, stats.Elo)
+
+	assert.NoError(t, ResetStats(ctx, db, "id9"))
+}
+
+func TestDedupeStats(t *testing.T) {
+	statsList := []Stats{
+		{Player: Player{ID: "id1", Name: "Player1"}, Elo: 1700},
+		{Player: Player{ID: "id2", Name: "Player2"}, Elo: 1600},
+		{Player: Player{ID: "id1", Name: "Stale"}, Elo: 1500},
+	}
+	assert.Equal(t, statsList[:2], dedupeStats(statsList))
+}
+
+func TestUpdateStats_GuildLadders(t *testing.T) {
+	db, cleanup := setupStatsTest(t)
+	defer cleanup()
+
+	ctx := context.WithValue(context.Background(), TraceKey, "test-guild-ladders")
+	uc := MakeUserCache(&MockUserFetcher{})
+
+	gr := GameResult{Winner: Player{ID: "id1"}, Loser: Player{ID: "id2"}}
+	if _, err := UpdateStats(ctx, db, "guild1", gr); err != nil {
+		t.Fatalf("failed to update stats: %v", err)
+	}
+	gr = GameResult{Winner: Player{ID: "id2"}, Loser: Player{ID: "id1"}}
+	if _, err := UpdateStats(ctx, db, "guild2", gr); err != nil {
+		t.Fatalf("failed to update stats: %v", err)
+	}
+
+	type Test struct {
+		guildID  string
+		expStats Stats
+	}
+	tests := []Test{
+		{guildID: "", expStats: Stats{Player: Player{ID: "id1", Name: "Player1"}, Elo: 1750, Won: 3, Lost: 2, Drawn: 1, PeakElo: 1800}},
+		{guildID: "guild1", expStats: Stats{Player: Player{ID: "id1", Name: "Player1"}, Elo: 1520, Won: 1, PeakElo: 1520}},
+		{guildID: "guild2", expStats: Stats{Player: Player{ID: "id1", Name: "Player1"}, Elo: 1481, Lost: 1, PeakElo: 1500}},
+	}
+
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d", i), func(t *testing.T) {
+			stats, err := ReadStats(ctx, db, &uc, test.guildID, "id1")
+			if err != nil {
+				t.Fatalf("failed to read stats: %v", err)
+			}
+			stats.Elo = math.Round(stats.Elo)
+			stats.PeakElo = math.Round(stats.PeakElo)
+			assert.Equal(t, test.expStats, stats)
+		})
+	}
+
+	// each ladder only contains the players rated on it
+	top, err := ReadTopStats(ctx, db, &uc, "guild1", LeaderboardSize, 0)
+	if err != nil {
+		t.Fatalf("failed to read top stats: %v", err)
+	}
+	assert.Equal(t, []Player{{ID: "id1", Name: "Player1"}, {ID: "id2", Name: "Player2"}}, []Player{top[0].Player, top[1].Player})
+	assert.Len(t, top, 2)
+
+	top, err = ReadTopStats(ctx, db, &uc, "", LeaderboardSize, 0)
+	if err != nil {
+		t.Fatalf("failed to read top stats: %v", err)
+	}
+	assert.Len(t, top, 5)
+}
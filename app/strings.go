@@ -2,7 +2,9 @@ package app
 
 import (
 	"log/slog"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func column(str string, size int, tail string) string {
@@ -43,3 +45,60 @@ func parseCustomId(customID string) (string, string) {
 	key := customID[index+1:]
 	return cond, key
 }
+
+func parseGameKey(key string) (string, string) {
+	gameID, playerID, found := strings.Cut(key, ",")
+	if !found {
+		slog.Warn("received a game key without a ',' delimiter", "key", key)
+		return "", ""
+	}
+	return gameID, playerID
+}
+
+func parseTakebackKey(key string) (string, string, int) {
+	gameID, playerID := parseGameKey(key)
+	playerID, countStr, found := strings.Cut(playerID, ",")
+	if !found {
+		slog.Warn("received a takeback key without a move count", "key", key)
+		return "", "", 0
+	}
+	moveCount, err := strconv.Atoi(countStr)
+	if err != nil {
+		slog.Warn("received a takeback key with an invalid move count", "key", key)
+		return "", "", 0
+	}
+	return gameID, playerID, moveCount
+}
+
+func parseRematchKey(key string) (string, string, time.Time) {
+	parts := strings.SplitN(key, ",", 3)
+	if len(parts) != 3 {
+		slog.Warn("received a rematch key without two ',' delimiters", "key", key)
+		return "", "", time.Time{}
+	}
+	finished, err := strconv.ParseInt(parts[2], 10, 64)
+	if err != nil {
+		slog.Warn("received a rematch key with an invalid finished time", "key", key)
+		return "", "", time.Time{}
+	}
+	return parts[0], parts[1], time.Unix(finished, 0)
+}
+
+func parseLeaderboardKey(key string) (int, time.Time, string) {
+	parts := strings.SplitN(key, ",", 3)
+	if len(parts) != 3 {
+		slog.Warn("received a leaderboard key without two ',' delimiters", "key", key)
+		return 0, time.Time{}, ""
+	}
+	page, err := strconv.Atoi(parts[0])
+	if err != nil {
+		slog.Warn("received a leaderboard key with an invalid page", "key", key)
+		return 0, time.Time{}, ""
+	}
+	created, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		slog.Warn("received a leaderboard key with an invalid created time", "key", key)
+		return 0, time.Time{}, ""
+	}
+	return page, time.Unix(created, 0), parts[2]
+}
@@ -31,7 +31,7 @@ func main() {
 	db.SetMaxOpenConns(1)
 	db.SetMaxIdleConns(1)
 
-	if _, err := db.Exec(app.CreateSchema); err != nil {
+	if err := app.MigrateSchema(db); err != nil {
 		log.Fatalf("failed to create schema: %v", err)
 	}
 
@@ -40,15 +40,27 @@ func main() {
 		_ = dg.Close()
 	}()
 
-	sh, err := app.StartNTestShell(path)
-	if err != nil {
-		log.Fatalf("failed to open ntest shell: %v", err)
+	var sh app.EngineShell
+	if path == "" {
+		slog.Warn("ntest path is empty, falling back to the built-in engine")
+		sh = app.MakeLocalEngineShell()
+	} else {
+		pool, err := app.StartEnginePool(path, app.ParseEngineWorkers(os.Getenv("NTEST_WORKERS")))
+		if err != nil {
+			log.Fatalf("failed to open ntest shell: %v", err)
+		}
+		defer func() {
+			_ = pool.Close()
+		}()
+		go pool.ListenRequests()
+		sh = pool
+	}
+	if os.Getenv("NTEST_PONDER") == "true" {
+		sh = app.MakePonderShell(sh)
 	}
-
-	go sh.ListenRequests()
-	go app.ExpireGamesCron(db)
 
 	state := app.MakeState(db, dg, sh)
+	go app.ExpireGamesCron(db, dg, state.GameTtl, state.NotifyExpiredGame)
 	dg.AddHandler(state.HandeInteractionCreate)
 
 	signalChan := make(chan os.Signal, 1)
@@ -15,7 +15,7 @@ func main() {
 	defer func() {
 		_ = db.Close()
 	}()
-	if _, err := db.Exec(app.CreateSchema); err != nil {
+	if err := app.MigrateSchema(db); err != nil {
 		log.Fatalf("failed to create schema: %v", err)
 	}
 }